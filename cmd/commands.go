@@ -0,0 +1,468 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/Magic-Kot/effective-mobile/internal/config"
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+	"github.com/Magic-Kot/effective-mobile/internal/repository/postgres"
+	"github.com/Magic-Kot/effective-mobile/internal/services/auth"
+	"github.com/Magic-Kot/effective-mobile/internal/services/backup"
+	"github.com/Magic-Kot/effective-mobile/internal/services/bulk"
+	"github.com/Magic-Kot/effective-mobile/internal/validation"
+	"github.com/Magic-Kot/effective-mobile/pkg/client/postg"
+	"github.com/Magic-Kot/effective-mobile/pkg/jwks"
+	"github.com/Magic-Kot/effective-mobile/pkg/migrator"
+	"github.com/Magic-Kot/effective-mobile/pkg/musicinfo"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	errUnknownCommand = errors.New("unknown command")
+	errImportFile     = errors.New("import: exactly one FILE is required, use - for the standard input")
+	errExportFile     = errors.New("export: exactly one FILE is required")
+	errBackupFile     = errors.New("exactly one FILE is required")
+	errApiKeyUsage    = errors.New("apikey: the create or revoke action is required")
+	errTokenUsage     = errors.New("token: the subject and at least one ROLE are required")
+	errNoStaticKey    = errors.New("token: JWT_STATIC_KEY is not set")
+)
+
+// runCommand - run the subcommand given on the command line: migrate, import, export, backup, restore, apikey, token
+func runCommand(ctx context.Context, cfg config.Config, command string, args []string) error {
+	switch command {
+	case "migrate":
+		return runMigrate(ctx, cfg, args)
+	case "import":
+		return runImport(ctx, cfg, args)
+	case "export":
+		return runExport(ctx, cfg, args)
+	case "backup":
+		return runBackup(ctx, cfg, args)
+	case "restore":
+		return runRestore(ctx, cfg, args)
+	case "apikey":
+		return runApiKey(ctx, cfg, args)
+	case "token":
+		return runToken(cfg, args)
+	}
+
+	return fmt.Errorf("%w: %s", errUnknownCommand, command)
+}
+
+// runMigrate - manage the database schema, see migrator.Usage
+func runMigrate(ctx context.Context, cfg config.Config, args []string) error {
+	if len(args) == 0 {
+		fmt.Println(migrator.Usage)
+		return nil
+	}
+
+	if args[0] == "create" {
+		return migrator.Create(cfg.MigrationDeps.Dir, args[1:])
+	}
+
+	pool, err := postg.NewClient(ctx, postgresDeps(cfg))
+	if err != nil {
+		return err
+	}
+
+	defer pool.Close()
+
+	migrations, err := migrator.NewMigrator(pool, &migrator.ConfigDeps{FS: embedMigrations, Dir: "migrations"})
+	if err != nil {
+		return err
+	}
+
+	return migrations.Run(ctx, args[0], args[1:])
+}
+
+// runImport - add the songs of a CSV or NDJSON file and print the report as JSON
+func runImport(ctx context.Context, cfg config.Config, args []string) error {
+	var req models.ImportSongsRequest
+
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: import [flags] FILE")
+		flags.PrintDefaults()
+	}
+
+	flags.StringVar(&req.Format, "format", models.FormatCSV, "file format: csv or ndjson")
+	flags.BoolVar(&req.DryRun, "dry-run", false, "check the rows without saving them")
+	flags.BoolVar(&req.Enrich, "enrich", false, "fill the missing details from the music info service")
+	flags.IntVar(&req.BatchSize, "batch-size", 0, "rows imported in one transaction, 100 by default")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errImportFile
+	}
+
+	var file io.Reader = os.Stdin
+
+	if name := flags.Arg(0); name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+
+		defer f.Close()
+
+		file = f
+	}
+
+	pool, err := postg.NewClient(ctx, postgresDeps(cfg))
+	if err != nil {
+		return err
+	}
+
+	defer pool.Close()
+
+	report, err := newBulkService(cfg, pool).ImportSongs(ctx, req, file)
+	if err != nil {
+		return err
+	}
+
+	return printJSON(report)
+}
+
+// runExport - write the songs matching the filters to the file, the standard output is taken by the logs
+func runExport(ctx context.Context, cfg config.Config, args []string) (err error) {
+	var (
+		req    models.RequestGetAll
+		format string
+	)
+
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: export [flags] FILE")
+		flags.PrintDefaults()
+	}
+
+	flags.StringVar(&format, "format", models.FormatNDJSON, "file format: json, ndjson or csv")
+	flags.StringVar(&req.Filter, "filter", "", "song column to filter by")
+	flags.StringVar(&req.Value, "value", "", "required value of the filter column")
+	flags.StringVar(&req.CreatedAfter, "created-after", "", "songs created at or after the RFC 3339 timestamp")
+	flags.StringVar(&req.CreatedBefore, "created-before", "", "songs created before the RFC 3339 timestamp")
+	flags.StringVar(&req.UpdatedAfter, "updated-after", "", "songs updated at or after the RFC 3339 timestamp")
+	flags.StringVar(&req.UpdatedBefore, "updated-before", "", "songs updated before the RFC 3339 timestamp")
+	flags.StringVar(&req.Artist, "artist", "", "songs crediting the music group")
+	flags.StringVar(&req.Role, "role", "", "songs crediting a music group with the role")
+	flags.StringVar(&req.Genre, "genre", "", "songs of any of the comma separated genres")
+	flags.StringVar(&req.Tag, "tag", "", "songs with any of the comma separated tags")
+
+	if err = flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errExportFile
+	}
+
+	pool, err := postg.NewClient(ctx, postgresDeps(cfg))
+	if err != nil {
+		return err
+	}
+
+	defer pool.Close()
+
+	file, err := os.Create(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	buffered := bufio.NewWriter(file)
+
+	if _, err = newBulkService(cfg, pool).ExportSongs(ctx, format, req, buffered); err != nil {
+		return err
+	}
+
+	return buffered.Flush()
+}
+
+// runBackup - write all the tables of the library to a compressed archive
+func runBackup(ctx context.Context, cfg config.Config, args []string) (err error) {
+	flags := flag.NewFlagSet("backup", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: backup FILE")
+	}
+
+	if err = flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errBackupFile
+	}
+
+	pool, err := postg.NewClient(ctx, postgresDeps(cfg))
+	if err != nil {
+		return err
+	}
+
+	defer pool.Close()
+
+	backupService, err := newBackupService(cfg, pool)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	buffered := bufio.NewWriter(file)
+
+	report, err := backupService.Backup(ctx, buffered)
+	if err != nil {
+		return err
+	}
+
+	if err = buffered.Flush(); err != nil {
+		return err
+	}
+
+	return printJSON(report)
+}
+
+// runRestore - load the archive written by the backup, the report is printed even if there are conflicts
+func runRestore(ctx context.Context, cfg config.Config, args []string) error {
+	var req models.RestoreRequest
+
+	flags := flag.NewFlagSet("restore", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: restore [flags] FILE")
+		flags.PrintDefaults()
+	}
+
+	flags.BoolVar(&req.DryRun, "dry-run", false, "check the archive and its conflicts without saving it")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errBackupFile
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	pool, err := postg.NewClient(ctx, postgresDeps(cfg))
+	if err != nil {
+		return err
+	}
+
+	defer pool.Close()
+
+	backupService, err := newBackupService(cfg, pool)
+	if err != nil {
+		return err
+	}
+
+	report, err := backupService.Restore(ctx, req, bufio.NewReader(file))
+	if len(report.Tables) > 0 {
+		if printErr := printJSON(report); printErr != nil && err == nil {
+			err = printErr
+		}
+	}
+
+	return err
+}
+
+// runApiKey - issue or revoke an API key without the API, the first admin key is issued this way
+func runApiKey(ctx context.Context, cfg config.Config, args []string) error {
+	var (
+		req models.CreateApiKey
+		id  int
+	)
+
+	flags := flag.NewFlagSet("apikey", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: apikey create -name NAME [-role ROLE] | apikey revoke -id ID")
+		flags.PrintDefaults()
+	}
+
+	flags.StringVar(&req.Name, "name", "", "name of the new key")
+	flags.StringVar(&req.Role, "role", models.RoleAdmin, "role of the new key: reader, editor or admin")
+	flags.IntVar(&id, "id", 0, "ID of the key to revoke")
+
+	if len(args) == 0 || (args[0] != "create" && args[0] != "revoke") {
+		flags.Usage()
+		return errApiKeyUsage
+	}
+
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	pool, err := postg.NewClient(ctx, postgresDeps(cfg))
+	if err != nil {
+		return err
+	}
+
+	defer pool.Close()
+
+	authService := auth.NewAuthService(postgres.NewApiKeyRepository(pool), nil)
+
+	if args[0] == "revoke" {
+		return authService.RevokeApiKey(ctx, id)
+	}
+
+	if err = validation.New().Struct(&req); err != nil {
+		return err
+	}
+
+	key, err := authService.IssueApiKey(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return printJSON(key)
+}
+
+// runToken - print a bearer token signed with the static key, for the local tests
+func runToken(cfg config.Config, args []string) error {
+	var (
+		subject string
+		ttl     time.Duration
+	)
+
+	flags := flag.NewFlagSet("token", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: token -sub SUBJECT [-ttl DURATION] ROLE...")
+		flags.PrintDefaults()
+	}
+
+	flags.StringVar(&subject, "sub", "", "subject of the token")
+	flags.DurationVar(&ttl, "ttl", time.Hour, "lifetime of the token")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if subject == "" || flags.NArg() == 0 {
+		flags.Usage()
+		return errTokenUsage
+	}
+
+	if cfg.TokenDeps.StaticKey == "" {
+		return errNoStaticKey
+	}
+
+	// the token is checked like the ones of the SSO, so it has their issuer, audience and role claim
+	tokens := auth.NewTokenVerifier(auth.TokenDeps{
+		StaticKey: []byte(cfg.TokenDeps.StaticKey),
+		Issuer:    cfg.TokenDeps.Issuer,
+		Audience:  cfg.TokenDeps.Audience,
+		RoleClaim: cfg.TokenDeps.RoleClaim,
+	})
+
+	token, err := tokens.IssueToken(subject, flags.Args(), ttl)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(token)
+
+	return nil
+}
+
+// printJSON - print the report of the command
+func printJSON(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(v)
+}
+
+func newBackupService(cfg config.Config, pool *sqlx.DB) (*backup.BackupService, error) {
+	migrations, err := migrator.NewMigrator(pool, &migrator.ConfigDeps{FS: embedMigrations, Dir: "migrations"})
+	if err != nil {
+		return nil, err
+	}
+
+	txCfg := postg.TxConfigDeps{
+		MaxRetries: cfg.PostgresDeps.TxMaxRetries,
+		RetryDelay: cfg.PostgresDeps.TxRetryDelay,
+	}
+
+	return backup.NewBackupService(postgres.NewBackupRepository(pool), postg.NewTxManager(pool, &txCfg), migrations), nil
+}
+
+func newBulkService(cfg config.Config, pool *sqlx.DB) *bulk.BulkService {
+	txCfg := postg.TxConfigDeps{
+		MaxRetries: cfg.PostgresDeps.TxMaxRetries,
+		RetryDelay: cfg.PostgresDeps.TxRetryDelay,
+	}
+
+	return bulk.NewBulkService(
+		postgres.NewSongRepository(pool),
+		postg.NewTxManager(pool, &txCfg),
+		musicinfo.NewMusicInfo(&musicinfo.ConfigDeps{
+			URL:        cfg.MusicInfo.Url,
+			HTTPClient: &http.Client{Timeout: cfg.MusicInfo.Timeout},
+		}),
+		validation.New(),
+	)
+}
+
+// newTokenVerifier - the verifier of the bearer tokens, nil if they are not configured
+func newTokenVerifier(ctx context.Context, cfg config.Config) (*auth.TokenVerifier, error) {
+	deps := auth.TokenDeps{
+		StaticKey: []byte(cfg.TokenDeps.StaticKey),
+		Issuer:    cfg.TokenDeps.Issuer,
+		Audience:  cfg.TokenDeps.Audience,
+		RoleClaim: cfg.TokenDeps.RoleClaim,
+		RoleMap:   cfg.TokenDeps.RoleMap,
+		Leeway:    cfg.TokenDeps.Leeway,
+	}
+
+	if cfg.TokenDeps.JwksFile != "" || cfg.TokenDeps.JwksURL != "" {
+		keys, err := jwks.NewKeySet(ctx, &jwks.ConfigDeps{
+			File:    cfg.TokenDeps.JwksFile,
+			URL:     cfg.TokenDeps.JwksURL,
+			Refresh: cfg.TokenDeps.JwksRefresh,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		deps.Keys = keys
+	} else if len(deps.StaticKey) == 0 {
+		return nil, nil
+	}
+
+	return auth.NewTokenVerifier(deps), nil
+}
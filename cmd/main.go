@@ -3,32 +3,60 @@ package main
 import (
 	"context"
 	"embed"
+	"net/http"
+	"os"
 	"time"
 
 	"github.com/Magic-Kot/effective-mobile/internal/config"
 	"github.com/Magic-Kot/effective-mobile/internal/controllers"
+	"github.com/Magic-Kot/effective-mobile/internal/controllers/apiv2"
+	"github.com/Magic-Kot/effective-mobile/internal/delivery/grpcapi"
 	"github.com/Magic-Kot/effective-mobile/internal/delivery/httpecho"
+	"github.com/Magic-Kot/effective-mobile/internal/graph"
+	"github.com/Magic-Kot/effective-mobile/internal/metrics"
 	"github.com/Magic-Kot/effective-mobile/internal/repository/postgres"
+	"github.com/Magic-Kot/effective-mobile/internal/services/album"
+	"github.com/Magic-Kot/effective-mobile/internal/services/auth"
+	"github.com/Magic-Kot/effective-mobile/internal/services/bulk"
+	"github.com/Magic-Kot/effective-mobile/internal/services/classification"
+	"github.com/Magic-Kot/effective-mobile/internal/services/group"
+	"github.com/Magic-Kot/effective-mobile/internal/services/listening"
+	"github.com/Magic-Kot/effective-mobile/internal/services/playlist"
 	"github.com/Magic-Kot/effective-mobile/internal/services/song"
+	"github.com/Magic-Kot/effective-mobile/internal/validation"
 	"github.com/Magic-Kot/effective-mobile/pkg/client/postg"
+	"github.com/Magic-Kot/effective-mobile/pkg/grpcserver"
 	"github.com/Magic-Kot/effective-mobile/pkg/httpserver"
 	"github.com/Magic-Kot/effective-mobile/pkg/logging"
+	"github.com/Magic-Kot/effective-mobile/pkg/migrator"
 	"github.com/Magic-Kot/effective-mobile/pkg/musicinfo"
 	"github.com/Magic-Kot/effective-mobile/pkg/ossignal"
+	"github.com/Magic-Kot/effective-mobile/pkg/ratelimit"
+	"github.com/Magic-Kot/effective-mobile/pkg/tracing"
 
-	"github.com/go-playground/validator/v10"
 	"github.com/ilyakaznacheev/cleanenv"
+	"github.com/labstack/echo/v4"
 	_ "github.com/lib/pq"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
-	"github.com/speakeasy-api/goose/v3"
 	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
 )
 
 // @title Online Song Library
 // @version 1.0
 // @description This project was developed as part of a test assignment from Effective Mobile
 
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @description The API key issued by an admin, see the README
+
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description "Bearer TOKEN" with a token of the SSO
+
 //go:embed migrations/*.sql
 var embedMigrations embed.FS
 
@@ -56,7 +84,28 @@ func main() {
 	ctx := context.Background()
 	ctx = logger.WithContext(ctx)
 
-	logger.Info().Msgf("config: %+v", cfg)
+	logger.Info().Msgf("config: %+v", cfg.Redacted())
+
+	// run a subcommand instead of the server
+	if len(os.Args) > 1 {
+		if err := runCommand(ctx, cfg, os.Args[1], os.Args[2:]); err != nil {
+			logger.Fatal().Err(err).Msgf("command %s", os.Args[1])
+		}
+
+		return
+	}
+
+	// tracing
+	tracer, err := tracing.NewProvider(ctx, &tracing.ConfigDeps{
+		Exporter:    cfg.TracingDeps.Exporter,
+		Endpoint:    cfg.TracingDeps.Endpoint,
+		Insecure:    cfg.TracingDeps.Insecure,
+		SampleRatio: cfg.TracingDeps.SampleRatio,
+		ServiceName: cfg.TracingDeps.ServiceName,
+	})
+	if err != nil {
+		logger.Fatal().Err(err).Msg("tracing.NewProvider")
+	}
 
 	// create server
 	serv := httpserver.ConfigDeps{
@@ -67,44 +116,162 @@ func main() {
 
 	server := httpserver.NewServer(&serv)
 
-	// create client Postgres
-	repo := postg.ConfigDeps{
-		MaxAttempts: cfg.PostgresDeps.MaxAttempts,
-		Delay:       cfg.PostgresDeps.Delay,
-		Username:    cfg.PostgresDeps.Username,
-		Password:    cfg.PostgresDeps.Password,
-		Host:        cfg.PostgresDeps.Host,
-		Port:        cfg.PostgresDeps.Port,
-		Database:    cfg.PostgresDeps.Database,
-		SSLMode:     cfg.PostgresDeps.SSLMode,
-	}
+	// the spans and the metrics of the requests, the metrics are served by the admin server
+	metric := metrics.NewMetrics()
+	server.Server().Use(httpecho.Tracing(), httpecho.Metrics(metric))
 
-	pool, err := postg.NewClient(ctx, &repo)
+	adminServer := httpserver.NewServer(&httpserver.ConfigDeps{
+		Host:    cfg.AdminDeps.Host,
+		Port:    cfg.AdminDeps.Port,
+		Timeout: cfg.ServerDeps.Timeout,
+	})
+
+	httpecho.SetMetricsRoutes(adminServer.Server(), metric)
+
+	// create client Postgres
+	pool, err := postg.NewClient(ctx, postgresDeps(cfg))
 	if err != nil {
 		logger.Fatal().Err(err).Msgf("NewClient: %s", err)
 	}
 
+	metric.RegisterDB(pool.DB, cfg.PostgresDeps.Database)
+	metric.RegisterTotals(postgres.NewMetricsRepository(pool), logger)
+
 	// migrations
-	goose.SetBaseFS(embedMigrations)
+	if cfg.MigrationDeps.AutoMigrate {
+		migrations, err := migrator.NewMigrator(pool, &migrator.ConfigDeps{FS: embedMigrations, Dir: "migrations"})
+		if err != nil {
+			logger.Fatal().Err(err).Msg("NewMigrator")
+		}
+
+		if err := migrations.Up(ctx); err != nil {
+			logger.Fatal().Err(err).Msg("applying migrations")
+		}
+	}
 
-	if err := goose.SetDialect("postgres"); err != nil {
-		panic(err)
+	// create validator and the translator of its messages
+	validate := validation.New()
+
+	translator, err := validation.NewTranslator(validate)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("NewTranslator")
+	}
+
+	server.Server().HTTPErrorHandler = httpecho.ProblemErrorHandler(logger, translator)
+
+	// API keys and bearer tokens
+	apiKeyRepository := postgres.NewApiKeyRepository(pool)
+	tokenVerifier, err := newTokenVerifier(ctx, cfg)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("newTokenVerifier")
 	}
 
-	if err := goose.Up(pool, "migrations"); err != nil {
-		panic(err)
+	authService := auth.NewAuthService(apiKeyRepository, tokenVerifier)
+
+	// rate limits, the clients are the API keys and the token subjects, or the addresses of the other requests.
+	// The failed authentications are limited by address before the credentials are checked.
+	limiter := ratelimit.NewLimiter(&ratelimit.ConfigDeps{
+		Read:     ratelimit.Limit{Requests: cfg.RateLimitDeps.ReadRequests, Period: cfg.RateLimitDeps.Period},
+		Write:    ratelimit.Limit{Requests: cfg.RateLimitDeps.WriteRequests, Period: cfg.RateLimitDeps.Period},
+		Failures: ratelimit.Limit{Requests: cfg.RateLimitDeps.AuthFailures, Period: cfg.RateLimitDeps.Period},
+	})
+
+	server.Server().IPExtractor = echo.ExtractIPDirect()
+	if cfg.RateLimitDeps.TrustProxy {
+		server.Server().IPExtractor = echo.ExtractIPFromXFFHeader()
 	}
 
-	// create validator
-	validate := validator.New()
+	server.Server().Use(
+		httpecho.RateLimitFailures(limiter, logger),
+		httpecho.Authenticate(authService, logger),
+		httpecho.RateLimit(limiter, logger),
+	)
+
+	// create transaction manager
+	txCfg := postg.TxConfigDeps{
+		MaxRetries: cfg.PostgresDeps.TxMaxRetries,
+		RetryDelay: cfg.PostgresDeps.TxRetryDelay,
+	}
+
+	txManager := postg.NewTxManager(pool, &txCfg)
 
 	// Song
 	songRepository := postgres.NewSongRepository(pool)
-	musicInfo := musicinfo.NewMusicInfo(cfg.MusicInfo.Url)
-	songService := song.NewSongService(songRepository, musicInfo)
+	musicInfo := musicinfo.NewMusicInfo(&musicinfo.ConfigDeps{
+		URL:        cfg.MusicInfo.Url,
+		HTTPClient: &http.Client{Transport: tracing.NewTransport(nil), Timeout: cfg.MusicInfo.Timeout},
+		Observe:    metric.ObserveMusicInfo,
+	})
+	songService := song.NewSongService(songRepository, txManager, musicInfo, cfg.MusicInfo.Required, validate)
 	songController := controllers.NewApiController(songService, logger, validate)
 	httpecho.SetSongRoutes(server.Server(), songController)
 
+	// Album
+	albumRepository := postgres.NewAlbumRepository(pool)
+	albumService := album.NewAlbumService(albumRepository, songRepository, txManager)
+	albumController := controllers.NewAlbumController(albumService, logger, validate)
+	httpecho.SetAlbumRoutes(server.Server(), albumController)
+
+	// Genres and tags
+	classificationRepository := postgres.NewClassificationRepository(pool)
+	classificationService := classification.NewClassificationService(classificationRepository, txManager)
+	classificationController := controllers.NewClassificationController(classificationService, logger, validate)
+	httpecho.SetClassificationRoutes(server.Server(), classificationController)
+
+	// Playlist
+	playlistRepository := postgres.NewPlaylistRepository(pool)
+	playlistService := playlist.NewPlaylistService(playlistRepository, txManager)
+	playlistController := controllers.NewPlaylistController(playlistService, logger, validate)
+	httpecho.SetPlaylistRoutes(server.Server(), playlistController)
+
+	// Bulk import
+	bulkService := bulk.NewBulkService(songRepository, txManager, musicInfo, validate)
+	bulkController := controllers.NewBulkController(bulkService, logger, validate)
+	httpecho.SetBulkRoutes(server.Server(), bulkController)
+
+	// Music group
+	groupRepository := postgres.NewGroupRepository(pool)
+	groupService := group.NewGroupService(groupRepository, txManager)
+
+	// Favorites, ratings and plays
+	listeningRepository := postgres.NewListeningRepository(pool)
+	listeningService := listening.NewListeningService(listeningRepository, songRepository, txManager)
+
+	// GraphQL
+	graphRepository := postgres.NewGraphRepository(pool)
+
+	graphqlHandler, err := graph.NewHandler(songService, groupService, graphRepository, songRepository, groupRepository, logger)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("graph.NewHandler")
+	}
+
+	httpecho.SetGraphQLRoutes(server.Server(), graphqlHandler)
+
+	// API v2
+	httpecho.SetApiV2Routes(server.Server(), httpecho.ApiV2Controllers{
+		Songs:          apiv2.NewSongController(songService, logger, validate),
+		Groups:         apiv2.NewGroupController(groupService, songService, albumService, logger),
+		Albums:         apiv2.NewAlbumController(albumService, logger, validate),
+		Playlists:      apiv2.NewPlaylistController(playlistService, logger, validate),
+		Classification: apiv2.NewClassificationController(classificationService, logger, validate),
+		SongsV1:        songController,
+		BulkV1:         bulkController,
+		PlaylistsV1:    playlistController,
+		ApiKeys:        apiv2.NewApiKeyController(authService, logger, validate),
+		Me:             apiv2.NewMeController(listeningService, logger, validate),
+	})
+
+	// gRPC
+	grpcServer := grpcserver.NewServer(
+		&grpcserver.ConfigDeps{Host: cfg.GrpcDeps.Host, Port: cfg.GrpcDeps.Port},
+		grpc.ChainUnaryInterceptor(grpcapi.UnaryInterceptor(logger), grpcapi.RateLimitFailuresUnaryInterceptor(limiter, logger),
+			grpcapi.AuthUnaryInterceptor(authService, logger), grpcapi.RateLimitUnaryInterceptor(limiter, logger)),
+		grpc.ChainStreamInterceptor(grpcapi.StreamInterceptor(logger), grpcapi.RateLimitFailuresStreamInterceptor(limiter, logger),
+			grpcapi.AuthStreamInterceptor(authService), grpcapi.RateLimitStreamInterceptor(limiter, logger)),
+	)
+
+	grpcapi.NewLibraryServer(songService, groupService, logger, validate).Register(grpcServer.Server())
+
 	runner, ctx := errgroup.WithContext(ctx)
 
 	// start server
@@ -117,6 +284,24 @@ func main() {
 		return nil
 	})
 
+	logger.Info().Msg("starting admin server")
+	runner.Go(func() error {
+		if err := adminServer.Start(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return errors.Wrap(err, "admin server")
+		}
+
+		return nil
+	})
+
+	logger.Info().Msg("starting gRPC server")
+	runner.Go(func() error {
+		if err := grpcServer.Start(); err != nil {
+			return errors.Wrap(err, "gRPC server")
+		}
+
+		return nil
+	})
+
 	runner.Go(func() error {
 		if err := ossignal.DefaultSignalWaiter(ctx); err != nil {
 			return errors.Wrap(err, "waiting os signal")
@@ -136,6 +321,18 @@ func main() {
 			logger.Error().Err(err).Msg("shutdown http server")
 		}
 
+		if err := adminServer.Shutdown(ctxSignal); err != nil {
+			logger.Error().Err(err).Msg("shutdown admin server")
+		}
+
+		if err := grpcServer.Shutdown(ctxSignal); err != nil {
+			logger.Error().Err(err).Msg("shutdown gRPC server")
+		}
+
+		if err := tracer.Shutdown(ctxSignal); err != nil {
+			logger.Error().Err(err).Msg("shutdown tracing")
+		}
+
 		return nil
 	})
 
@@ -148,3 +345,16 @@ func main() {
 		}
 	}
 }
+
+func postgresDeps(cfg config.Config) *postg.ConfigDeps {
+	return &postg.ConfigDeps{
+		MaxAttempts: cfg.PostgresDeps.MaxAttempts,
+		Delay:       cfg.PostgresDeps.Delay,
+		Username:    cfg.PostgresDeps.Username,
+		Password:    cfg.PostgresDeps.Password,
+		Host:        cfg.PostgresDeps.Host,
+		Port:        cfg.PostgresDeps.Port,
+		Database:    cfg.PostgresDeps.Database,
+		SSLMode:     cfg.PostgresDeps.SSLMode,
+	}
+}
@@ -27,7 +27,7 @@ CREATE TABLE IF NOT EXISTS mgs --music_group_songs
 
 -- +goose Down
 -- +goose StatementBegin
-DROP TABLE IF EXISTS music_group_songs;
+DROP TABLE IF EXISTS mgs;
 DROP TABLE IF EXISTS music_group;
 DROP TABLE IF EXISTS songs;
 -- +goose StatementEnd
\ No newline at end of file
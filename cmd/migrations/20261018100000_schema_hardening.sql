@@ -0,0 +1,71 @@
+-- +goose Up
+-- +goose StatementBegin
+-- merge duplicated music groups into the one with the smallest id
+UPDATE mgs SET group_id = d.keep_id
+FROM (SELECT id, MIN(id) OVER (PARTITION BY group_name) AS keep_id FROM music_group) d
+WHERE mgs.group_id = d.id AND d.id <> d.keep_id;
+
+DELETE FROM music_group g USING music_group k WHERE g.group_name = k.group_name AND g.id > k.id;
+
+DELETE FROM mgs a USING mgs b WHERE a.group_id = b.group_id AND a.song_id = b.song_id AND a.id > b.id;
+
+DROP INDEX IF EXISTS music_group_name;
+
+ALTER TABLE music_group ADD CONSTRAINT music_group_group_name_key UNIQUE (group_name);
+ALTER TABLE mgs ADD CONSTRAINT mgs_group_id_song_id_key UNIQUE (group_id, song_id);
+
+CREATE INDEX IF NOT EXISTS mgs_song_id ON mgs (song_id);
+
+UPDATE songs SET text = '' WHERE text IS NULL;
+UPDATE songs SET link = '' WHERE link IS NULL;
+
+-- the lyrics are bounded, the longer ones must be shortened by hand before the migration
+DO $$
+DECLARE
+    long_ids TEXT;
+BEGIN
+    SELECT string_agg(id::TEXT, ', ' ORDER BY id) INTO long_ids FROM songs WHERE char_length(text) > 50000;
+
+    IF long_ids IS NOT NULL THEN
+        RAISE EXCEPTION 'the lyrics of the songs % are longer than 50000 characters', long_ids;
+    END IF;
+END $$;
+
+ALTER TABLE songs
+    ALTER COLUMN text TYPE VARCHAR(50000),
+    ALTER COLUMN text SET NOT NULL,
+    ALTER COLUMN link SET NOT NULL;
+
+ALTER TABLE songs
+    ADD COLUMN created_at  TIMESTAMPTZ  NOT NULL  DEFAULT now(),
+    ADD COLUMN updated_at  TIMESTAMPTZ  NOT NULL  DEFAULT now();
+
+ALTER TABLE music_group
+    ADD COLUMN created_at  TIMESTAMPTZ  NOT NULL  DEFAULT now(),
+    ADD COLUMN updated_at  TIMESTAMPTZ  NOT NULL  DEFAULT now();
+
+CREATE INDEX IF NOT EXISTS songs_created_at ON songs (created_at);
+CREATE INDEX IF NOT EXISTS songs_updated_at ON songs (updated_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- removed duplicates are not restored
+DROP INDEX IF EXISTS songs_updated_at;
+DROP INDEX IF EXISTS songs_created_at;
+
+ALTER TABLE music_group DROP COLUMN IF EXISTS updated_at, DROP COLUMN IF EXISTS created_at;
+ALTER TABLE songs DROP COLUMN IF EXISTS updated_at, DROP COLUMN IF EXISTS created_at;
+
+ALTER TABLE songs
+    ALTER COLUMN link DROP NOT NULL,
+    ALTER COLUMN text DROP NOT NULL,
+    ALTER COLUMN text TYPE VARCHAR;
+
+DROP INDEX IF EXISTS mgs_song_id;
+
+ALTER TABLE mgs DROP CONSTRAINT IF EXISTS mgs_group_id_song_id_key;
+ALTER TABLE music_group DROP CONSTRAINT IF EXISTS music_group_group_name_key;
+
+CREATE INDEX IF NOT EXISTS music_group_name ON music_group (group_name);
+-- +goose StatementEnd
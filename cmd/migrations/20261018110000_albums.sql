@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS albums
+(
+    id            SERIAL         PRIMARY KEY,
+    group_id      INTEGER        references music_group (id) on delete cascade    NOT NULL,
+    title         VARCHAR        NOT NULL,
+    release_date  VARCHAR        NOT NULL    DEFAULT(''),
+    cover_link    VARCHAR        NOT NULL    DEFAULT(''),
+    created_at    TIMESTAMPTZ    NOT NULL    DEFAULT now(),
+    updated_at    TIMESTAMPTZ    NOT NULL    DEFAULT now(),
+    CONSTRAINT albums_group_id_title_key UNIQUE (group_id, title)
+);
+
+ALTER TABLE songs
+    ADD COLUMN album_id      INTEGER    references albums (id) on delete set null,
+    ADD COLUMN track_number  INTEGER    CHECK (track_number > 0);
+
+-- deferred, so the track list can be reordered in one transaction
+ALTER TABLE songs ADD CONSTRAINT songs_album_id_track_number_key UNIQUE (album_id, track_number) DEFERRABLE INITIALLY DEFERRED;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE songs DROP CONSTRAINT IF EXISTS songs_album_id_track_number_key;
+ALTER TABLE songs DROP COLUMN IF EXISTS track_number, DROP COLUMN IF EXISTS album_id;
+DROP TABLE IF EXISTS albums;
+-- +goose StatementEnd
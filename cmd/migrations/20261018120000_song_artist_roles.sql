@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE mgs ADD COLUMN role VARCHAR NOT NULL DEFAULT 'primary';
+
+ALTER TABLE mgs ADD CONSTRAINT mgs_role_check CHECK (role IN ('primary', 'featured', 'composer', 'lyricist'));
+
+-- a group can be credited on one song with several roles
+ALTER TABLE mgs DROP CONSTRAINT IF EXISTS mgs_group_id_song_id_key;
+ALTER TABLE mgs ADD CONSTRAINT mgs_group_id_song_id_role_key UNIQUE (group_id, song_id, role);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM mgs WHERE role <> 'primary';
+
+ALTER TABLE mgs DROP CONSTRAINT IF EXISTS mgs_group_id_song_id_role_key;
+ALTER TABLE mgs ADD CONSTRAINT mgs_group_id_song_id_key UNIQUE (group_id, song_id);
+
+ALTER TABLE mgs DROP CONSTRAINT IF EXISTS mgs_role_check;
+ALTER TABLE mgs DROP COLUMN IF EXISTS role;
+-- +goose StatementEnd
@@ -0,0 +1,55 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS genres
+(
+    id      SERIAL     PRIMARY KEY,
+    name    VARCHAR    NOT NULL    UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS tags
+(
+    id      SERIAL     PRIMARY KEY,
+    name    VARCHAR    NOT NULL    UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS song_genres
+(
+    song_id     INTEGER    references songs (id) on delete cascade     NOT NULL,
+    genre_id    INTEGER    references genres (id) on delete cascade    NOT NULL,
+    PRIMARY KEY (song_id, genre_id)
+);
+
+CREATE TABLE IF NOT EXISTS song_tags
+(
+    song_id    INTEGER    references songs (id) on delete cascade    NOT NULL,
+    tag_id     INTEGER    references tags (id) on delete cascade     NOT NULL,
+    PRIMARY KEY (song_id, tag_id)
+);
+
+CREATE TABLE IF NOT EXISTS group_genres
+(
+    group_id    INTEGER    references music_group (id) on delete cascade    NOT NULL,
+    genre_id    INTEGER    references genres (id) on delete cascade         NOT NULL,
+    PRIMARY KEY (group_id, genre_id)
+);
+
+CREATE TABLE IF NOT EXISTS group_tags
+(
+    group_id    INTEGER    references music_group (id) on delete cascade    NOT NULL,
+    tag_id      INTEGER    references tags (id) on delete cascade           NOT NULL,
+    PRIMARY KEY (group_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS song_genres_genre_id ON song_genres (genre_id);
+CREATE INDEX IF NOT EXISTS song_tags_tag_id ON song_tags (tag_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS group_tags;
+DROP TABLE IF EXISTS group_genres;
+DROP TABLE IF EXISTS song_tags;
+DROP TABLE IF EXISTS song_genres;
+DROP TABLE IF EXISTS tags;
+DROP TABLE IF EXISTS genres;
+-- +goose StatementEnd
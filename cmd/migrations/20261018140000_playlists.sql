@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS playlists
+(
+    id             SERIAL         PRIMARY KEY,
+    name           VARCHAR        NOT NULL,
+    owner          VARCHAR        NOT NULL,
+    description    VARCHAR        NOT NULL    DEFAULT(''),
+    created_at     TIMESTAMPTZ    NOT NULL    DEFAULT now(),
+    updated_at     TIMESTAMPTZ    NOT NULL    DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS playlists_owner ON playlists (owner);
+
+CREATE TABLE IF NOT EXISTS playlist_entries
+(
+    id             SERIAL     PRIMARY KEY,
+    playlist_id    INTEGER    references playlists (id) on delete cascade    NOT NULL,
+    position       INTEGER    NOT NULL    CHECK (position > 0),
+    song_id        INTEGER    references songs (id) on delete cascade        NOT NULL
+);
+
+-- deferred, so entries can be shifted and reordered in one transaction
+ALTER TABLE playlist_entries ADD CONSTRAINT playlist_entries_playlist_id_position_key
+    UNIQUE (playlist_id, position) DEFERRABLE INITIALLY DEFERRED;
+
+CREATE INDEX IF NOT EXISTS playlist_entries_song_id ON playlist_entries (song_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS playlist_entries;
+DROP TABLE IF EXISTS playlists;
+-- +goose StatementEnd
@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS api_keys
+(
+    id             SERIAL         PRIMARY KEY,
+    name           VARCHAR        NOT NULL,
+    role           VARCHAR        NOT NULL    CHECK (role IN ('reader', 'editor', 'admin')),
+    prefix         VARCHAR        NOT NULL,
+    key_hash       CHAR(64)       NOT NULL    UNIQUE,
+    created_by     INTEGER        references api_keys (id),
+    created_at     TIMESTAMPTZ    NOT NULL    DEFAULT now(),
+    revoked_at     TIMESTAMPTZ
+);
+
+-- the writes of the API attributed to the calling key, kept after the key is revoked
+CREATE TABLE IF NOT EXISTS api_key_writes
+(
+    id             BIGSERIAL      PRIMARY KEY,
+    key_id         INTEGER        references api_keys (id)    NOT NULL,
+    method         VARCHAR        NOT NULL,
+    route          VARCHAR        NOT NULL,
+    path           VARCHAR        NOT NULL,
+    status         INTEGER        NOT NULL,
+    created_at     TIMESTAMPTZ    NOT NULL    DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS api_key_writes_key_id ON api_key_writes (key_id, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS api_key_writes;
+DROP TABLE IF EXISTS api_keys;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the writes made with a bearer token have the subject of the token instead of a key
+ALTER TABLE api_key_writes ALTER COLUMN key_id DROP NOT NULL;
+ALTER TABLE api_key_writes ADD COLUMN IF NOT EXISTS subject VARCHAR;
+ALTER TABLE api_key_writes ADD CONSTRAINT api_key_writes_caller CHECK (key_id IS NOT NULL OR subject IS NOT NULL);
+
+-- the keys issued by an admin signed in with a bearer token have its subject instead of a key
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS created_by_subject VARCHAR;
+
+CREATE INDEX IF NOT EXISTS api_key_writes_subject ON api_key_writes (subject, id) WHERE subject IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS api_key_writes_subject;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS created_by_subject;
+
+DELETE FROM api_key_writes WHERE key_id IS NULL;
+ALTER TABLE api_key_writes DROP CONSTRAINT IF EXISTS api_key_writes_caller;
+ALTER TABLE api_key_writes DROP COLUMN IF EXISTS subject;
+ALTER TABLE api_key_writes ALTER COLUMN key_id SET NOT NULL;
+-- +goose StatementEnd
@@ -0,0 +1,55 @@
+-- +goose Up
+-- +goose StatementBegin
+-- the user is the subject of the bearer token or the API key, see auth.UserId
+CREATE TABLE IF NOT EXISTS song_favorites
+(
+    user_id        VARCHAR        NOT NULL,
+    song_id        INTEGER        references songs (id) on delete cascade    NOT NULL,
+    created_at     TIMESTAMPTZ    NOT NULL    DEFAULT now(),
+    PRIMARY KEY (user_id, song_id)
+);
+
+CREATE TABLE IF NOT EXISTS song_ratings
+(
+    user_id        VARCHAR        NOT NULL,
+    song_id        INTEGER        references songs (id) on delete cascade    NOT NULL,
+    rating         SMALLINT       NOT NULL    CHECK (rating BETWEEN 1 AND 5),
+    created_at     TIMESTAMPTZ    NOT NULL    DEFAULT now(),
+    updated_at     TIMESTAMPTZ    NOT NULL    DEFAULT now(),
+    PRIMARY KEY (user_id, song_id)
+);
+
+CREATE TABLE IF NOT EXISTS song_plays
+(
+    id             BIGSERIAL      PRIMARY KEY,
+    user_id        VARCHAR        NOT NULL,
+    song_id        INTEGER        references songs (id) on delete cascade    NOT NULL,
+    played_at      TIMESTAMPTZ    NOT NULL    DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS song_plays_user_id ON song_plays (user_id, id);
+
+-- the aggregates of the song listing, kept up to date with the favorites, ratings and plays
+CREATE TABLE IF NOT EXISTS song_stats
+(
+    song_id           INTEGER     PRIMARY KEY    references songs (id) on delete cascade,
+    favorite_count    INTEGER     NOT NULL    DEFAULT 0,
+    rating_count      INTEGER     NOT NULL    DEFAULT 0,
+    rating_sum        INTEGER     NOT NULL    DEFAULT 0,
+    play_count        BIGINT      NOT NULL    DEFAULT 0,
+    rating            NUMERIC(3, 2) GENERATED ALWAYS AS
+        (CASE WHEN rating_count > 0 THEN rating_sum::NUMERIC / rating_count ELSE 0 END) STORED
+);
+
+CREATE INDEX IF NOT EXISTS song_stats_rating ON song_stats (rating, song_id);
+CREATE INDEX IF NOT EXISTS song_stats_play_count ON song_stats (play_count, song_id);
+CREATE INDEX IF NOT EXISTS song_stats_favorite_count ON song_stats (favorite_count, song_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS song_stats;
+DROP TABLE IF EXISTS song_plays;
+DROP TABLE IF EXISTS song_ratings;
+DROP TABLE IF EXISTS song_favorites;
+-- +goose StatementEnd
@@ -0,0 +1,176 @@
+// songctl - manage the songs of the library from the command line through the /api/v2 routes
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Magic-Kot/effective-mobile/pkg/songlibclient"
+)
+
+const usage = `Usage: songctl [flags] COMMAND [flags] [args]
+
+Commands:
+  add       add a song
+  list      list the songs matching the filters
+  search    find the songs with the title, the group or the lyrics containing the text
+  get       show a song
+  verses    show the verses of the lyrics
+  update    update a song
+  delete    delete songs
+  import    import the songs of a csv or ndjson file
+  export    export the songs matching the filters
+
+Run 'songctl COMMAND -h' for the flags of the command.
+
+Flags:`
+
+const defaultRetryDelay = 200 * time.Millisecond
+
+var errUnknownCommand = errors.New("unknown command")
+
+// app - the client and the printer shared by the commands
+type app struct {
+	client *songlibclient.Client
+	out    *printer
+	stdout io.Writer
+	stdin  io.Reader
+}
+
+type command func(ctx context.Context, a *app, args []string) error
+
+var commands = map[string]command{
+	"add":    runAdd,
+	"list":   runList,
+	"search": runSearch,
+	"get":    runGet,
+	"verses": runVerses,
+	"update": runUpdate,
+	"delete": runDelete,
+	"import": runImport,
+	"export": runExport,
+}
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
+	err := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+
+	cancel()
+
+	switch {
+	case err == nil:
+	case errors.Is(err, flag.ErrHelp):
+		os.Exit(2)
+	default:
+		printError(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) error {
+	var (
+		path    string
+		name    string
+		baseURL string
+		output  string
+	)
+
+	flags := flag.NewFlagSet("songctl", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), usage)
+		flags.PrintDefaults()
+	}
+
+	flags.StringVar(&path, "config", configPath(), "profile file, SONGCTL_CONFIG overrides the default")
+	flags.StringVar(&name, "profile", "", "profile of the file, SONGCTL_PROFILE or the current one by default")
+	flags.StringVar(&baseURL, "url", "", "address of the server, overrides the profile")
+	flags.StringVar(&output, "o", "", "output format: table, json or yaml, overrides the profile")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return flag.ErrHelp
+	}
+
+	cmd, ok := commands[flags.Arg(0)]
+	if !ok {
+		flags.Usage()
+		return fmt.Errorf("%w: %s", errUnknownCommand, flags.Arg(0))
+	}
+
+	explicit := false
+	flags.Visit(func(f *flag.Flag) {
+		explicit = explicit || f.Name == "config"
+	})
+
+	p, err := loadProfile(path, name, explicit || os.Getenv("SONGCTL_CONFIG") != "")
+	if err != nil {
+		return err
+	}
+
+	if baseURL != "" {
+		p.URL = baseURL
+	}
+
+	if output != "" {
+		p.Output = output
+	}
+
+	out, err := newPrinter(stdout, p.Output)
+	if err != nil {
+		return err
+	}
+
+	header := make(http.Header)
+	if p.Language != "" {
+		header.Set("Accept-Language", p.Language)
+	}
+
+	client, err := songlibclient.NewClient(&songlibclient.ConfigDeps{
+		BaseURL:     p.URL,
+		HTTPClient:  &http.Client{Timeout: p.Timeout},
+		MaxRetries:  p.Retries,
+		RetryDelay:  defaultRetryDelay,
+		Header:      header,
+		APIKey:      p.APIKey,
+		BearerToken: p.Token,
+	})
+	if err != nil {
+		return err
+	}
+
+	return cmd(ctx, &app{client: client, out: out, stdout: stdout, stdin: stdin}, flags.Args()[1:])
+}
+
+// printError - the message of the error, with the fields breaking the validation rules if the server sent them
+func printError(w io.Writer, err error) {
+	var e *songlibclient.Error
+	if !errors.As(err, &e) {
+		fmt.Fprintf(w, "songctl: %v\n", err)
+		return
+	}
+
+	message := e.Detail
+	if message == "" {
+		message = e.Title
+	}
+
+	fmt.Fprintf(w, "songctl: %s (%s, %d)\n", message, e.Code, e.StatusCode)
+
+	for _, field := range e.Errors {
+		fmt.Fprintf(w, "  %s: %s\n", field.Field, field.Message)
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultProfile = "default"
+	defaultURL     = "http://localhost:8080"
+)
+
+// profile - the server and the defaults of one environment, e.g. local or production
+type profile struct {
+	URL      string        `yaml:"url"`
+	Output   string        `yaml:"output"`
+	Language string        `yaml:"language"`
+	Retries  int           `yaml:"retries"`
+	Timeout  time.Duration `yaml:"timeout"`
+	APIKey   string        `yaml:"api_key"`
+	Token    string        `yaml:"token"`
+}
+
+// profileFile - the profiles by name, current is used when no profile is asked for
+type profileFile struct {
+	Current  string             `yaml:"current"`
+	Profiles map[string]profile `yaml:"profiles"`
+}
+
+// configPath - SONGCTL_CONFIG or songctl/config.yaml in the user config directory
+func configPath() string {
+	if path := os.Getenv("SONGCTL_CONFIG"); path != "" {
+		return path
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "songctl", "config.yaml")
+}
+
+// loadProfile - the profile of the name, or of SONGCTL_PROFILE, or the current one of the file.
+// A missing file is not an error unless it was given explicitly, the defaults are used instead.
+// SONGCTL_API_KEY and SONGCTL_TOKEN take the place of the key and the bearer token of the profile.
+func loadProfile(path string, name string, explicit bool) (profile, error) {
+	res, err := readProfile(path, name, explicit)
+	if key := os.Getenv("SONGCTL_API_KEY"); key != "" {
+		res.APIKey = key
+	}
+
+	if token := os.Getenv("SONGCTL_TOKEN"); token != "" {
+		res.Token = token
+	}
+
+	return res, err
+}
+
+func readProfile(path string, name string, explicit bool) (profile, error) {
+	res := profile{URL: defaultURL, Output: outputTable, Retries: 2, Timeout: time.Minute}
+
+	var file profileFile
+
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err = yaml.Unmarshal(data, &file); err != nil {
+			return res, fmt.Errorf("config %s: %w", path, err)
+		}
+	case errors.Is(err, fs.ErrNotExist) && !explicit:
+	default:
+		return res, fmt.Errorf("config: %w", err)
+	}
+
+	if name == "" {
+		name = os.Getenv("SONGCTL_PROFILE")
+	}
+
+	asked := name != ""
+
+	if name == "" {
+		name = file.Current
+	}
+
+	if name == "" {
+		name = defaultProfile
+	}
+
+	p, ok := file.Profiles[name]
+	if !ok {
+		if asked || file.Current != "" {
+			return res, fmt.Errorf("config %s: no profile %q", path, name)
+		}
+
+		return res, nil
+	}
+
+	if p.URL != "" {
+		res.URL = p.URL
+	}
+
+	if p.Output != "" {
+		res.Output = p.Output
+	}
+
+	if p.Retries != 0 {
+		res.Retries = p.Retries
+	}
+
+	if p.Timeout != 0 {
+		res.Timeout = p.Timeout
+	}
+
+	res.Language = p.Language
+	res.APIKey = p.APIKey
+	res.Token = p.Token
+
+	return res, nil
+}
@@ -0,0 +1,492 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Magic-Kot/effective-mobile/pkg/songlibclient"
+)
+
+var (
+	errArguments = errors.New("wrong number of arguments")
+	errInvalidId = errors.New("the id must be a positive number")
+)
+
+// newFlags - the flag set of the command, its usage line lists the arguments
+func newFlags(name string, arguments string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: songctl %s [flags] %s\n", name, arguments)
+		flags.PrintDefaults()
+	}
+
+	return flags
+}
+
+// parse - parse the flags and check the number of the arguments left
+func parse(flags *flag.FlagSet, args []string, min int, max int) error {
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() < min || flags.NArg() > max {
+		flags.Usage()
+		return errArguments
+	}
+
+	return nil
+}
+
+func parseId(value string) (int, error) {
+	id, err := strconv.Atoi(value)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("%w: %s", errInvalidId, value)
+	}
+
+	return id, nil
+}
+
+// artistsFlag - the repeated -artist GROUP:ROLE flag
+type artistsFlag []songlibclient.SongArtist
+
+func (f *artistsFlag) String() string {
+	credits := make([]string, 0, len(*f))
+
+	for _, artist := range *f {
+		credits = append(credits, artist.Group+":"+artist.Role)
+	}
+
+	return strings.Join(credits, ",")
+}
+
+func (f *artistsFlag) Set(value string) error {
+	group, role, ok := strings.Cut(value, ":")
+	if !ok || group == "" || role == "" {
+		return fmt.Errorf("want GROUP:ROLE, got %q", value)
+	}
+
+	*f = append(*f, songlibclient.SongArtist{Group: group, Role: role})
+
+	return nil
+}
+
+// filterFlags - the filters of the song listing shared by list and export
+func filterFlags(flags *flag.FlagSet) *songlibclient.SongFilters {
+	var f songlibclient.SongFilters
+
+	flags.StringVar(&f.Filter, "filter", "", "song column to filter by")
+	flags.StringVar(&f.Value, "value", "", "required value of the filter column")
+	flags.StringVar(&f.CreatedAfter, "created-after", "", "songs created at or after the RFC 3339 timestamp")
+	flags.StringVar(&f.CreatedBefore, "created-before", "", "songs created before the RFC 3339 timestamp")
+	flags.StringVar(&f.UpdatedAfter, "updated-after", "", "songs updated at or after the RFC 3339 timestamp")
+	flags.StringVar(&f.UpdatedBefore, "updated-before", "", "songs updated before the RFC 3339 timestamp")
+	flags.StringVar(&f.Artist, "artist", "", "songs crediting the music group")
+	flags.StringVar(&f.Role, "role", "", "songs crediting a music group with the role")
+	flags.StringVar(&f.Genre, "genre", "", "songs of any of the comma separated genres")
+	flags.StringVar(&f.Tag, "tag", "", "songs with any of the comma separated tags")
+
+	return &f
+}
+
+// readText - the text of the file, '-' is the standard input
+func (a *app) readText(name string) (string, error) {
+	if name == "-" {
+		data, err := io.ReadAll(a.stdin)
+		return string(data), err
+	}
+
+	data, err := os.ReadFile(name)
+
+	return string(data), err
+}
+
+// runAdd - add a song, the release date, lyrics and link come from the music info service
+func runAdd(ctx context.Context, a *app, args []string) error {
+	var (
+		req     songlibclient.CreateSong
+		artists artistsFlag
+	)
+
+	flags := newFlags("add", "")
+	flags.StringVar(&req.Group, "group", "", "primary music group (required)")
+	flags.StringVar(&req.Song, "song", "", "title of the song (required)")
+	flags.IntVar(&req.AlbumId, "album", 0, "id of the album of the song")
+	flags.IntVar(&req.TrackNumber, "track", 0, "track number on the album")
+	flags.Var(&artists, "artist", "credited music group as GROUP:ROLE, repeatable; role is primary, featured, composer or lyricist")
+
+	if err := parse(flags, args, 0, 0); err != nil {
+		return err
+	}
+
+	req.Artists = artists
+
+	song, err := a.client.CreateSong(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return a.printSong(song)
+}
+
+// runList - list the songs in id order, one page or all of them
+func runList(ctx context.Context, a *app, args []string) error {
+	var (
+		page songlibclient.Page
+		all  bool
+	)
+
+	flags := newFlags("list", "")
+	flags.IntVar(&page.After, "after", 0, "songs with the id greater than this one")
+	flags.IntVar(&page.Limit, "limit", 20, "songs on a page, at most 100")
+	flags.BoolVar(&all, "all", false, "follow the pages to the last one")
+	filters := filterFlags(flags)
+
+	if err := parse(flags, args, 0, 0); err != nil {
+		return err
+	}
+
+	var songs []songlibclient.Song
+
+	for {
+		res, err := a.client.ListSongs(ctx, page, *filters)
+		if err != nil {
+			return err
+		}
+
+		songs = append(songs, res...)
+
+		if !all || len(res) < page.Limit {
+			break
+		}
+
+		page.After = res[len(res)-1].Id
+	}
+
+	return a.printSongs(songs)
+}
+
+func runSearch(ctx context.Context, a *app, args []string) error {
+	var limit int
+
+	flags := newFlags("search", "TEXT")
+	flags.IntVar(&limit, "limit", 20, "songs to show, at most 100")
+
+	if err := parse(flags, args, 1, 1); err != nil {
+		return err
+	}
+
+	songs, err := a.client.SearchSongs(ctx, flags.Arg(0), limit)
+	if err != nil {
+		return err
+	}
+
+	return a.printSongs(songs)
+}
+
+func runGet(ctx context.Context, a *app, args []string) error {
+	flags := newFlags("get", "ID")
+
+	if err := parse(flags, args, 1, 1); err != nil {
+		return err
+	}
+
+	id, err := parseId(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	song, err := a.client.GetSong(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return a.printSong(song)
+}
+
+// runVerses - show the verse of the number, or all the verses of the lyrics numbered from 0
+func runVerses(ctx context.Context, a *app, args []string) error {
+	flags := newFlags("verses", "ID [VERSE]")
+
+	if err := parse(flags, args, 1, 2); err != nil {
+		return err
+	}
+
+	id, err := parseId(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	var verses []songlibclient.Verse
+
+	if flags.NArg() == 2 {
+		number, err := strconv.Atoi(flags.Arg(1))
+		if err != nil {
+			return fmt.Errorf("the verse must be a number: %s", flags.Arg(1))
+		}
+
+		verse, err := a.client.GetVerse(ctx, id, number)
+		if err != nil {
+			return err
+		}
+
+		verses = append(verses, verse)
+	} else {
+		song, err := a.client.GetSong(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		for i, text := range strings.Split(song.Text, "\n\n") {
+			verses = append(verses, songlibclient.Verse{SongId: id, Verse: i, Text: text})
+		}
+	}
+
+	return a.out.print(verses, func(w io.Writer) {
+		for i, verse := range verses {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+
+			fmt.Fprintf(w, "[%d]\n%s\n", verse.Verse, verse.Text)
+		}
+	})
+}
+
+// runUpdate - change the given fields of the song, the others stay as they are
+func runUpdate(ctx context.Context, a *app, args []string) error {
+	var (
+		req      songlibclient.UpdateSong
+		artists  artistsFlag
+		textFile string
+	)
+
+	flags := newFlags("update", "ID")
+	flags.StringVar(&req.Song, "song", "", "title of the song")
+	flags.StringVar(&req.ReleaseDate, "release-date", "", "release date of the song")
+	flags.StringVar(&req.Text, "text", "", "lyrics, the verses are separated by an empty line")
+	flags.StringVar(&textFile, "text-file", "", "file with the lyrics, '-' reads them from the standard input")
+	flags.StringVar(&req.Link, "link", "", "link to the song")
+	flags.Var(&artists, "artist", "credited music group as GROUP:ROLE, repeatable, replaces the credits")
+
+	if err := parse(flags, args, 1, 1); err != nil {
+		return err
+	}
+
+	id, err := parseId(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if textFile != "" {
+		if req.Text, err = a.readText(textFile); err != nil {
+			return err
+		}
+	}
+
+	req.Artists = artists
+
+	song, err := a.client.UpdateSong(ctx, id, req)
+	if err != nil {
+		return err
+	}
+
+	return a.printSong(song)
+}
+
+// runDelete - delete the songs one by one, stopping at the first failure
+func runDelete(ctx context.Context, a *app, args []string) error {
+	flags := newFlags("delete", "ID...")
+
+	if err := parse(flags, args, 1, len(args)); err != nil {
+		return err
+	}
+
+	ids := make([]int, 0, flags.NArg())
+
+	for _, arg := range flags.Args() {
+		id, err := parseId(arg)
+		if err != nil {
+			return err
+		}
+
+		ids = append(ids, id)
+	}
+
+	for _, id := range ids {
+		if err := a.client.DeleteSong(ctx, id); err != nil {
+			return fmt.Errorf("delete song %d: %w", id, err)
+		}
+	}
+
+	return a.out.print(map[string][]int{"deleted": ids}, func(w io.Writer) {
+		for _, id := range ids {
+			fmt.Fprintf(w, "deleted song %d\n", id)
+		}
+	})
+}
+
+// runImport - import the songs of the file, the format is taken from the extension unless given
+func runImport(ctx context.Context, a *app, args []string) error {
+	var (
+		format string
+		opts   songlibclient.ImportSongsOptions
+	)
+
+	flags := newFlags("import", "FILE")
+	flags.StringVar(&format, "format", "", "file format: csv or ndjson, by the extension of the file by default")
+	flags.BoolVar(&opts.DryRun, "dry-run", false, "check the rows without saving them")
+	flags.BoolVar(&opts.Enrich, "enrich", false, "fill the missing details from the music info service")
+	flags.IntVar(&opts.BatchSize, "batch-size", 0, "rows imported in one transaction, 100 by default")
+
+	if err := parse(flags, args, 1, 1); err != nil {
+		return err
+	}
+
+	name := flags.Arg(0)
+
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(name), ".")
+	}
+
+	var file io.Reader = a.stdin
+
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+
+		defer f.Close()
+
+		file = f
+	}
+
+	report, err := a.client.ImportSongs(ctx, format, file, opts)
+	if err != nil {
+		return err
+	}
+
+	return a.out.print(report, func(w io.Writer) {
+		row(w, "ROW", "STATUS", "ID", "ERROR")
+
+		for _, r := range report.Rows {
+			id := ""
+			if r.Id > 0 {
+				id = strconv.Itoa(r.Id)
+			}
+
+			row(w, r.Row, r.Status, id, r.Error)
+		}
+
+		fmt.Fprintf(w, "\ncreated %d, duplicate %d, invalid %d, failed %d", report.Created, report.Duplicate, report.Invalid, report.Failed)
+
+		if report.DryRun {
+			fmt.Fprint(w, " (dry run)")
+		}
+
+		fmt.Fprintln(w)
+	})
+}
+
+// runExport - write the songs matching the filters in the format to the file or the standard output,
+// the export is written as the server sends it whatever the output format
+func runExport(ctx context.Context, a *app, args []string) (err error) {
+	var format string
+
+	flags := newFlags("export", "[FILE]")
+	flags.StringVar(&format, "format", songlibclient.FormatNDJSON, "file format: json, ndjson or csv")
+	filters := filterFlags(flags)
+
+	if err = parse(flags, args, 0, 1); err != nil {
+		return err
+	}
+
+	body, err := a.client.ExportSongs(ctx, format, *filters)
+	if err != nil {
+		return err
+	}
+
+	defer body.Close()
+
+	w := a.stdout
+
+	if name := flags.Arg(0); name != "" && name != "-" {
+		file, err := os.Create(name)
+		if err != nil {
+			return err
+		}
+
+		defer func() {
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+		}()
+
+		w = file
+	}
+
+	_, err = io.Copy(w, body)
+
+	return err
+}
+
+func (a *app) printSongs(songs []songlibclient.Song) error {
+	if songs == nil {
+		songs = make([]songlibclient.Song, 0)
+	}
+
+	return a.out.print(songs, func(w io.Writer) {
+		row(w, "ID", "GROUP", "SONG", "RELEASED", "GENRES", "TAGS")
+
+		for _, s := range songs {
+			row(w, s.Id, s.GroupSong, s.Song, s.ReleaseDate, strings.Join(s.Genres, ","), strings.Join(s.Tags, ","))
+		}
+	})
+}
+
+func (a *app) printSong(s songlibclient.Song) error {
+	return a.out.print(s, func(w io.Writer) {
+		credits := make([]string, 0, len(s.Artists))
+
+		for _, artist := range s.Artists {
+			credits = append(credits, fmt.Sprintf("%s (%s)", artist.Group, artist.Role))
+		}
+
+		row(w, "ID:", s.Id)
+		row(w, "Group:", s.GroupSong)
+		row(w, "Song:", s.Song)
+		row(w, "Released:", s.ReleaseDate)
+		row(w, "Link:", s.Link)
+
+		if s.AlbumId != nil {
+			track := ""
+			if s.TrackNumber != nil {
+				track = fmt.Sprintf(", track %d", *s.TrackNumber)
+			}
+
+			row(w, "Album:", fmt.Sprintf("%d%s", *s.AlbumId, track))
+		}
+
+		row(w, "Artists:", strings.Join(credits, ", "))
+		row(w, "Genres:", strings.Join(s.Genres, ", "))
+		row(w, "Tags:", strings.Join(s.Tags, ", "))
+		row(w, "Verses:", verseCount(s.Text))
+		row(w, "Created:", s.CreatedAt.Format("2006-01-02 15:04:05"))
+		row(w, "Updated:", s.UpdatedAt.Format("2006-01-02 15:04:05"))
+	})
+}
+
+func verseCount(text string) int {
+	if text == "" {
+		return 0
+	}
+
+	return len(strings.Split(text, "\n\n"))
+}
@@ -0,0 +1,89 @@
+package apperror
+
+import "errors"
+
+// Kind - the class of the error, it decides the HTTP status of the response
+type Kind string
+
+const (
+	KindNotFound     Kind = "not_found"
+	KindConflict     Kind = "conflict"
+	KindValidation   Kind = "validation"
+	KindUnauthorized Kind = "unauthorized"
+	KindForbidden    Kind = "forbidden"
+	KindRateLimited  Kind = "rate_limited"
+	KindUpstream     Kind = "upstream"
+	KindInternal     Kind = "internal"
+)
+
+// Error - an error of the application with the stable code reported to the clients.
+// The errors are declared once as sentinels, wrap them to add details or the cause.
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func New(kind Kind, code string, message string) *Error {
+	return &Error{
+		Kind:    kind,
+		Code:    code,
+		Message: message,
+	}
+}
+
+// NotFound - the requested resource does not exist
+func NotFound(code string, message string) *Error {
+	return New(KindNotFound, code, message)
+}
+
+// Conflict - the change clashes with the stored data
+func Conflict(code string, message string) *Error {
+	return New(KindConflict, code, message)
+}
+
+// Validation - the request is malformed or breaks a rule
+func Validation(code string, message string) *Error {
+	return New(KindValidation, code, message)
+}
+
+// Unauthorized - the caller is not authenticated: the credentials are missing, unknown or revoked
+func Unauthorized(code string, message string) *Error {
+	return New(KindUnauthorized, code, message)
+}
+
+// Forbidden - the caller is authenticated but its role does not allow the request
+func Forbidden(code string, message string) *Error {
+	return New(KindForbidden, code, message)
+}
+
+// RateLimited - the caller made too many requests, it may try again later
+func RateLimited(code string, message string) *Error {
+	return New(KindRateLimited, code, message)
+}
+
+// Upstream - a service the library depends on failed
+func Upstream(code string, message string) *Error {
+	return New(KindUpstream, code, message)
+}
+
+// Internal - the library failed, the message is safe to show but the cause is not
+func Internal(code string, message string) *Error {
+	return New(KindInternal, code, message)
+}
+
+// From - the outermost application error in the chain, an internal one if there is none
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+
+	return errUnknown
+}
+
+var errUnknown = Internal("internal", "internal server error")
@@ -4,9 +4,15 @@ import "time"
 
 type Config struct {
 	ServerDeps
+	GrpcDeps
+	AdminDeps
 	PostgresDeps
 	LoggerDeps
 	MusicInfo
+	MigrationDeps
+	TokenDeps
+	RateLimitDeps
+	TracingDeps
 }
 
 type ServerDeps struct {
@@ -15,15 +21,28 @@ type ServerDeps struct {
 	Timeout time.Duration `env:"TIMEOUT"  env-default:"5s"`
 }
 
+type GrpcDeps struct {
+	Host string `env:"GRPC_HOST"  env-default:"localhost"`
+	Port string `env:"GRPC_PORT"  env-default:":9090"`
+}
+
+// AdminDeps - the server of /metrics, apart from the API so it is not exposed with it
+type AdminDeps struct {
+	Host string `env:"ADMIN_HOST"  env-default:"localhost"`
+	Port string `env:"ADMIN_PORT"  env-default:":9100"`
+}
+
 type PostgresDeps struct {
-	MaxAttempts int           `env:"MAX_ATTEMPTS"       env-default:"3"`
-	Delay       time.Duration `env:"DELAY"              env-default:"10s"`
-	Username    string        `env:"USERNAME_POSTGRES"  env-default:"postgres"`
-	Password    string        `env:"PASSWORD_POSTGRES"  env-default:"postgres"`
-	Host        string        `env:"HOST_POSTGRES"      env-default:"127.0.0.1"`
-	Port        string        `env:"PORT_POSTGRES"      env-default:"5432"`
-	Database    string        `env:"DATABASE"           env-default:"postgres"`
-	SSLMode     string        `env:"MODELESS"           env-default:"disable"`
+	MaxAttempts  int           `env:"MAX_ATTEMPTS"       env-default:"3"`
+	Delay        time.Duration `env:"DELAY"              env-default:"10s"`
+	Username     string        `env:"USERNAME_POSTGRES"  env-default:"postgres"`
+	Password     string        `env:"PASSWORD_POSTGRES"  env-default:"postgres"`
+	Host         string        `env:"HOST_POSTGRES"      env-default:"127.0.0.1"`
+	Port         string        `env:"PORT_POSTGRES"      env-default:"5432"`
+	Database     string        `env:"DATABASE"           env-default:"postgres"`
+	SSLMode      string        `env:"MODELESS"           env-default:"disable"`
+	TxMaxRetries int           `env:"TX_MAX_RETRIES"     env-default:"3"`
+	TxRetryDelay time.Duration `env:"TX_RETRY_DELAY"     env-default:"50ms"`
 }
 
 type LoggerDeps struct {
@@ -31,5 +50,64 @@ type LoggerDeps struct {
 }
 
 type MusicInfo struct {
-	Url string `env:"MUSIC_URL"`
+	Url     string        `env:"MUSIC_URL"`
+	Timeout time.Duration `env:"MUSIC_TIMEOUT"  env-default:"5s"`
+	// Required - a song is not added if the music info service fails, it is added without details otherwise
+	Required bool `env:"MUSIC_REQUIRED" env-default:"false"`
+}
+
+type MigrationDeps struct {
+	AutoMigrate bool   `env:"AUTO_MIGRATE"    env-default:"true"`
+	Dir         string `env:"MIGRATIONS_DIR"  env-default:"cmd/migrations"`
+}
+
+// TokenDeps - the bearer tokens of the SSO, they are not accepted if neither a key set nor the static key is given
+type TokenDeps struct {
+	JwksFile    string            `env:"JWT_JWKS_FILE"`
+	JwksURL     string            `env:"JWT_JWKS_URL"`
+	JwksRefresh time.Duration     `env:"JWT_JWKS_REFRESH"  env-default:"15m"`
+	StaticKey   string            `env:"JWT_STATIC_KEY"`
+	Issuer      string            `env:"JWT_ISSUER"`
+	Audience    string            `env:"JWT_AUDIENCE"`
+	RoleClaim   string            `env:"JWT_ROLE_CLAIM"    env-default:"roles"`
+	RoleMap     map[string]string `env:"JWT_ROLE_MAP"`
+	Leeway      time.Duration     `env:"JWT_LEEWAY"        env-default:"30s"`
+}
+
+// RateLimitDeps - the token buckets of each client: its API key, the subject of its token or else its IP address.
+// A bucket of 0 requests is off.
+type RateLimitDeps struct {
+	ReadRequests  int `env:"RATE_LIMIT_READ"         env-default:"600"`
+	WriteRequests int `env:"RATE_LIMIT_WRITE"        env-default:"60"`
+	// AuthFailures - the failed authentications of an IP address
+	AuthFailures int           `env:"RATE_LIMIT_AUTH_FAILURES"  env-default:"20"`
+	Period       time.Duration `env:"RATE_LIMIT_PERIOD"       env-default:"1m"`
+	// TrustProxy - take the address of the client from X-Forwarded-For, only behind a proxy setting it
+	TrustProxy bool `env:"RATE_LIMIT_TRUST_PROXY"  env-default:"false"`
+}
+
+// TracingDeps - the spans of the requests, the song service, the statements and the music info calls.
+// No exporter keeps only the propagation of the trace context.
+type TracingDeps struct {
+	Exporter    string  `env:"TRACING_EXPORTER"`
+	Endpoint    string  `env:"TRACING_OTLP_ENDPOINT"  env-default:"localhost:4317"`
+	Insecure    bool    `env:"TRACING_OTLP_INSECURE"  env-default:"true"`
+	SampleRatio float64 `env:"TRACING_SAMPLE_RATIO"   env-default:"1"`
+	ServiceName string  `env:"TRACING_SERVICE_NAME"   env-default:"songlib"`
+}
+
+// redacted - the value of a secret in the logs
+const redacted = "[REDACTED]"
+
+// Redacted - the config with the secrets hidden, to be logged
+func (c Config) Redacted() Config {
+	if c.PostgresDeps.Password != "" {
+		c.PostgresDeps.Password = redacted
+	}
+
+	if c.TokenDeps.StaticKey != "" {
+		c.TokenDeps.StaticKey = redacted
+	}
+
+	return c
 }
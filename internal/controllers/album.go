@@ -0,0 +1,255 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+	"github.com/Magic-Kot/effective-mobile/internal/services/album"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+)
+
+type AlbumController struct {
+	albumService album.AlbumService
+	logger       *zerolog.Logger
+	validator    *validator.Validate
+}
+
+func NewAlbumController(albumService *album.AlbumService, logger *zerolog.Logger, validator *validator.Validate) *AlbumController {
+	return &AlbumController{
+		albumService: *albumService,
+		logger:       logger,
+		validator:    validator,
+	}
+}
+
+// @Summary Add Album
+// @Tags albums
+// @Description add a new album of the music group
+// @ID add-album
+// @Accept  json
+// @Produce  json
+// @Param input body models.CreateAlbum true "You need to specify the name of the band and the album title in the request body"
+// @Success 200 {string} string
+// @Failure 400 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /album/create [post]
+func (ac *AlbumController) CreateAlbum(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = ac.logger.WithContext(ctx)
+
+	ac.logger.Debug().Msg("starting the handler 'CreateAlbum'")
+
+	req := new(models.CreateAlbum)
+	if err := c.Bind(req); err != nil {
+		ac.logger.Debug().Msgf("bind: invalid request: %v", err)
+
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	if err := ac.validator.Struct(req); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	id, err := ac.albumService.CreateAlbum(ctx, *req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, fmt.Sprintf("successfully created album, id: %d", id))
+}
+
+// @Summary Get All Albums
+// @Tags albums
+// @Description get all saved albums
+// @ID get-all-albums
+// @Accept  json
+// @Produce  json
+// @Param id query string true "Enter the entry id in the table"
+// @Param limit query string true "Enter the number of albums to output"
+// @Param group query string false "Enter the name of the music group"
+// @Success 200 {object} []models.AlbumResponse
+// @Failure 404 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /album/all [get]
+func (ac *AlbumController) GetAllAlbums(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = ac.logger.WithContext(ctx)
+
+	ac.logger.Debug().Msg("starting the handler 'GetAllAlbums'")
+
+	var req models.RequestGetAllAlbums
+
+	req.Id = c.QueryParam("id")
+	req.Limit = c.QueryParam("limit")
+	req.Group = c.QueryParam("group")
+
+	result, err := ac.albumService.GetAllAlbums(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	if result == nil {
+		return errNoAlbums
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary Get Album
+// @Tags albums
+// @Description get the album with its ordered track list
+// @ID get-album
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Enter the ID of the saved album"
+// @Success 200 {object} models.AlbumResponse
+// @Failure 400,404 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /album/get/{id} [get]
+func (ac *AlbumController) GetAlbum(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = ac.logger.WithContext(ctx)
+
+	ac.logger.Debug().Msg("starting the handler 'GetAlbum'")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return errInvalidId
+	}
+
+	result, err := ac.albumService.GetAlbum(ctx, id)
+	if err != nil {
+		ac.logger.Debug().Msgf("error receiving album data: %v", err)
+		return err
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary Update Album
+// @Tags albums
+// @Description update information about a saved album
+// @ID update-album
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Enter the album ID"
+// @Param input body models.UpdateAlbum true "You need to specify the album title in the request body"
+// @Success 200 {string} string
+// @Failure 400,404 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /album/update/{id} [put]
+func (ac *AlbumController) UpdateAlbum(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = ac.logger.WithContext(ctx)
+
+	ac.logger.Debug().Msg("starting the handler 'UpdateAlbum'")
+
+	var req models.UpdateAlbum
+	if err := c.Bind(&req); err != nil {
+		ac.logger.Debug().Msgf("bind: invalid request: %v", err)
+
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return errInvalidId
+	}
+
+	req.Id = id
+
+	if err := ac.validator.Struct(&req); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	if err := ac.albumService.UpdateAlbum(ctx, req); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, fmt.Sprint("successfully updated"))
+}
+
+// @Summary Delete Album
+// @Tags albums
+// @Description delete an album, its songs stay in the library
+// @ID delete-album
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Enter the ID of the saved album"
+// @Success 200 {string} string
+// @Failure 400,404 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /album/delete/{id} [delete]
+func (ac *AlbumController) DeleteAlbum(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = ac.logger.WithContext(ctx)
+
+	ac.logger.Debug().Msg("starting the handler 'DeleteAlbum'")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return errInvalidId
+	}
+
+	if err := ac.albumService.DeleteAlbum(ctx, id); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, fmt.Sprintf("successfully deleted album: %d", id))
+}
+
+// @Summary Set Album Tracks
+// @Tags albums
+// @Description replace the ordered track list of the album, the track number is the position of the song ID in the list
+// @ID set-album-tracks
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Enter the album ID"
+// @Param input body models.AlbumTracksRequest true "You need to specify the song IDs in track order"
+// @Success 200 {string} string
+// @Failure 400,404 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /album/tracks/{id} [put]
+func (ac *AlbumController) SetAlbumTracks(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = ac.logger.WithContext(ctx)
+
+	ac.logger.Debug().Msg("starting the handler 'SetAlbumTracks'")
+
+	var req models.AlbumTracksRequest
+	if err := c.Bind(&req); err != nil {
+		ac.logger.Debug().Msgf("bind: invalid request: %v", err)
+
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return errInvalidId
+	}
+
+	req.Id = id
+
+	if err := ac.validator.Struct(&req); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	if err := ac.albumService.SetAlbumTracks(ctx, req); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, fmt.Sprint("successfully updated tracks"))
+}
@@ -0,0 +1,273 @@
+package apiv2
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+	"github.com/Magic-Kot/effective-mobile/internal/services/album"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+)
+
+type AlbumController struct {
+	albumService album.AlbumService
+	logger       *zerolog.Logger
+	validator    *validator.Validate
+}
+
+func NewAlbumController(albumService *album.AlbumService, logger *zerolog.Logger, validator *validator.Validate) *AlbumController {
+	return &AlbumController{
+		albumService: *albumService,
+		logger:       logger,
+		validator:    validator,
+	}
+}
+
+// @Summary List Albums
+// @Tags albums v2
+// @Description get a page of albums ordered by ID, a full page has a Link header to the next one
+// @ID v2-list-albums
+// @Produce  json
+// @Param after query int false "Albums with the ID greater than this one, 0 by default"
+// @Param limit query int false "Enter the number of albums to output, from 1 to 100, 20 by default"
+// @Param group query string false "Enter the name of the music group"
+// @Success 200 {object} []models.AlbumResponse
+// @Failure 400 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/albums [get]
+func (ac *AlbumController) GetAllAlbums(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = ac.logger.WithContext(ctx)
+
+	ac.logger.Debug().Msg("starting the handler 'GetAllAlbums'")
+
+	req := models.RequestGetAllAlbums{
+		Group: c.QueryParam("group"),
+	}
+
+	var err error
+
+	if req.Id, req.Limit, err = page(c); err != nil {
+		return err
+	}
+
+	result, err := ac.albumService.GetAllAlbums(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	if result == nil {
+		result = make([]models.AlbumResponse, 0)
+	}
+
+	if len(result) > 0 {
+		setNextLink(c, len(result), req.Limit, result[len(result)-1].Id)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary Create Album
+// @Tags albums v2
+// @Description add a new album of the music group, the response is the saved album and its location
+// @ID v2-create-album
+// @Accept  json
+// @Produce  json
+// @Param input body models.CreateAlbum true "You need to specify the name of the band and the album title in the request body"
+// @Success 201 {object} models.AlbumResponse
+// @Header 201 {string} Location "The URL of the album"
+// @Failure 400,409 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/albums [post]
+func (ac *AlbumController) CreateAlbum(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = ac.logger.WithContext(ctx)
+
+	ac.logger.Debug().Msg("starting the handler 'CreateAlbum'")
+
+	var req models.CreateAlbum
+	if err := c.Bind(&req); err != nil {
+		ac.logger.Debug().Msgf("bind: invalid request: %v", err)
+
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	if err := ac.validator.Struct(&req); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	id, err := ac.albumService.CreateAlbum(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	result, err := ac.albumService.GetAlbum(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return created(c, fmt.Sprintf("%s/albums/%d", BasePath, id), result)
+}
+
+// @Summary Get Album
+// @Tags albums v2
+// @Description get the album with its ordered track list
+// @ID v2-get-album
+// @Produce  json
+// @Param id path int true "Enter the ID of the saved album"
+// @Success 200 {object} models.AlbumResponse
+// @Failure 400,404 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/albums/{id} [get]
+func (ac *AlbumController) GetAlbum(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = ac.logger.WithContext(ctx)
+
+	ac.logger.Debug().Msg("starting the handler 'GetAlbum'")
+
+	id, err := pathId(c, "id")
+	if err != nil {
+		return err
+	}
+
+	result, err := ac.albumService.GetAlbum(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary Replace Album
+// @Tags albums v2
+// @Description replace the details of a saved album
+// @ID v2-update-album
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Enter the album ID"
+// @Param input body models.UpdateAlbum true "You need to specify the album title in the request body"
+// @Success 200 {object} models.AlbumResponse
+// @Failure 400,404 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/albums/{id} [put]
+func (ac *AlbumController) UpdateAlbum(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = ac.logger.WithContext(ctx)
+
+	ac.logger.Debug().Msg("starting the handler 'UpdateAlbum'")
+
+	var req models.UpdateAlbum
+	if err := c.Bind(&req); err != nil {
+		ac.logger.Debug().Msgf("bind: invalid request: %v", err)
+
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	id, err := pathId(c, "id")
+	if err != nil {
+		return err
+	}
+
+	req.Id = id
+
+	if err := ac.validator.Struct(&req); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	if err := ac.albumService.UpdateAlbum(ctx, req); err != nil {
+		return err
+	}
+
+	result, err := ac.albumService.GetAlbum(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary Delete Album
+// @Tags albums v2
+// @Description delete an album, its songs stay in the library
+// @ID v2-delete-album
+// @Param id path int true "Enter the ID of the saved album"
+// @Success 204
+// @Failure 400,404 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/albums/{id} [delete]
+func (ac *AlbumController) DeleteAlbum(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = ac.logger.WithContext(ctx)
+
+	ac.logger.Debug().Msg("starting the handler 'DeleteAlbum'")
+
+	id, err := pathId(c, "id")
+	if err != nil {
+		return err
+	}
+
+	if err := ac.albumService.DeleteAlbum(ctx, id); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// @Summary Replace Album Tracks
+// @Tags albums v2
+// @Description replace the ordered track list of the album, the track number is the position of the song ID in the list
+// @ID v2-set-album-tracks
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Enter the album ID"
+// @Param input body models.AlbumTracksRequest true "You need to specify the song IDs in track order"
+// @Success 200 {object} models.AlbumResponse
+// @Failure 400,404 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/albums/{id}/tracks [put]
+func (ac *AlbumController) SetAlbumTracks(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = ac.logger.WithContext(ctx)
+
+	ac.logger.Debug().Msg("starting the handler 'SetAlbumTracks'")
+
+	var req models.AlbumTracksRequest
+	if err := c.Bind(&req); err != nil {
+		ac.logger.Debug().Msgf("bind: invalid request: %v", err)
+
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	id, err := pathId(c, "id")
+	if err != nil {
+		return err
+	}
+
+	req.Id = id
+
+	if err := ac.validator.Struct(&req); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	if err := ac.albumService.SetAlbumTracks(ctx, req); err != nil {
+		return err
+	}
+
+	result, err := ac.albumService.GetAlbum(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,233 @@
+package apiv2
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+	"github.com/Magic-Kot/effective-mobile/internal/services/auth"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+)
+
+type ApiKeyController struct {
+	authService auth.AuthService
+	logger      *zerolog.Logger
+	validator   *validator.Validate
+}
+
+func NewApiKeyController(authService *auth.AuthService, logger *zerolog.Logger, validator *validator.Validate) *ApiKeyController {
+	return &ApiKeyController{
+		authService: *authService,
+		logger:      logger,
+		validator:   validator,
+	}
+}
+
+// @Summary Issue API Key
+// @Tags admin v2
+// @Description issue an API key with the role: reader, editor or admin. The key is in the response only, it is stored hashed.
+// @ID v2-issue-api-key
+// @Accept  json
+// @Produce  json
+// @Param input body models.CreateApiKey true "You need to specify the name and the role of the key"
+// @Success 201 {object} models.ApiKeyResponse
+// @Header 201 {string} Location "The URL of the key"
+// @Failure 400,401,403 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/admin/keys [post]
+func (kc *ApiKeyController) IssueApiKey(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = kc.logger.WithContext(ctx)
+
+	kc.logger.Debug().Msg("starting the handler 'IssueApiKey'")
+
+	var req models.CreateApiKey
+	if err := c.Bind(&req); err != nil {
+		kc.logger.Debug().Msgf("bind: invalid request: %v", err)
+
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	if err := kc.validator.Struct(&req); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	// an admin signed in with a bearer token has no key
+	if principal, ok := auth.PrincipalFrom(ctx); ok {
+		if principal.KeyId != 0 {
+			req.CreatedBy = &principal.KeyId
+		}
+
+		req.CreatedBySubject = principal.Subject
+	}
+
+	result, err := kc.authService.IssueApiKey(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return created(c, fmt.Sprintf("%s/admin/keys/%d", BasePath, result.Id), result)
+}
+
+// @Summary List API Keys
+// @Tags admin v2
+// @Description get all the API keys with the revoked ones, without the keys themselves
+// @ID v2-list-api-keys
+// @Produce  json
+// @Success 200 {object} []models.ApiKeyResponse
+// @Failure 401,403 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/admin/keys [get]
+func (kc *ApiKeyController) GetAllApiKeys(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = kc.logger.WithContext(ctx)
+
+	kc.logger.Debug().Msg("starting the handler 'GetAllApiKeys'")
+
+	result, err := kc.authService.GetAllApiKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	if result == nil {
+		result = make([]models.ApiKeyResponse, 0)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary Revoke API Key
+// @Tags admin v2
+// @Description revoke an API key, its writes stay attributed to it
+// @ID v2-revoke-api-key
+// @Param id path int true "Enter the ID of the key"
+// @Success 204
+// @Failure 400,401,403,404 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/admin/keys/{id} [delete]
+func (kc *ApiKeyController) RevokeApiKey(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = kc.logger.WithContext(ctx)
+
+	kc.logger.Debug().Msg("starting the handler 'RevokeApiKey'")
+
+	id, err := pathId(c, "id")
+	if err != nil {
+		return err
+	}
+
+	if err := kc.authService.RevokeApiKey(ctx, id); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// @Summary List API Key Writes
+// @Tags admin v2
+// @Description get a page of the writes made with the API key in id order, a full page has a Link header to the next one
+// @ID v2-list-api-key-writes
+// @Produce  json
+// @Param id path int true "Enter the ID of the key"
+// @Param after query int false "Writes with the ID greater than this one, 0 by default"
+// @Param limit query int false "Enter the number of writes to output, from 1 to 100, 20 by default"
+// @Success 200 {object} []models.ApiKeyWrite
+// @Failure 400,401,403 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/admin/keys/{id}/writes [get]
+func (kc *ApiKeyController) GetApiKeyWrites(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = kc.logger.WithContext(ctx)
+
+	kc.logger.Debug().Msg("starting the handler 'GetApiKeyWrites'")
+
+	id, err := pathId(c, "id")
+	if err != nil {
+		return err
+	}
+
+	after, limit, err := page(c)
+	if err != nil {
+		return err
+	}
+
+	req := models.RequestGetApiKeyWrites{KeyId: id}
+	req.After, _ = strconv.ParseInt(after, 10, 64)
+	req.Limit, _ = strconv.Atoi(limit)
+
+	result, err := kc.authService.GetApiKeyWrites(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	if result == nil {
+		result = make([]models.ApiKeyWrite, 0)
+	}
+
+	if len(result) > 0 {
+		setNextLink(c, len(result), limit, int(result[len(result)-1].Id))
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary List Token Writes
+// @Tags admin v2
+// @Description get a page of the writes made with the bearer tokens of the subject in id order, a full page has a Link header to the next one
+// @ID v2-list-subject-writes
+// @Produce  json
+// @Param subject query string true "Enter the sub claim of the tokens"
+// @Param after query int false "Writes with the ID greater than this one, 0 by default"
+// @Param limit query int false "Enter the number of writes to output, from 1 to 100, 20 by default"
+// @Success 200 {object} []models.ApiKeyWrite
+// @Failure 400,401,403 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/admin/writes [get]
+func (kc *ApiKeyController) GetSubjectWrites(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = kc.logger.WithContext(ctx)
+
+	kc.logger.Debug().Msg("starting the handler 'GetSubjectWrites'")
+
+	subject := c.QueryParam("subject")
+	if subject == "" {
+		return fmt.Errorf("%w: subject is required", errInvalidRequest)
+	}
+
+	after, limit, err := page(c)
+	if err != nil {
+		return err
+	}
+
+	req := models.RequestGetApiKeyWrites{Subject: subject}
+	req.After, _ = strconv.ParseInt(after, 10, 64)
+	req.Limit, _ = strconv.Atoi(limit)
+
+	result, err := kc.authService.GetApiKeyWrites(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	if result == nil {
+		result = make([]models.ApiKeyWrite, 0)
+	}
+
+	if len(result) > 0 {
+		setNextLink(c, len(result), limit, int(result[len(result)-1].Id))
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
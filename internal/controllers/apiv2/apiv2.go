@@ -0,0 +1,96 @@
+// Package apiv2 - the handlers of the resource-oriented /api/v2 routes. They answer with the
+// resources themselves: 201 with the Location of a created resource, 204 when there is no body.
+package apiv2
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// BasePath - the prefix of all the v2 routes
+	BasePath = "/api/v2"
+
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+// labelKinds - the classification kind of each path segment
+var labelKinds = map[string]string{
+	"genres": models.KindGenre,
+	"tags":   models.KindTag,
+}
+
+// pathId - the positive number in the path parameter
+func pathId(c echo.Context, name string) (int, error) {
+	id, err := strconv.Atoi(c.Param(name))
+	if err != nil || id <= 0 {
+		return 0, errInvalidId
+	}
+
+	return id, nil
+}
+
+// page - the 'after' and 'limit' query parameters of a listing, the first 20 resources by default
+func page(c echo.Context) (string, string, error) {
+	after := c.QueryParam("after")
+	if after == "" {
+		after = "0"
+	}
+
+	if n, err := strconv.Atoi(after); err != nil || n < 0 {
+		return "", "", fmt.Errorf("%w: after must be a non-negative number", errInvalidRequest)
+	}
+
+	limit, err := limitParam(c)
+	if err != nil {
+		return "", "", err
+	}
+
+	return after, strconv.Itoa(limit), nil
+}
+
+// limitParam - the 'limit' query parameter, 20 by default
+func limitParam(c echo.Context) (int, error) {
+	value := c.QueryParam("limit")
+	if value == "" {
+		return defaultLimit, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 || n > maxLimit {
+		return 0, fmt.Errorf("%w: limit must be a number from 1 to %d", errInvalidRequest, maxLimit)
+	}
+
+	return n, nil
+}
+
+// setNextLink - link a full page to the next one, which starts after the last resource of the page
+func setNextLink(c echo.Context, count int, limit string, lastId int) {
+	setCursorLink(c, count, limit, "after", int64(lastId))
+}
+
+// setCursorLink - link a full page to the next one, the cursor parameter has the id of the last resource
+func setCursorLink(c echo.Context, count int, limit string, cursor string, lastId int64) {
+	if n, _ := strconv.Atoi(limit); count == 0 || count < n {
+		return
+	}
+
+	query := c.Request().URL.Query()
+	query.Set(cursor, strconv.FormatInt(lastId, 10))
+	query.Set("limit", limit)
+
+	c.Response().Header().Set("Link", fmt.Sprintf(`<%s?%s>; rel="next"`, c.Request().URL.Path, query.Encode()))
+}
+
+// created - respond with the created resource and its location
+func created(c echo.Context, location string, resource interface{}) error {
+	c.Response().Header().Set(echo.HeaderLocation, location)
+
+	return c.JSON(http.StatusCreated, resource)
+}
@@ -0,0 +1,204 @@
+package apiv2
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+	"github.com/Magic-Kot/effective-mobile/internal/services/classification"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+)
+
+type ClassificationController struct {
+	classificationService classification.ClassificationService
+	logger                *zerolog.Logger
+	validator             *validator.Validate
+}
+
+func NewClassificationController(classificationService *classification.ClassificationService, logger *zerolog.Logger, validator *validator.Validate) *ClassificationController {
+	return &ClassificationController{
+		classificationService: *classificationService,
+		logger:                logger,
+		validator:             validator,
+	}
+}
+
+// @Summary List Genres
+// @Tags classification v2
+// @Description get all genres with the number of songs of each
+// @ID v2-list-genres
+// @Produce  json
+// @Success 200 {object} []models.LabelResponse
+// @Failure 500 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/genres [get]
+func (cc *ClassificationController) GetAllGenres(c echo.Context) error {
+	cc.logger.Debug().Msg("starting the handler 'GetAllGenres'")
+
+	return cc.getAllLabels(c, models.KindGenre)
+}
+
+// @Summary List Tags
+// @Tags classification v2
+// @Description get all tags with the number of songs of each
+// @ID v2-list-tags
+// @Produce  json
+// @Success 200 {object} []models.LabelResponse
+// @Failure 500 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/tags [get]
+func (cc *ClassificationController) GetAllTags(c echo.Context) error {
+	cc.logger.Debug().Msg("starting the handler 'GetAllTags'")
+
+	return cc.getAllLabels(c, models.KindTag)
+}
+
+func (cc *ClassificationController) getAllLabels(c echo.Context, kind string) error {
+	ctx := cc.logger.WithContext(c.Request().Context())
+
+	result, err := cc.classificationService.GetAllLabels(ctx, kind)
+	if err != nil {
+		return err
+	}
+
+	if result == nil {
+		result = make([]models.LabelResponse, 0)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary Assign Song Genre Or Tag
+// @Tags classification v2
+// @Description assign a genre or tag to a song, the genre or tag is created if it does not exist
+// @ID v2-assign-song-label
+// @Param id path int true "Enter the ID of the song"
+// @Param kinds path string true "Kind of classification" Enums(genres, tags)
+// @Param name path string true "Enter the name of the genre or tag"
+// @Success 204
+// @Failure 400,404 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/songs/{id}/{kinds}/{name} [put]
+func (cc *ClassificationController) AssignSongLabel(c echo.Context) error {
+	cc.logger.Debug().Msg("starting the handler 'AssignSongLabel'")
+
+	return cc.assignLabel(c, models.TargetSong)
+}
+
+// @Summary Remove Song Genre Or Tag
+// @Tags classification v2
+// @Description remove a genre or tag from a song
+// @ID v2-remove-song-label
+// @Param id path int true "Enter the ID of the song"
+// @Param kinds path string true "Kind of classification" Enums(genres, tags)
+// @Param name path string true "Enter the name of the genre or tag"
+// @Success 204
+// @Failure 400,404 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/songs/{id}/{kinds}/{name} [delete]
+func (cc *ClassificationController) RemoveSongLabel(c echo.Context) error {
+	cc.logger.Debug().Msg("starting the handler 'RemoveSongLabel'")
+
+	return cc.removeLabel(c, models.TargetSong)
+}
+
+// @Summary Assign Music Group Genre Or Tag
+// @Tags classification v2
+// @Description assign a genre or tag to a music group, the genre or tag is created if it does not exist
+// @ID v2-assign-group-label
+// @Param id path int true "Enter the ID of the music group"
+// @Param kinds path string true "Kind of classification" Enums(genres, tags)
+// @Param name path string true "Enter the name of the genre or tag"
+// @Success 204
+// @Failure 400,404 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/groups/{id}/{kinds}/{name} [put]
+func (cc *ClassificationController) AssignGroupLabel(c echo.Context) error {
+	cc.logger.Debug().Msg("starting the handler 'AssignGroupLabel'")
+
+	return cc.assignLabel(c, models.TargetGroup)
+}
+
+// @Summary Remove Music Group Genre Or Tag
+// @Tags classification v2
+// @Description remove a genre or tag from a music group
+// @ID v2-remove-group-label
+// @Param id path int true "Enter the ID of the music group"
+// @Param kinds path string true "Kind of classification" Enums(genres, tags)
+// @Param name path string true "Enter the name of the genre or tag"
+// @Success 204
+// @Failure 400,404 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/groups/{id}/{kinds}/{name} [delete]
+func (cc *ClassificationController) RemoveGroupLabel(c echo.Context) error {
+	cc.logger.Debug().Msg("starting the handler 'RemoveGroupLabel'")
+
+	return cc.removeLabel(c, models.TargetGroup)
+}
+
+func (cc *ClassificationController) assignLabel(c echo.Context, target string) error {
+	ctx := cc.logger.WithContext(c.Request().Context())
+
+	req, err := cc.bindLabel(c, target)
+	if err != nil {
+		return err
+	}
+
+	if err := cc.classificationService.AssignLabel(ctx, req); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (cc *ClassificationController) removeLabel(c echo.Context, target string) error {
+	ctx := cc.logger.WithContext(c.Request().Context())
+
+	req, err := cc.bindLabel(c, target)
+	if err != nil {
+		return err
+	}
+
+	if err := cc.classificationService.RemoveLabel(ctx, req); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// bindLabel - read the song or music group, the kind of classification and its name from the path
+func (cc *ClassificationController) bindLabel(c echo.Context, target string) (models.LabelRequest, error) {
+	req := models.LabelRequest{
+		Target: target,
+		Name:   c.Param("name"),
+	}
+
+	kind, ok := labelKinds[c.Param("kinds")]
+	if !ok {
+		return req, fmt.Errorf("%w: %s", errUnknownClassification, c.Param("kinds"))
+	}
+
+	req.Kind = kind
+
+	id, err := pathId(c, "id")
+	if err != nil {
+		return req, err
+	}
+
+	req.TargetId = id
+
+	if err := cc.validator.Struct(&req); err != nil {
+		return req, fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	return req, nil
+}
@@ -0,0 +1,13 @@
+package apiv2
+
+import (
+	"github.com/Magic-Kot/effective-mobile/internal/apperror"
+)
+
+var (
+	errInvalidRequest        = apperror.Validation("invalid_request", "invalid request")
+	errInvalidId             = apperror.Validation("invalid_id", "invalid id")
+	errInvalidPosition       = apperror.Validation("invalid_position", "invalid position")
+	errUnknownClassification = apperror.Validation("unknown_classification", "unknown classification")
+	errNotSignedIn           = apperror.Unauthorized("missing_credentials", "an API key or a bearer token is required")
+)
@@ -0,0 +1,214 @@
+package apiv2
+
+import (
+	"net/http"
+
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+	"github.com/Magic-Kot/effective-mobile/internal/services/album"
+	"github.com/Magic-Kot/effective-mobile/internal/services/group"
+	"github.com/Magic-Kot/effective-mobile/internal/services/song"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+)
+
+type GroupController struct {
+	groupService group.GroupService
+	songService  song.SongService
+	albumService album.AlbumService
+	logger       *zerolog.Logger
+}
+
+func NewGroupController(groupService *group.GroupService, songService *song.SongService, albumService *album.AlbumService, logger *zerolog.Logger) *GroupController {
+	return &GroupController{
+		groupService: *groupService,
+		songService:  *songService,
+		albumService: *albumService,
+		logger:       logger,
+	}
+}
+
+// @Summary List Music Groups
+// @Tags groups v2
+// @Description get a page of music groups ordered by ID with the number of their songs and albums, a full page has a Link header to the next one
+// @ID v2-list-groups
+// @Produce  json
+// @Param after query int false "Music groups with the ID greater than this one, 0 by default"
+// @Param limit query int false "Enter the number of music groups to output, from 1 to 100, 20 by default"
+// @Param name query string false "Music groups with the name containing the string, ignoring case"
+// @Success 200 {object} []models.GroupResponse
+// @Failure 400 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/groups [get]
+func (gc *GroupController) GetAllGroups(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = gc.logger.WithContext(ctx)
+
+	gc.logger.Debug().Msg("starting the handler 'GetAllGroups'")
+
+	req := models.RequestGetAllGroups{
+		Name: c.QueryParam("name"),
+	}
+
+	var err error
+
+	if req.Id, req.Limit, err = page(c); err != nil {
+		return err
+	}
+
+	result, err := gc.groupService.GetAllGroups(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	if result == nil {
+		result = make([]models.GroupResponse, 0)
+	}
+
+	if len(result) > 0 {
+		setNextLink(c, len(result), req.Limit, result[len(result)-1].Id)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary Get Music Group
+// @Tags groups v2
+// @Description get the music group with its genres and tags
+// @ID v2-get-group
+// @Produce  json
+// @Param id path int true "Enter the ID of the music group"
+// @Success 200 {object} models.GroupResponse
+// @Failure 400,404 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/groups/{id} [get]
+func (gc *GroupController) GetGroup(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = gc.logger.WithContext(ctx)
+
+	gc.logger.Debug().Msg("starting the handler 'GetGroup'")
+
+	id, err := pathId(c, "id")
+	if err != nil {
+		return err
+	}
+
+	result, err := gc.groupService.GetGroup(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary List Music Group Songs
+// @Tags groups v2
+// @Description get a page of the songs crediting the music group, a full page has a Link header to the next one
+// @ID v2-list-group-songs
+// @Produce  json
+// @Param id path int true "Enter the ID of the music group"
+// @Param after query int false "Songs with the ID greater than this one, 0 by default"
+// @Param limit query int false "Enter the number of songs to output, from 1 to 100, 20 by default"
+// @Param role query string false "Songs crediting the music group with the role: primary, featured, composer, lyricist"
+// @Success 200 {object} []models.SongsResponse
+// @Failure 400,404 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/groups/{id}/songs [get]
+func (gc *GroupController) GetGroupSongs(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = gc.logger.WithContext(ctx)
+
+	gc.logger.Debug().Msg("starting the handler 'GetGroupSongs'")
+
+	id, err := pathId(c, "id")
+	if err != nil {
+		return err
+	}
+
+	req := models.RequestGetAll{
+		Role: c.QueryParam("role"),
+	}
+
+	if req.Id, req.Limit, err = page(c); err != nil {
+		return err
+	}
+
+	musicGroup, err := gc.groupService.GetGroup(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	req.Artist = musicGroup.Name
+
+	result, err := gc.songService.GetAllSong(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	if result == nil {
+		result = make([]models.SongsResponse, 0)
+	}
+
+	if len(result) > 0 {
+		setNextLink(c, len(result), req.Limit, result[len(result)-1].Id)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary List Music Group Albums
+// @Tags groups v2
+// @Description get a page of the albums of the music group, a full page has a Link header to the next one
+// @ID v2-list-group-albums
+// @Produce  json
+// @Param id path int true "Enter the ID of the music group"
+// @Param after query int false "Albums with the ID greater than this one, 0 by default"
+// @Param limit query int false "Enter the number of albums to output, from 1 to 100, 20 by default"
+// @Success 200 {object} []models.AlbumResponse
+// @Failure 400,404 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/groups/{id}/albums [get]
+func (gc *GroupController) GetGroupAlbums(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = gc.logger.WithContext(ctx)
+
+	gc.logger.Debug().Msg("starting the handler 'GetGroupAlbums'")
+
+	id, err := pathId(c, "id")
+	if err != nil {
+		return err
+	}
+
+	var req models.RequestGetAllAlbums
+
+	if req.Id, req.Limit, err = page(c); err != nil {
+		return err
+	}
+
+	musicGroup, err := gc.groupService.GetGroup(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	req.Group = musicGroup.Name
+
+	result, err := gc.albumService.GetAllAlbums(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	if result == nil {
+		result = make([]models.AlbumResponse, 0)
+	}
+
+	if len(result) > 0 {
+		setNextLink(c, len(result), req.Limit, result[len(result)-1].Id)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
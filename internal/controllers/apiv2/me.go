@@ -0,0 +1,331 @@
+package apiv2
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+	"github.com/Magic-Kot/effective-mobile/internal/services/auth"
+	"github.com/Magic-Kot/effective-mobile/internal/services/listening"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+)
+
+// MeController - the favorites, ratings and listening history of the signed-in user
+type MeController struct {
+	listeningService listening.ListeningService
+	logger           *zerolog.Logger
+	validator        *validator.Validate
+}
+
+func NewMeController(listeningService *listening.ListeningService, logger *zerolog.Logger, validator *validator.Validate) *MeController {
+	return &MeController{
+		listeningService: *listeningService,
+		logger:           logger,
+		validator:        validator,
+	}
+}
+
+// currentUser - the user of the request, see auth.UserId
+func currentUser(c echo.Context) (string, error) {
+	principal, ok := auth.PrincipalFrom(c.Request().Context())
+	if !ok {
+		return "", errNotSignedIn
+	}
+
+	return auth.UserId(principal), nil
+}
+
+// @Summary List Favorites
+// @Tags me v2
+// @Description get a page of the favorite songs of the user ordered by ID, a full page has a Link header to the next one
+// @ID v2-list-favorites
+// @Produce  json
+// @Param after query int false "Songs with the ID greater than this one, 0 by default"
+// @Param limit query int false "Enter the number of songs to output, from 1 to 100, 20 by default"
+// @Success 200 {object} []models.SongsResponse
+// @Failure 400,401 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/me/favorites [get]
+func (mc *MeController) GetFavorites(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = mc.logger.WithContext(ctx)
+
+	mc.logger.Debug().Msg("starting the handler 'GetFavorites'")
+
+	userId, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+
+	after, limit, err := page(c)
+	if err != nil {
+		return err
+	}
+
+	req := models.RequestGetFavorites{UserId: userId}
+	req.After, _ = strconv.Atoi(after)
+	req.Limit, _ = strconv.Atoi(limit)
+
+	result, err := mc.listeningService.GetFavorites(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	if result == nil {
+		result = make([]models.SongsResponse, 0)
+	}
+
+	if len(result) > 0 {
+		setNextLink(c, len(result), limit, result[len(result)-1].Id)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary Add Favorite
+// @Tags me v2
+// @Description add the song to the favorites of the user, adding it again changes nothing
+// @ID v2-add-favorite
+// @Param id path int true "Enter the ID of the song"
+// @Success 204
+// @Failure 400,401,404 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/me/favorites/{id} [put]
+func (mc *MeController) AddFavorite(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = mc.logger.WithContext(ctx)
+
+	mc.logger.Debug().Msg("starting the handler 'AddFavorite'")
+
+	userId, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := pathId(c, "id")
+	if err != nil {
+		return err
+	}
+
+	if err = mc.listeningService.AddFavorite(ctx, userId, id); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// @Summary Remove Favorite
+// @Tags me v2
+// @Description remove the song from the favorites of the user, removing a song that is not there changes nothing
+// @ID v2-remove-favorite
+// @Param id path int true "Enter the ID of the song"
+// @Success 204
+// @Failure 400,401 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/me/favorites/{id} [delete]
+func (mc *MeController) RemoveFavorite(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = mc.logger.WithContext(ctx)
+
+	mc.logger.Debug().Msg("starting the handler 'RemoveFavorite'")
+
+	userId, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := pathId(c, "id")
+	if err != nil {
+		return err
+	}
+
+	if err = mc.listeningService.RemoveFavorite(ctx, userId, id); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// @Summary Rate Song
+// @Tags me v2
+// @Description rate the song from 1 to 5, a new rating replaces the previous one of the user
+// @ID v2-rate-song
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Enter the ID of the song"
+// @Param input body models.RateSong true "The rating from 1 to 5"
+// @Success 200 {object} models.RatingResponse
+// @Failure 400,401,404 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/me/ratings/{id} [put]
+func (mc *MeController) RateSong(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = mc.logger.WithContext(ctx)
+
+	mc.logger.Debug().Msg("starting the handler 'RateSong'")
+
+	userId, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.RateSong
+	if err = c.Bind(&req); err != nil {
+		mc.logger.Debug().Msgf("bind: invalid request: %v", err)
+
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	if req.SongId, err = pathId(c, "id"); err != nil {
+		return err
+	}
+
+	if err = mc.validator.Struct(&req); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	result, err := mc.listeningService.RateSong(ctx, userId, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary Delete Rating
+// @Tags me v2
+// @Description remove the rating of the user from the song
+// @ID v2-delete-rating
+// @Param id path int true "Enter the ID of the song"
+// @Success 204
+// @Failure 400,401,404 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/me/ratings/{id} [delete]
+func (mc *MeController) DeleteRating(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = mc.logger.WithContext(ctx)
+
+	mc.logger.Debug().Msg("starting the handler 'DeleteRating'")
+
+	userId, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := pathId(c, "id")
+	if err != nil {
+		return err
+	}
+
+	if err = mc.listeningService.DeleteRating(ctx, userId, id); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// @Summary List Plays
+// @Tags me v2
+// @Description get a page of the listening history of the user, the latest play first; a full page has a Link header to the next one
+// @ID v2-list-plays
+// @Produce  json
+// @Param before query int false "Plays with the ID less than this one, the latest ones by default"
+// @Param limit query int false "Enter the number of plays to output, from 1 to 100, 20 by default"
+// @Success 200 {object} []models.PlayResponse
+// @Failure 400,401 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/me/plays [get]
+func (mc *MeController) GetPlays(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = mc.logger.WithContext(ctx)
+
+	mc.logger.Debug().Msg("starting the handler 'GetPlays'")
+
+	userId, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+
+	req := models.RequestGetPlays{UserId: userId}
+
+	if before := c.QueryParam("before"); before != "" {
+		if req.Before, err = strconv.ParseInt(before, 10, 64); err != nil || req.Before < 0 {
+			return fmt.Errorf("%w: before must be a non-negative number", errInvalidRequest)
+		}
+	}
+
+	if req.Limit, err = limitParam(c); err != nil {
+		return err
+	}
+
+	result, err := mc.listeningService.GetPlays(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	if result == nil {
+		result = make([]models.PlayResponse, 0)
+	}
+
+	if len(result) > 0 {
+		setCursorLink(c, len(result), strconv.Itoa(req.Limit), "before", result[len(result)-1].Id)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary Record Play
+// @Tags me v2
+// @Description add a play of the song to the listening history of the user
+// @ID v2-record-play
+// @Accept  json
+// @Produce  json
+// @Param input body models.RecordPlay true "The ID of the played song"
+// @Success 201 {object} models.PlayResponse
+// @Failure 400,401,404 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/me/plays [post]
+func (mc *MeController) RecordPlay(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = mc.logger.WithContext(ctx)
+
+	mc.logger.Debug().Msg("starting the handler 'RecordPlay'")
+
+	userId, err := currentUser(c)
+	if err != nil {
+		return err
+	}
+
+	var req models.RecordPlay
+	if err = c.Bind(&req); err != nil {
+		mc.logger.Debug().Msgf("bind: invalid request: %v", err)
+
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	if err = mc.validator.Struct(&req); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	result, err := mc.listeningService.RecordPlay(ctx, userId, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, result)
+}
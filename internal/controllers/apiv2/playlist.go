@@ -0,0 +1,464 @@
+package apiv2
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Magic-Kot/effective-mobile/internal/controllers"
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+	"github.com/Magic-Kot/effective-mobile/internal/services/playlist"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+)
+
+// maxPlaylistFileSize - the largest playlist file accepted by the import
+const maxPlaylistFileSize = 10 << 20
+
+type PlaylistController struct {
+	playlistService playlist.PlaylistService
+	logger          *zerolog.Logger
+	validator       *validator.Validate
+}
+
+func NewPlaylistController(playlistService *playlist.PlaylistService, logger *zerolog.Logger, validator *validator.Validate) *PlaylistController {
+	return &PlaylistController{
+		playlistService: *playlistService,
+		logger:          logger,
+		validator:       validator,
+	}
+}
+
+// @Summary List Playlists
+// @Tags playlists v2
+// @Description get a page of playlists ordered by ID, a full page has a Link header to the next one
+// @ID v2-list-playlists
+// @Produce  json
+// @Param after query int false "Playlists with the ID greater than this one, 0 by default"
+// @Param limit query int false "Enter the number of playlists to output, from 1 to 100, 20 by default"
+// @Param owner query string false "Enter the owner of the playlists"
+// @Success 200 {object} []models.PlaylistResponse
+// @Failure 400 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/playlists [get]
+func (pc *PlaylistController) GetAllPlaylists(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = pc.logger.WithContext(ctx)
+
+	pc.logger.Debug().Msg("starting the handler 'GetAllPlaylists'")
+
+	req := models.RequestGetAllPlaylists{
+		Owner: c.QueryParam("owner"),
+	}
+
+	var err error
+
+	if req.Id, req.Limit, err = page(c); err != nil {
+		return err
+	}
+
+	result, err := pc.playlistService.GetAllPlaylists(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	if result == nil {
+		result = make([]models.PlaylistResponse, 0)
+	}
+
+	if len(result) > 0 {
+		setNextLink(c, len(result), req.Limit, result[len(result)-1].Id)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary Create Playlist
+// @Tags playlists v2
+// @Description add a new playlist, optionally with songs in order, the response is the saved playlist and its location
+// @ID v2-create-playlist
+// @Accept  json
+// @Produce  json
+// @Param input body models.CreatePlaylist true "You need to specify the name and the owner of the playlist in the request body"
+// @Success 201 {object} models.PlaylistResponse
+// @Header 201 {string} Location "The URL of the playlist"
+// @Failure 400 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/playlists [post]
+func (pc *PlaylistController) CreatePlaylist(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = pc.logger.WithContext(ctx)
+
+	pc.logger.Debug().Msg("starting the handler 'CreatePlaylist'")
+
+	var req models.CreatePlaylist
+	if err := c.Bind(&req); err != nil {
+		pc.logger.Debug().Msgf("bind: invalid request: %v", err)
+
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	if err := pc.validator.Struct(&req); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	id, err := pc.playlistService.CreatePlaylist(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return pc.createdPlaylist(c, id)
+}
+
+// createdPlaylist - respond with the new playlist and its location
+func (pc *PlaylistController) createdPlaylist(c echo.Context, id int) error {
+	ctx := pc.logger.WithContext(c.Request().Context())
+
+	result, err := pc.playlistService.GetPlaylist(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return created(c, fmt.Sprintf("%s/playlists/%d", BasePath, id), result)
+}
+
+// @Summary Get Playlist
+// @Tags playlists v2
+// @Description get the playlist with its ordered entries
+// @ID v2-get-playlist
+// @Produce  json
+// @Param id path int true "Enter the ID of the saved playlist"
+// @Success 200 {object} models.PlaylistResponse
+// @Failure 400,404 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/playlists/{id} [get]
+func (pc *PlaylistController) GetPlaylist(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = pc.logger.WithContext(ctx)
+
+	pc.logger.Debug().Msg("starting the handler 'GetPlaylist'")
+
+	id, err := pathId(c, "id")
+	if err != nil {
+		return err
+	}
+
+	result, err := pc.playlistService.GetPlaylist(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary Replace Playlist
+// @Tags playlists v2
+// @Description replace the name and the description of a saved playlist
+// @ID v2-update-playlist
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Enter the playlist ID"
+// @Param input body models.UpdatePlaylist true "You need to specify the name of the playlist in the request body"
+// @Success 200 {object} models.PlaylistResponse
+// @Failure 400,404 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/playlists/{id} [put]
+func (pc *PlaylistController) UpdatePlaylist(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = pc.logger.WithContext(ctx)
+
+	pc.logger.Debug().Msg("starting the handler 'UpdatePlaylist'")
+
+	var req models.UpdatePlaylist
+	if err := c.Bind(&req); err != nil {
+		pc.logger.Debug().Msgf("bind: invalid request: %v", err)
+
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	id, err := pathId(c, "id")
+	if err != nil {
+		return err
+	}
+
+	req.Id = id
+
+	if err := pc.validator.Struct(&req); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	if err := pc.playlistService.UpdatePlaylist(ctx, req); err != nil {
+		return err
+	}
+
+	result, err := pc.playlistService.GetPlaylist(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary Delete Playlist
+// @Tags playlists v2
+// @Description delete a playlist, its songs stay in the library
+// @ID v2-delete-playlist
+// @Param id path int true "Enter the ID of the saved playlist"
+// @Success 204
+// @Failure 400,404 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/playlists/{id} [delete]
+func (pc *PlaylistController) DeletePlaylist(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = pc.logger.WithContext(ctx)
+
+	pc.logger.Debug().Msg("starting the handler 'DeletePlaylist'")
+
+	id, err := pathId(c, "id")
+	if err != nil {
+		return err
+	}
+
+	if err := pc.playlistService.DeletePlaylist(ctx, id); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// @Summary Add Playlist Entries
+// @Tags playlists v2
+// @Description insert songs into a playlist starting at the position, or append them if the position is 0
+// @ID v2-add-playlist-entries
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Enter the playlist ID"
+// @Param input body models.PlaylistEntriesRequest true "You need to specify the song IDs in the request body"
+// @Success 200 {object} models.PlaylistResponse
+// @Failure 400,404 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/playlists/{id}/entries [post]
+func (pc *PlaylistController) AddEntries(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = pc.logger.WithContext(ctx)
+
+	pc.logger.Debug().Msg("starting the handler 'AddEntries'")
+
+	var req models.PlaylistEntriesRequest
+	if err := c.Bind(&req); err != nil {
+		pc.logger.Debug().Msgf("bind: invalid request: %v", err)
+
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	id, err := pathId(c, "id")
+	if err != nil {
+		return err
+	}
+
+	req.Id = id
+
+	if err := pc.validator.Struct(&req); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	if err := pc.playlistService.AddEntries(ctx, req); err != nil {
+		return err
+	}
+
+	result, err := pc.playlistService.GetPlaylist(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary Move Playlist Entry
+// @Tags playlists v2
+// @Description move the entry at the position to the new position given as 'to'
+// @ID v2-move-playlist-entry
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Enter the playlist ID"
+// @Param position path int true "Enter the position of the entry"
+// @Param input body models.MoveEntryRequest true "You need to specify the new position as 'to' in the request body"
+// @Success 200 {object} models.PlaylistResponse
+// @Failure 400,404 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/playlists/{id}/entries/{position} [patch]
+func (pc *PlaylistController) MoveEntry(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = pc.logger.WithContext(ctx)
+
+	pc.logger.Debug().Msg("starting the handler 'MoveEntry'")
+
+	var req models.MoveEntryRequest
+	if err := c.Bind(&req); err != nil {
+		pc.logger.Debug().Msgf("bind: invalid request: %v", err)
+
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	id, err := pathId(c, "id")
+	if err != nil {
+		return err
+	}
+
+	position, err := strconv.Atoi(c.Param("position"))
+	if err != nil || position < 1 {
+		return errInvalidPosition
+	}
+
+	req.Id = id
+	req.From = position
+
+	if err := pc.validator.Struct(&req); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	if err := pc.playlistService.MoveEntry(ctx, req); err != nil {
+		return err
+	}
+
+	result, err := pc.playlistService.GetPlaylist(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary Remove Playlist Entry
+// @Tags playlists v2
+// @Description remove the entry at the position, the entries after it move up
+// @ID v2-remove-playlist-entry
+// @Param id path int true "Enter the playlist ID"
+// @Param position path int true "Enter the position of the entry"
+// @Success 204
+// @Failure 400,404 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/playlists/{id}/entries/{position} [delete]
+func (pc *PlaylistController) RemoveEntry(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = pc.logger.WithContext(ctx)
+
+	pc.logger.Debug().Msg("starting the handler 'RemoveEntry'")
+
+	id, err := pathId(c, "id")
+	if err != nil {
+		return err
+	}
+
+	position, err := strconv.Atoi(c.Param("position"))
+	if err != nil || position < 1 {
+		return errInvalidPosition
+	}
+
+	if err := pc.playlistService.RemoveEntry(ctx, id, position); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// @Summary Copy Playlist
+// @Tags playlists v2
+// @Description copy a playlist with its entries, the response is the copy and its location
+// @ID v2-copy-playlist
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Enter the ID of the playlist to copy"
+// @Param input body models.DuplicatePlaylist false "The name and the owner of the copy, taken from the original if empty"
+// @Success 201 {object} models.PlaylistResponse
+// @Header 201 {string} Location "The URL of the copy"
+// @Failure 400,404 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/playlists/{id}/copies [post]
+func (pc *PlaylistController) DuplicatePlaylist(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = pc.logger.WithContext(ctx)
+
+	pc.logger.Debug().Msg("starting the handler 'DuplicatePlaylist'")
+
+	var req models.DuplicatePlaylist
+	if err := c.Bind(&req); err != nil {
+		pc.logger.Debug().Msgf("bind: invalid request: %v", err)
+
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	id, err := pathId(c, "id")
+	if err != nil {
+		return err
+	}
+
+	req.Id = id
+
+	if err := pc.validator.Struct(&req); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	newId, err := pc.playlistService.DuplicatePlaylist(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return pc.createdPlaylist(c, newId)
+}
+
+// @Summary Import Playlist
+// @Tags playlists v2
+// @Description create a playlist from an M3U8, XSPF or JSPF file, entries are matched with library songs by group and title
+// @ID v2-import-playlist
+// @Accept  plain
+// @Produce  json
+// @Param format query string true "Enter the playlist format" Enums(m3u8, xspf, jspf)
+// @Param owner query string true "Enter the owner of the playlist"
+// @Param name query string false "Enter the name of the playlist, taken from the file if empty"
+// @Param input body string true "The playlist file"
+// @Success 201 {object} models.ImportPlaylistResponse
+// @Header 201 {string} Location "The URL of the playlist"
+// @Failure 400 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/playlists/import [post]
+func (pc *PlaylistController) ImportPlaylist(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = pc.logger.WithContext(ctx)
+
+	pc.logger.Debug().Msg("starting the handler 'ImportPlaylist'")
+
+	req := models.ImportPlaylist{
+		Format: c.QueryParam("format"),
+		Name:   c.QueryParam("name"),
+		Owner:  c.QueryParam("owner"),
+	}
+
+	if err := pc.validator.Struct(&req); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	body := http.MaxBytesReader(c.Response(), c.Request().Body, maxPlaylistFileSize)
+
+	result, err := pc.playlistService.ImportPlaylist(ctx, req, body)
+	if err != nil {
+		return controllers.FileTooLarge(err)
+	}
+
+	return created(c, fmt.Sprintf("%s/playlists/%d", BasePath, result.Id), result)
+}
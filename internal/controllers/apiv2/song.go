@@ -0,0 +1,358 @@
+package apiv2
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+	"github.com/Magic-Kot/effective-mobile/internal/services/song"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+)
+
+type SongController struct {
+	songService song.SongService
+	logger      *zerolog.Logger
+	validator   *validator.Validate
+}
+
+func NewSongController(songService *song.SongService, logger *zerolog.Logger, validator *validator.Validate) *SongController {
+	return &SongController{
+		songService: *songService,
+		logger:      logger,
+		validator:   validator,
+	}
+}
+
+// songFilters - the filters of the song listing shared by the songs and their facets
+func songFilters(c echo.Context) models.RequestGetAll {
+	return models.RequestGetAll{
+		Filter:        c.QueryParam("filter"),
+		Value:         c.QueryParam("value"),
+		CreatedAfter:  c.QueryParam("created_after"),
+		CreatedBefore: c.QueryParam("created_before"),
+		UpdatedAfter:  c.QueryParam("updated_after"),
+		UpdatedBefore: c.QueryParam("updated_before"),
+		Artist:        c.QueryParam("artist"),
+		Role:          c.QueryParam("role"),
+		Genre:         c.QueryParam("genre"),
+		Tag:           c.QueryParam("tag"),
+	}
+}
+
+// @Summary List Songs
+// @Tags songs v2
+// @Description get a page of songs ordered by ID or sorted by an aggregate, a full page has a Link header to the next one
+// @ID v2-list-songs
+// @Produce  json
+// @Param after query int false "Songs after the one with this ID in the order of the listing, 0 by default"
+// @Param limit query int false "Enter the number of songs to output, from 1 to 100, 20 by default"
+// @Param sort query string false "Sort by rating, plays or favorites with the ID breaking ties, a - before it sorts in descending order"
+// @Param filter query string false "Enter the column name"
+// @Param value query string false "Enter the required column value"
+// @Param created_after query string false "Songs created at or after the RFC 3339 timestamp"
+// @Param created_before query string false "Songs created before the RFC 3339 timestamp"
+// @Param updated_after query string false "Songs updated at or after the RFC 3339 timestamp"
+// @Param updated_before query string false "Songs updated before the RFC 3339 timestamp"
+// @Param artist query string false "Songs crediting the music group"
+// @Param role query string false "Songs crediting a music group with the role: primary, featured, composer, lyricist"
+// @Param genre query string false "Songs of any of the comma separated genres"
+// @Param tag query string false "Songs with any of the comma separated tags"
+// @Success 200 {object} []models.SongsResponse
+// @Failure 400 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/songs [get]
+func (sc *SongController) GetAllSongs(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = sc.logger.WithContext(ctx)
+
+	sc.logger.Debug().Msg("starting the handler 'GetAllSongs'")
+
+	req := songFilters(c)
+	req.Sort = c.QueryParam("sort")
+
+	var err error
+
+	if req.Id, req.Limit, err = page(c); err != nil {
+		return err
+	}
+
+	result, err := sc.songService.GetAllSong(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	if result == nil {
+		result = make([]models.SongsResponse, 0)
+	}
+
+	if len(result) > 0 {
+		setNextLink(c, len(result), req.Limit, result[len(result)-1].Id)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary Song Facets
+// @Tags songs v2
+// @Description get the genre and tag counts of all the songs matching the filters of the song listing
+// @ID v2-song-facets
+// @Produce  json
+// @Param filter query string false "Enter the column name"
+// @Param value query string false "Enter the required column value"
+// @Param artist query string false "Songs crediting the music group"
+// @Param role query string false "Songs crediting a music group with the role: primary, featured, composer, lyricist"
+// @Param genre query string false "Songs of any of the comma separated genres"
+// @Param tag query string false "Songs with any of the comma separated tags"
+// @Success 200 {object} models.SongFacets
+// @Failure 400 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/songs/facets [get]
+func (sc *SongController) GetSongFacets(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = sc.logger.WithContext(ctx)
+
+	sc.logger.Debug().Msg("starting the handler 'GetSongFacets'")
+
+	req := songFilters(c)
+	req.Id = "0"
+	req.Limit = "0"
+
+	result, err := sc.songService.GetSongFacets(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary Search Songs
+// @Tags songs v2
+// @Description get the songs with the title, the primary group or the lyrics containing the text, ignoring case
+// @ID v2-search-songs
+// @Produce  json
+// @Param q query string true "Enter the text to search for"
+// @Param limit query int false "Enter the number of songs to output, from 1 to 100, 20 by default"
+// @Success 200 {object} []models.SongsResponse
+// @Failure 400 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/songs/search [get]
+func (sc *SongController) SearchSongs(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = sc.logger.WithContext(ctx)
+
+	sc.logger.Debug().Msg("starting the handler 'SearchSongs'")
+
+	limit, err := limitParam(c)
+	if err != nil {
+		return err
+	}
+
+	result, err := sc.songService.SearchSongs(ctx, c.QueryParam("q"), limit)
+	if err != nil {
+		return err
+	}
+
+	if result == nil {
+		result = make([]models.SongsResponse, 0)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary Create Song
+// @Tags songs v2
+// @Description add a new song, the response is the saved song and its location
+// @ID v2-create-song
+// @Accept  json
+// @Produce  json
+// @Param input body models.CreateSong true "You need to specify the name of the band and the song in the request body"
+// @Success 201 {object} models.SongsResponse
+// @Header 201 {string} Location "The URL of the song"
+// @Failure 400,409 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 502 {object} models.Problem "the music info service failed and MUSIC_REQUIRED is set"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/songs [post]
+func (sc *SongController) CreateSong(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = sc.logger.WithContext(ctx)
+
+	sc.logger.Debug().Msg("starting the handler 'CreateSong'")
+
+	var req models.CreateSong
+	if err := c.Bind(&req); err != nil {
+		sc.logger.Debug().Msgf("bind: invalid request: %v", err)
+
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	if err := sc.validator.Struct(&req); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	id, err := sc.songService.AddSong(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	result, err := sc.songService.GetSong(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return created(c, fmt.Sprintf("%s/songs/%d", BasePath, id), result)
+}
+
+// @Summary Get Song
+// @Tags songs v2
+// @Description get the song with its music groups, genres and tags
+// @ID v2-get-song
+// @Produce  json
+// @Param id path int true "Enter the ID of the saved song"
+// @Success 200 {object} models.SongsResponse
+// @Failure 400,404 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/songs/{id} [get]
+func (sc *SongController) GetSong(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = sc.logger.WithContext(ctx)
+
+	sc.logger.Debug().Msg("starting the handler 'GetSong'")
+
+	id, err := pathId(c, "id")
+	if err != nil {
+		return err
+	}
+
+	result, err := sc.songService.GetSong(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary Replace Song
+// @Tags songs v2
+// @Description replace the details of a saved song, the credited music groups are kept unless artists are given
+// @ID v2-update-song
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Enter the song ID"
+// @Param input body models.UpdateRequest true "The new details of the song"
+// @Success 200 {object} models.SongsResponse
+// @Failure 400,404 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/songs/{id} [put]
+func (sc *SongController) UpdateSong(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = sc.logger.WithContext(ctx)
+
+	sc.logger.Debug().Msg("starting the handler 'UpdateSong'")
+
+	var req models.UpdateRequest
+	if err := c.Bind(&req); err != nil {
+		sc.logger.Debug().Msgf("bind: invalid request: %v", err)
+
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	id, err := pathId(c, "id")
+	if err != nil {
+		return err
+	}
+
+	req.Id = id
+
+	if err := sc.validator.Struct(&req); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	if err := sc.songService.UpdateSong(ctx, req); err != nil {
+		return err
+	}
+
+	result, err := sc.songService.GetSong(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary Delete Song
+// @Tags songs v2
+// @Description delete a song from the library and from every playlist holding it
+// @ID v2-delete-song
+// @Param id path int true "Enter the ID of the saved song"
+// @Success 204
+// @Failure 400,404 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/songs/{id} [delete]
+func (sc *SongController) DeleteSong(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = sc.logger.WithContext(ctx)
+
+	sc.logger.Debug().Msg("starting the handler 'DeleteSong'")
+
+	id, err := pathId(c, "id")
+	if err != nil {
+		return err
+	}
+
+	if err := sc.songService.DeleteSong(ctx, id); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// @Summary Get Song Verse
+// @Tags songs v2
+// @Description get a verse of the lyrics, the verses are separated by an empty line and numbered from 0
+// @ID v2-get-song-verse
+// @Produce  json
+// @Param id path int true "Enter the ID of the saved song"
+// @Param verse path int true "Enter the verse number"
+// @Success 200 {object} models.VerseResponse
+// @Failure 400,404 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /api/v2/songs/{id}/verses/{verse} [get]
+func (sc *SongController) GetVerse(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = sc.logger.WithContext(ctx)
+
+	sc.logger.Debug().Msg("starting the handler 'GetVerse'")
+
+	id, err := pathId(c, "id")
+	if err != nil {
+		return err
+	}
+
+	verse, err := strconv.Atoi(c.Param("verse"))
+	if err != nil {
+		return fmt.Errorf("%w: verse must be a number", errInvalidRequest)
+	}
+
+	text, err := sc.songService.GetLyricsSong(ctx, strconv.Itoa(id), strconv.Itoa(verse))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, models.VerseResponse{SongId: id, Verse: verse, Text: text})
+}
@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+	"github.com/Magic-Kot/effective-mobile/internal/services/bulk"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+)
+
+// maxImportFileSize - the largest file accepted by the songs import
+const maxImportFileSize = 100 << 20
+
+type BulkController struct {
+	bulkService bulk.BulkService
+	logger      *zerolog.Logger
+	validator   *validator.Validate
+}
+
+func NewBulkController(bulkService *bulk.BulkService, logger *zerolog.Logger, validator *validator.Validate) *BulkController {
+	return &BulkController{
+		bulkService: *bulkService,
+		logger:      logger,
+		validator:   validator,
+	}
+}
+
+// @Summary Import Songs
+// @Tags bulk
+// @Description add songs from a CSV file with a group,song,release_date,text,link header or from NDJSON, one object per line. Every batch is imported in a transaction, the report gives the status of each row
+// @Accept  text/csv,application/x-ndjson
+// @Produce  json
+// @Param format query string true "Enter the file format: csv or ndjson"
+// @Param dry_run query bool false "Check the rows without saving them"
+// @Param enrich query bool false "Fill the missing details from the music info service"
+// @Param batch_size query int false "Enter the number of rows imported in one transaction, 100 by default"
+// @Param input body string true "The file to import"
+// @Success 200 {object} models.ImportSongsReport
+// @Failure 400 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /song/import [post]
+// @Router /api/v2/songs/import [post]
+func (bc *BulkController) ImportSongs(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = bc.logger.WithContext(ctx)
+
+	bc.logger.Debug().Msg("starting the handler 'ImportSongs'")
+
+	req := models.ImportSongsRequest{
+		Format: c.QueryParam("format"),
+	}
+
+	var err error
+
+	if value := c.QueryParam("dry_run"); value != "" {
+		if req.DryRun, err = strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("%w: dry_run must be a boolean", errInvalidRequest)
+		}
+	}
+
+	if value := c.QueryParam("enrich"); value != "" {
+		if req.Enrich, err = strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("%w: enrich must be a boolean", errInvalidRequest)
+		}
+	}
+
+	if value := c.QueryParam("batch_size"); value != "" {
+		if req.BatchSize, err = strconv.Atoi(value); err != nil {
+			return fmt.Errorf("%w: batch_size must be a number", errInvalidRequest)
+		}
+	}
+
+	if err := bc.validator.Struct(&req); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	body := http.MaxBytesReader(c.Response(), c.Request().Body, maxImportFileSize)
+
+	result, err := bc.bulkService.ImportSongs(ctx, req, body)
+	if err != nil {
+		bc.logger.Debug().Msgf("songs import failed: %v", err)
+		return FileTooLarge(err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary Export Songs
+// @Tags bulk
+// @Description export all the songs matching the filters of the song listing with their music groups, lyrics, genres and tags. The songs are streamed as they are read from the database
+// @Accept  json
+// @Produce  json,text/csv,application/x-ndjson
+// @Param format query string true "Enter the export format" Enums(json, ndjson, csv)
+// @Param filter query string false "Enter the column name"
+// @Param value query string false "Enter the required column value"
+// @Param created_after query string false "Songs created at or after the RFC 3339 timestamp"
+// @Param created_before query string false "Songs created before the RFC 3339 timestamp"
+// @Param updated_after query string false "Songs updated at or after the RFC 3339 timestamp"
+// @Param updated_before query string false "Songs updated before the RFC 3339 timestamp"
+// @Param artist query string false "Songs crediting the music group"
+// @Param role query string false "Songs crediting a music group with the role: primary, featured, composer, lyricist"
+// @Param genre query string false "Songs of any of the comma separated genres"
+// @Param tag query string false "Songs with any of the comma separated tags"
+// @Success 200 {object} []models.ExportSong
+// @Failure 400 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /song/export [get]
+// @Router /api/v2/songs/export [get]
+func (bc *BulkController) ExportSongs(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = bc.logger.WithContext(ctx)
+
+	bc.logger.Debug().Msg("starting the handler 'ExportSongs'")
+
+	format := c.QueryParam("format")
+	switch format {
+	case models.FormatJSON, models.FormatNDJSON, models.FormatCSV:
+	default:
+		return errInvalidFormat
+	}
+
+	var req models.RequestGetAll
+
+	req.Filter = c.QueryParam("filter")
+	req.Value = c.QueryParam("value")
+	req.CreatedAfter = c.QueryParam("created_after")
+	req.CreatedBefore = c.QueryParam("created_before")
+	req.UpdatedAfter = c.QueryParam("updated_after")
+	req.UpdatedBefore = c.QueryParam("updated_before")
+	req.Artist = c.QueryParam("artist")
+	req.Role = c.QueryParam("role")
+	req.Genre = c.QueryParam("genre")
+	req.Tag = c.QueryParam("tag")
+
+	header := c.Response().Header()
+	header.Set(echo.HeaderContentType, bulk.ContentType(format))
+	header.Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="songs.%s"`, format))
+
+	// the status is sent with the first song, after it an error can only cut the export short
+	if _, err := bc.bulkService.ExportSongs(ctx, format, req, c.Response()); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+	"github.com/Magic-Kot/effective-mobile/internal/services/classification"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+)
+
+type ClassificationController struct {
+	classificationService classification.ClassificationService
+	logger                *zerolog.Logger
+	validator             *validator.Validate
+}
+
+func NewClassificationController(classificationService *classification.ClassificationService, logger *zerolog.Logger, validator *validator.Validate) *ClassificationController {
+	return &ClassificationController{
+		classificationService: *classificationService,
+		logger:                logger,
+		validator:             validator,
+	}
+}
+
+// @Summary Get All Genres Or Tags
+// @Tags classification
+// @Description get all genres or tags with the number of songs of each
+// @ID get-all-labels
+// @Accept  json
+// @Produce  json
+// @Param kind path string true "Kind of classification" Enums(genre, tag)
+// @Success 200 {object} []models.LabelResponse
+// @Failure 400 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /classify/{kind}/all [get]
+func (cc *ClassificationController) GetAllLabels(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = cc.logger.WithContext(ctx)
+
+	cc.logger.Debug().Msg("starting the handler 'GetAllLabels'")
+
+	kind := c.Param("kind")
+	if kind != models.KindGenre && kind != models.KindTag {
+		return errUnknownClassification
+	}
+
+	result, err := cc.classificationService.GetAllLabels(ctx, kind)
+	if err != nil {
+		return err
+	}
+
+	if result == nil {
+		result = make([]models.LabelResponse, 0)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary Assign Genre Or Tag
+// @Tags classification
+// @Description assign a genre or tag to a song or music group, the genre or tag is created if it does not exist
+// @ID assign-label
+// @Accept  json
+// @Produce  json
+// @Param kind path string true "Kind of classification" Enums(genre, tag)
+// @Param target path string true "What to classify" Enums(song, group)
+// @Param id path int true "Enter the ID of the song or music group"
+// @Param input body models.LabelRequest true "You need to specify the name of the genre or tag in the request body"
+// @Success 200 {string} string
+// @Failure 400,404 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /classify/{kind}/{target}/{id} [post]
+func (cc *ClassificationController) AssignLabel(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = cc.logger.WithContext(ctx)
+
+	cc.logger.Debug().Msg("starting the handler 'AssignLabel'")
+
+	var req models.LabelRequest
+	if err := c.Bind(&req); err != nil {
+		cc.logger.Debug().Msgf("bind: invalid request: %v", err)
+
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	if err := cc.bindTarget(c, &req); err != nil {
+		return err
+	}
+
+	if err := cc.validator.Struct(&req); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	if err := cc.classificationService.AssignLabel(ctx, req); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, fmt.Sprintf("successfully assigned %s", req.Kind))
+}
+
+// @Summary Remove Genre Or Tag
+// @Tags classification
+// @Description remove a genre or tag from a song or music group
+// @ID remove-label
+// @Accept  json
+// @Produce  json
+// @Param kind path string true "Kind of classification" Enums(genre, tag)
+// @Param target path string true "What to classify" Enums(song, group)
+// @Param id path int true "Enter the ID of the song or music group"
+// @Param name query string true "Enter the name of the genre or tag"
+// @Success 200 {string} string
+// @Failure 400,404 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /classify/{kind}/{target}/{id} [delete]
+func (cc *ClassificationController) RemoveLabel(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = cc.logger.WithContext(ctx)
+
+	cc.logger.Debug().Msg("starting the handler 'RemoveLabel'")
+
+	var req models.LabelRequest
+
+	if err := cc.bindTarget(c, &req); err != nil {
+		return err
+	}
+
+	req.Name = c.QueryParam("name")
+
+	if err := cc.validator.Struct(&req); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	if err := cc.classificationService.RemoveLabel(ctx, req); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, fmt.Sprintf("successfully removed %s", req.Kind))
+}
+
+// bindTarget - read the kind of classification and what it is assigned to from the path
+func (cc *ClassificationController) bindTarget(c echo.Context, req *models.LabelRequest) error {
+	req.Kind = c.Param("kind")
+	if req.Kind != models.KindGenre && req.Kind != models.KindTag {
+		return fmt.Errorf("%w: %s", errUnknownClassification, req.Kind)
+	}
+
+	req.Target = c.Param("target")
+	if req.Target != models.TargetSong && req.Target != models.TargetGroup {
+		return fmt.Errorf("%w: unknown target: %s", errInvalidRequest, req.Target)
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return errInvalidId
+	}
+
+	req.TargetId = id
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Magic-Kot/effective-mobile/internal/apperror"
+)
+
+var (
+	errInvalidRequest        = apperror.Validation("invalid_request", "invalid request")
+	errInvalidId             = apperror.Validation("invalid_id", "invalid id")
+	errInvalidPosition       = apperror.Validation("invalid_position", "invalid position")
+	errInvalidFormat         = apperror.Validation("invalid_format", "invalid format")
+	errFileTooLarge          = apperror.Validation("file_too_large", "the file is too large")
+	errUnknownClassification = apperror.Validation("unknown_classification", "unknown classification")
+	errNoSongs               = apperror.NotFound("songs_not_found", "no songs found")
+	errNoAlbums              = apperror.NotFound("albums_not_found", "no albums found")
+	errNoPlaylists           = apperror.NotFound("playlists_not_found", "no playlists found")
+)
+
+// FileTooLarge - errFileTooLarge if the body read with http.MaxBytesReader is over its limit,
+// the file uploads of both API versions answer with it
+func FileTooLarge(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return fmt.Errorf("%w: the limit is %d bytes", errFileTooLarge, maxBytesErr.Limit)
+	}
+
+	return err
+}
@@ -0,0 +1,466 @@
+package controllers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+	"github.com/Magic-Kot/effective-mobile/internal/services/playlist"
+	"github.com/Magic-Kot/effective-mobile/pkg/playlistformat"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+)
+
+// maxPlaylistFileSize - the largest playlist file accepted by the import
+const maxPlaylistFileSize = 10 << 20
+
+type PlaylistController struct {
+	playlistService playlist.PlaylistService
+	logger          *zerolog.Logger
+	validator       *validator.Validate
+}
+
+func NewPlaylistController(playlistService *playlist.PlaylistService, logger *zerolog.Logger, validator *validator.Validate) *PlaylistController {
+	return &PlaylistController{
+		playlistService: *playlistService,
+		logger:          logger,
+		validator:       validator,
+	}
+}
+
+// @Summary Add Playlist
+// @Tags playlists
+// @Description add a new playlist, optionally with songs in order
+// @ID add-playlist
+// @Accept  json
+// @Produce  json
+// @Param input body models.CreatePlaylist true "You need to specify the name and the owner of the playlist in the request body"
+// @Success 200 {string} string
+// @Failure 400 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /playlist/create [post]
+func (pc *PlaylistController) CreatePlaylist(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = pc.logger.WithContext(ctx)
+
+	pc.logger.Debug().Msg("starting the handler 'CreatePlaylist'")
+
+	req := new(models.CreatePlaylist)
+	if err := c.Bind(req); err != nil {
+		pc.logger.Debug().Msgf("bind: invalid request: %v", err)
+
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	if err := pc.validator.Struct(req); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	id, err := pc.playlistService.CreatePlaylist(ctx, *req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, fmt.Sprintf("successfully created playlist, id: %d", id))
+}
+
+// @Summary Get All Playlists
+// @Tags playlists
+// @Description get all saved playlists
+// @ID get-all-playlists
+// @Accept  json
+// @Produce  json
+// @Param id query string true "Enter the entry id in the table"
+// @Param limit query string true "Enter the number of playlists to output"
+// @Param owner query string false "Enter the owner of the playlists"
+// @Success 200 {object} []models.PlaylistResponse
+// @Failure 404 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /playlist/all [get]
+func (pc *PlaylistController) GetAllPlaylists(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = pc.logger.WithContext(ctx)
+
+	pc.logger.Debug().Msg("starting the handler 'GetAllPlaylists'")
+
+	var req models.RequestGetAllPlaylists
+
+	req.Id = c.QueryParam("id")
+	req.Limit = c.QueryParam("limit")
+	req.Owner = c.QueryParam("owner")
+
+	result, err := pc.playlistService.GetAllPlaylists(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	if result == nil {
+		return errNoPlaylists
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary Get Playlist
+// @Tags playlists
+// @Description get the playlist with its ordered entries
+// @ID get-playlist
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Enter the ID of the saved playlist"
+// @Success 200 {object} models.PlaylistResponse
+// @Failure 400,404 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /playlist/get/{id} [get]
+func (pc *PlaylistController) GetPlaylist(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = pc.logger.WithContext(ctx)
+
+	pc.logger.Debug().Msg("starting the handler 'GetPlaylist'")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return errInvalidId
+	}
+
+	result, err := pc.playlistService.GetPlaylist(ctx, id)
+	if err != nil {
+		pc.logger.Debug().Msgf("error receiving playlist data: %v", err)
+		return err
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
+
+// @Summary Update Playlist
+// @Tags playlists
+// @Description update the name and the description of a playlist
+// @ID update-playlist
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Enter the playlist ID"
+// @Param input body models.UpdatePlaylist true "You need to specify the name of the playlist in the request body"
+// @Success 200 {string} string
+// @Failure 400,404 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /playlist/update/{id} [put]
+func (pc *PlaylistController) UpdatePlaylist(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = pc.logger.WithContext(ctx)
+
+	pc.logger.Debug().Msg("starting the handler 'UpdatePlaylist'")
+
+	var req models.UpdatePlaylist
+	if err := c.Bind(&req); err != nil {
+		pc.logger.Debug().Msgf("bind: invalid request: %v", err)
+
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return errInvalidId
+	}
+
+	req.Id = id
+
+	if err := pc.validator.Struct(&req); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	if err := pc.playlistService.UpdatePlaylist(ctx, req); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, fmt.Sprint("successfully updated"))
+}
+
+// @Summary Delete Playlist
+// @Tags playlists
+// @Description delete a playlist
+// @ID delete-playlist
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Enter the ID of the saved playlist"
+// @Success 200 {string} string
+// @Failure 400,404 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /playlist/delete/{id} [delete]
+func (pc *PlaylistController) DeletePlaylist(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = pc.logger.WithContext(ctx)
+
+	pc.logger.Debug().Msg("starting the handler 'DeletePlaylist'")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return errInvalidId
+	}
+
+	if err := pc.playlistService.DeletePlaylist(ctx, id); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, fmt.Sprintf("successfully deleted playlist: %d", id))
+}
+
+// @Summary Add Playlist Entries
+// @Tags playlists
+// @Description insert songs into a playlist at the position, position 0 appends them to the end
+// @ID add-playlist-entries
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Enter the playlist ID"
+// @Param input body models.PlaylistEntriesRequest true "You need to specify the song IDs in the request body"
+// @Success 200 {string} string
+// @Failure 400,404 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /playlist/entries/{id} [post]
+func (pc *PlaylistController) AddEntries(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = pc.logger.WithContext(ctx)
+
+	pc.logger.Debug().Msg("starting the handler 'AddEntries'")
+
+	var req models.PlaylistEntriesRequest
+	if err := c.Bind(&req); err != nil {
+		pc.logger.Debug().Msgf("bind: invalid request: %v", err)
+
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return errInvalidId
+	}
+
+	req.Id = id
+
+	if err := pc.validator.Struct(&req); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	if err := pc.playlistService.AddEntries(ctx, req); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, fmt.Sprint("successfully added entries"))
+}
+
+// @Summary Remove Playlist Entry
+// @Tags playlists
+// @Description remove the entry at the position from a playlist
+// @ID remove-playlist-entry
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Enter the playlist ID"
+// @Param position query int true "Enter the position of the entry"
+// @Success 200 {string} string
+// @Failure 400,404 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /playlist/entries/{id} [delete]
+func (pc *PlaylistController) RemoveEntry(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = pc.logger.WithContext(ctx)
+
+	pc.logger.Debug().Msg("starting the handler 'RemoveEntry'")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return errInvalidId
+	}
+
+	position, err := strconv.Atoi(c.QueryParam("position"))
+	if err != nil || position < 1 {
+		return errInvalidPosition
+	}
+
+	if err := pc.playlistService.RemoveEntry(ctx, id, position); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, fmt.Sprint("successfully removed entry"))
+}
+
+// @Summary Move Playlist Entry
+// @Tags playlists
+// @Description move an entry of a playlist to another position
+// @ID move-playlist-entry
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Enter the playlist ID"
+// @Param input body models.MoveEntryRequest true "You need to specify the current and the new position in the request body"
+// @Success 200 {string} string
+// @Failure 400,404 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /playlist/move/{id} [put]
+func (pc *PlaylistController) MoveEntry(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = pc.logger.WithContext(ctx)
+
+	pc.logger.Debug().Msg("starting the handler 'MoveEntry'")
+
+	var req models.MoveEntryRequest
+	if err := c.Bind(&req); err != nil {
+		pc.logger.Debug().Msgf("bind: invalid request: %v", err)
+
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return errInvalidId
+	}
+
+	req.Id = id
+
+	if err := pc.validator.Struct(&req); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	if err := pc.playlistService.MoveEntry(ctx, req); err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, fmt.Sprint("successfully moved entry"))
+}
+
+// @Summary Duplicate Playlist
+// @Tags playlists
+// @Description copy a playlist with its entries
+// @ID duplicate-playlist
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Enter the ID of the playlist to copy"
+// @Param input body models.DuplicatePlaylist false "The name and the owner of the copy, taken from the original if empty"
+// @Success 200 {string} string
+// @Failure 400,404 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /playlist/duplicate/{id} [post]
+func (pc *PlaylistController) DuplicatePlaylist(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = pc.logger.WithContext(ctx)
+
+	pc.logger.Debug().Msg("starting the handler 'DuplicatePlaylist'")
+
+	var req models.DuplicatePlaylist
+	if err := c.Bind(&req); err != nil {
+		pc.logger.Debug().Msgf("bind: invalid request: %v", err)
+
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return errInvalidId
+	}
+
+	req.Id = id
+
+	if err := pc.validator.Struct(&req); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	newId, err := pc.playlistService.DuplicatePlaylist(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, fmt.Sprintf("successfully duplicated playlist, id: %d", newId))
+}
+
+// @Summary Export Playlist
+// @Tags playlists
+// @Description export a playlist as M3U8, XSPF or JSPF, the stored link of each song is used as its location
+// @Produce  plain
+// @Param id path int true "Enter the ID of the saved playlist"
+// @Param format query string true "Enter the playlist format" Enums(m3u8, xspf, jspf)
+// @Success 200 {file} file
+// @Failure 400,404 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /playlist/export/{id} [get]
+// @Router /api/v2/playlists/{id}/export [get]
+func (pc *PlaylistController) ExportPlaylist(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = pc.logger.WithContext(ctx)
+
+	pc.logger.Debug().Msg("starting the handler 'ExportPlaylist'")
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return errInvalidId
+	}
+
+	format := c.QueryParam("format")
+	if !playlistformat.IsSupported(format) {
+		return errInvalidFormat
+	}
+
+	var buf bytes.Buffer
+
+	if err := pc.playlistService.ExportPlaylist(ctx, id, format, &buf); err != nil {
+		return err
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="playlist-%d.%s"`, id, format))
+
+	return c.Blob(http.StatusOK, playlistformat.ContentType(format), buf.Bytes())
+}
+
+// @Summary Import Playlist
+// @Tags playlists
+// @Description create a playlist from an M3U8, XSPF or JSPF file, entries are matched with library songs by group and title
+// @ID import-playlist
+// @Accept  plain
+// @Produce  json
+// @Param format query string true "Enter the playlist format" Enums(m3u8, xspf, jspf)
+// @Param owner query string true "Enter the owner of the playlist"
+// @Param name query string false "Enter the name of the playlist, taken from the file if empty"
+// @Param input body string true "The playlist file"
+// @Success 200 {object} models.ImportPlaylistResponse
+// @Failure 400 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /playlist/import [post]
+func (pc *PlaylistController) ImportPlaylist(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = pc.logger.WithContext(ctx)
+
+	pc.logger.Debug().Msg("starting the handler 'ImportPlaylist'")
+
+	req := models.ImportPlaylist{
+		Format: c.QueryParam("format"),
+		Name:   c.QueryParam("name"),
+		Owner:  c.QueryParam("owner"),
+	}
+
+	if err := pc.validator.Struct(&req); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	body := http.MaxBytesReader(c.Response(), c.Request().Body, maxPlaylistFileSize)
+
+	result, err := pc.playlistService.ImportPlaylist(ctx, req, body)
+	if err != nil {
+		return FileTooLarge(err)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
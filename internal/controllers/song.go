@@ -35,8 +35,11 @@ func NewApiController(songService *song.SongService, logger *zerolog.Logger, val
 // @Produce  json
 // @Param input body models.CreateSong true "You need to specify the name of the band and the song in the request body"
 // @Success 200 {string} string
-// @Failure 400 {string} string
-// @Failure 500 {string} string
+// @Failure 400,409 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Failure 502 {object} models.Problem "the music info service failed and MUSIC_REQUIRED is set"
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /song/create [post]
 func (ac *ApiController) AddSong(c echo.Context) error {
 	ctx := c.Request().Context()
@@ -48,38 +51,16 @@ func (ac *ApiController) AddSong(c echo.Context) error {
 	if err := c.Bind(req); err != nil {
 		ac.logger.Debug().Msgf("bind: invalid request: %v", err)
 
-		return c.JSON(http.StatusBadRequest, fmt.Sprint("invalid request"))
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
 	}
 
-	err := ac.validator.Struct(req)
-	if err != nil {
-		for _, err := range err.(validator.ValidationErrors) {
-			if err.StructField() == "group" {
-				switch err.Tag() {
-				case "required":
-					return c.JSON(http.StatusBadRequest, fmt.Sprintf("Enter the name of the group"))
-				case "min":
-					return c.JSON(http.StatusBadRequest, fmt.Sprintf("The minimum length of the group name is 2 characters"))
-				case "max":
-					return c.JSON(http.StatusBadRequest, fmt.Sprintf("The maximum length of the group name is 20 characters"))
-				}
-			}
-
-			if err.StructField() == "song" {
-				switch err.Tag() {
-				case "required":
-					return c.JSON(http.StatusBadRequest, fmt.Sprintf("Enter the name of the song"))
-				case "min":
-					return c.JSON(http.StatusBadRequest, fmt.Sprintf("The minimum length of the song name is 2 characters"))
-				}
-			}
-		}
-		return c.JSON(http.StatusBadRequest, err.Error())
+	if err := ac.validator.Struct(req); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
 	}
 
 	id, err := ac.songService.AddSong(ctx, *req)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, fmt.Sprintf("successfully created song, id: %d", id))
@@ -92,12 +73,23 @@ func (ac *ApiController) AddSong(c echo.Context) error {
 // @Accept  json
 // @Produce  json
 // @Param id query string true "Enter the entry id in the table"
-// @Param limit query string true "Enter the number of songs to output"
+// @Param limit query string true "Enter the number of songs to output, from 1 to 1000"
 // @Param filter query string false "Enter the column name"
 // @Param value query string false "Enter the required column value"
-// @Success 200 {object} []models.SongsResponse
-// @Failure 404 {string} string
-// @Failure 500 {string} string
+// @Param created_after query string false "Songs created at or after the RFC 3339 timestamp"
+// @Param created_before query string false "Songs created before the RFC 3339 timestamp"
+// @Param updated_after query string false "Songs updated at or after the RFC 3339 timestamp"
+// @Param updated_before query string false "Songs updated before the RFC 3339 timestamp"
+// @Param artist query string false "Songs crediting the music group"
+// @Param role query string false "Songs crediting a music group with the role: primary, featured, composer, lyricist"
+// @Param genre query string false "Songs of any of the comma separated genres"
+// @Param tag query string false "Songs with any of the comma separated tags"
+// @Param facets query bool false "Wrap the songs into an object with the genre and tag counts of all matching songs"
+// @Success 200 {object} []models.SongsResponse "a list of songs, or models.SongsFacetedResponse when facets=true"
+// @Failure 404 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /song/all [get]
 func (ac *ApiController) GetAllSong(c echo.Context) error {
 	ctx := c.Request().Context()
@@ -111,14 +103,36 @@ func (ac *ApiController) GetAllSong(c echo.Context) error {
 	req.Limit = c.QueryParam("limit")
 	req.Filter = c.QueryParam("filter")
 	req.Value = c.QueryParam("value")
+	req.CreatedAfter = c.QueryParam("created_after")
+	req.CreatedBefore = c.QueryParam("created_before")
+	req.UpdatedAfter = c.QueryParam("updated_after")
+	req.UpdatedBefore = c.QueryParam("updated_before")
+	req.Artist = c.QueryParam("artist")
+	req.Role = c.QueryParam("role")
+	req.Genre = c.QueryParam("genre")
+	req.Tag = c.QueryParam("tag")
+	req.Facets, _ = strconv.ParseBool(c.QueryParam("facets"))
 
 	result, err := ac.songService.GetAllSong(ctx, req)
-	if result == nil {
-		return c.JSON(http.StatusNotFound, fmt.Sprintf("no songs found"))
+	if err != nil {
+		return err
 	}
 
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, err.Error())
+	if req.Facets {
+		facets, err := ac.songService.GetSongFacets(ctx, req)
+		if err != nil {
+			return err
+		}
+
+		if result == nil {
+			result = make([]models.SongsResponse, 0)
+		}
+
+		return c.JSON(http.StatusOK, models.SongsFacetedResponse{Songs: result, Facets: facets})
+	}
+
+	if result == nil {
+		return errNoSongs
 	}
 
 	return c.JSON(http.StatusOK, result)
@@ -133,8 +147,10 @@ func (ac *ApiController) GetAllSong(c echo.Context) error {
 // @Param id path int true "Enter the ID of the saved song"
 // @Param verse query string true "Enter the verse number of the song"
 // @Success 200 {string} string
-// @Failure 400 {string} string
-// @Failure 500 {string} string
+// @Failure 400 {object} models.Problem
+// @Failure 500 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /song/get/{id} [get]
 func (ac *ApiController) GetLyricsSong(c echo.Context) error {
 	ctx := c.Request().Context()
@@ -148,7 +164,7 @@ func (ac *ApiController) GetLyricsSong(c echo.Context) error {
 	result, err := ac.songService.GetLyricsSong(ctx, id, verse)
 	if err != nil {
 		ac.logger.Debug().Msgf("error receiving song data: %v", err)
-		return c.JSON(http.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, result)
@@ -163,7 +179,9 @@ func (ac *ApiController) GetLyricsSong(c echo.Context) error {
 // @Param id path int true "Enter the song ID"
 // @Param input body models.UpdateRequest true "You need to specify the name of the band and the song in the request body"
 // @Success 200 {string} string
-// @Failure 400 {string} string
+// @Failure 400 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /song/update/{id} [put]
 func (ac *ApiController) UpdateSong(c echo.Context) error {
 	ctx := c.Request().Context()
@@ -175,55 +193,27 @@ func (ac *ApiController) UpdateSong(c echo.Context) error {
 	if err := c.Bind(&req); err != nil {
 		ac.logger.Warn().Msgf("bind: invalid request: %v", err)
 
-		return c.JSON(http.StatusBadRequest, fmt.Sprint("invalid request"))
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
 	}
 
 	id := c.Param("id")
 
 	songIdInt, err := strconv.Atoi(id)
 	if err != nil {
-		ac.logger.Debug().Msgf("updateSong: invalid id: %d", id)
+		ac.logger.Debug().Msgf("updateSong: invalid id: %s", id)
 
-		return c.JSON(http.StatusBadRequest, fmt.Sprint("invalid id"))
+		return errInvalidId
 	}
 
 	req.Id = songIdInt
 
-	err = ac.validator.Struct(&req)
-
-	if err != nil {
-		for _, err := range err.(validator.ValidationErrors) {
-			if err.StructField() == "Id" && err.Value() != "" {
-				return c.JSON(http.StatusBadRequest, fmt.Sprintf("incorrect id"))
-			}
-
-			if err.StructField() == "group" {
-				switch err.Tag() {
-				case "required":
-					return c.JSON(http.StatusBadRequest, fmt.Sprintf("Enter the name of the group"))
-				case "min":
-					return c.JSON(http.StatusBadRequest, fmt.Sprintf("The minimum length of the group name is 2 characters"))
-				case "max":
-					return c.JSON(http.StatusBadRequest, fmt.Sprintf("The maximum length of the group name is 20 characters"))
-				}
-			}
-
-			if err.StructField() == "song" {
-				switch err.Tag() {
-				case "required":
-					return c.JSON(http.StatusBadRequest, fmt.Sprintf("Enter the name of the song"))
-				case "min":
-					return c.JSON(http.StatusBadRequest, fmt.Sprintf("The minimum length of the song name is 2 characters"))
-				}
-			}
-
-			return c.JSON(http.StatusBadRequest, err.Error())
-		}
+	if err := ac.validator.Struct(&req); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
 	}
 
 	err = ac.songService.UpdateSong(ctx, req)
 	if err != nil {
-		return c.JSON(http.StatusNotFound, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, fmt.Sprint("successfully updated"))
@@ -237,7 +227,9 @@ func (ac *ApiController) UpdateSong(c echo.Context) error {
 // @Produce  json
 // @Param id path int true "Enter the ID of the saved song"
 // @Success 200 {string} string
-// @Failure 400,404 {string} string
+// @Failure 400,404 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /song/delete/{id} [delete]
 func (ac *ApiController) DeleteSong(c echo.Context) error {
 	ctx := c.Request().Context()
@@ -251,13 +243,55 @@ func (ac *ApiController) DeleteSong(c echo.Context) error {
 	if err != nil {
 		ac.logger.Debug().Msgf("deleteSong: invalid id: %s", id)
 
-		return c.JSON(http.StatusBadRequest, fmt.Sprint("invalid id"))
+		return errInvalidId
 	}
 
 	err = ac.songService.DeleteSong(ctx, songIdInt)
 	if err != nil {
-		return c.JSON(http.StatusNotFound, err.Error())
+		return err
 	}
 
 	return c.JSON(http.StatusOK, fmt.Sprintf("successfully deleted song: %d", songIdInt))
 }
+
+// @Summary Batch Songs
+// @Tags songs
+// @Description run a list of create, update and delete operations in one request with one result per operation. The batch is atomic unless best_effort is set, then the failed operations are rolled back alone
+// @Accept  json
+// @Produce  json
+// @Param input body models.BatchRequest true "The operations: create takes a models.CreateSong as the song, update the id and a models.UpdateRequest, delete the id"
+// @Success 200 {object} models.BatchResponse
+// @Failure 400 {object} models.BatchResponse "the atomic batch was not applied"
+// @Failure 500 {object} models.Problem
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /song/batch [post]
+// @Router /api/v2/songs/batch [post]
+func (ac *ApiController) Batch(c echo.Context) error {
+	ctx := c.Request().Context()
+	ctx = ac.logger.WithContext(ctx)
+
+	ac.logger.Debug().Msg("starting the handler 'Batch'")
+
+	var req models.BatchRequest
+	if err := c.Bind(&req); err != nil {
+		ac.logger.Debug().Msgf("bind: invalid request: %v", err)
+
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	if err := ac.validator.Struct(&req); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	result, err := ac.songService.Batch(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	if !result.BestEffort && result.Failed > 0 {
+		return c.JSON(http.StatusBadRequest, result)
+	}
+
+	return c.JSON(http.StatusOK, result)
+}
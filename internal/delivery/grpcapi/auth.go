@@ -0,0 +1,132 @@
+package grpcapi
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+	"github.com/Magic-Kot/effective-mobile/internal/services/auth"
+	libraryv1 "github.com/Magic-Kot/effective-mobile/pkg/api/library/v1"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// MetadataApiKey - the metadata key carrying the API key of the caller, like the X-API-Key header
+	MetadataApiKey = "x-api-key"
+	// metadataAuthorization - the metadata key carrying "Bearer TOKEN", like the Authorization header
+	metadataAuthorization = "authorization"
+	bearerScheme          = "Bearer "
+	// methodGRPC - the method of the writes made with gRPC in the attribution
+	methodGRPC = "GRPC"
+	// publicPrefix - the reflection service is open without an API key
+	publicPrefix = "/grpc.reflection."
+)
+
+// methodRoles - the role each method needs, a method missing here needs the admin role
+var methodRoles = map[string]string{
+	libraryv1.LibraryService_GetSong_FullMethodName:     models.RoleReader,
+	libraryv1.LibraryService_ListSongs_FullMethodName:   models.RoleReader,
+	libraryv1.LibraryService_GetVerse_FullMethodName:    models.RoleReader,
+	libraryv1.LibraryService_GetLyrics_FullMethodName:   models.RoleReader,
+	libraryv1.LibraryService_GetGroup_FullMethodName:    models.RoleReader,
+	libraryv1.LibraryService_ListGroups_FullMethodName:  models.RoleReader,
+	libraryv1.LibraryService_CreateSong_FullMethodName:  models.RoleEditor,
+	libraryv1.LibraryService_UpdateSong_FullMethodName:  models.RoleEditor,
+	libraryv1.LibraryService_DeleteSong_FullMethodName:  models.RoleEditor,
+	libraryv1.LibraryService_CreateGroup_FullMethodName: models.RoleEditor,
+	libraryv1.LibraryService_UpdateGroup_FullMethodName: models.RoleEditor,
+	libraryv1.LibraryService_DeleteGroup_FullMethodName: models.RoleEditor,
+}
+
+// AuthUnaryInterceptor - the Authenticate middleware of the HTTP server for the gRPC calls,
+// it goes after the UnaryInterceptor so its errors become statuses too
+func AuthUnaryInterceptor(authService *auth.AuthService, logger *zerolog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if strings.HasPrefix(info.FullMethod, publicPrefix) {
+			return handler(ctx, req)
+		}
+
+		ctx, role, err := authorize(ctx, authService, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := handler(ctx, req)
+		if err != nil || role == models.RoleReader {
+			return resp, err
+		}
+
+		principal, _ := auth.PrincipalFrom(ctx)
+
+		write := models.ApiKeyWrite{
+			KeyId:   principal.KeyId,
+			Subject: principal.Subject,
+			Method:  methodGRPC,
+			Route:   info.FullMethod,
+			Path:    info.FullMethod,
+			Status:  int(codes.OK),
+		}
+
+		// the write is done, so a failure to record it is only logged
+		if err := authService.RecordWrite(ctx, write); err != nil {
+			logger.Error().Msgf("attributing %s to %s: %v", write.Route, principal.Name, err)
+		}
+
+		return resp, nil
+	}
+}
+
+// AuthStreamInterceptor - the AuthUnaryInterceptor of the streaming calls, they only read
+func AuthStreamInterceptor(authService *auth.AuthService) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if strings.HasPrefix(info.FullMethod, publicPrefix) {
+			return handler(srv, stream)
+		}
+
+		ctx, _, err := authorize(stream.Context(), authService, info.FullMethod)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &loggedStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// authorize - authenticate the bearer token or the API key of the metadata and check the role of the method
+func authorize(ctx context.Context, authService *auth.AuthService, method string) (context.Context, string, error) {
+	principal, err := authenticate(ctx, authService)
+	if err != nil {
+		return ctx, "", err
+	}
+
+	ctx = auth.WithPrincipal(ctx, principal)
+
+	role, ok := methodRoles[method]
+	if !ok {
+		role = models.RoleAdmin
+	}
+
+	if err = auth.Authorize(ctx, role); err != nil {
+		return ctx, "", err
+	}
+
+	return ctx, role, nil
+}
+
+func authenticate(ctx context.Context, authService *auth.AuthService) (models.Principal, error) {
+	if values := metadata.ValueFromIncomingContext(ctx, metadataAuthorization); len(values) > 0 &&
+		len(values[0]) >= len(bearerScheme) && strings.EqualFold(values[0][:len(bearerScheme)], bearerScheme) {
+		return authService.AuthenticateToken(ctx, strings.TrimSpace(values[0][len(bearerScheme):]))
+	}
+
+	var key string
+	if values := metadata.ValueFromIncomingContext(ctx, MetadataApiKey); len(values) > 0 {
+		key = values[0]
+	}
+
+	return authService.Authenticate(ctx, key)
+}
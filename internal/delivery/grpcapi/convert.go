@@ -0,0 +1,71 @@
+package grpcapi
+
+import (
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+	libraryv1 "github.com/Magic-Kot/effective-mobile/pkg/api/library/v1"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func songToProto(song models.SongsResponse) *libraryv1.Song {
+	res := &libraryv1.Song{
+		Id:          int64(song.Id),
+		Group:       song.GroupSong,
+		Song:        song.Song,
+		ReleaseDate: song.ReleaseDate,
+		Text:        song.Text,
+		Link:        song.Link,
+		Artists:     artistsToProto(song.Artists),
+		Genres:      song.Genres,
+		Tags:        song.Tags,
+		CreatedAt:   timestamppb.New(song.CreatedAt),
+		UpdatedAt:   timestamppb.New(song.UpdatedAt),
+	}
+
+	if song.AlbumId != nil {
+		res.AlbumId = int64(*song.AlbumId)
+	}
+
+	if song.TrackNumber != nil {
+		res.TrackNumber = int64(*song.TrackNumber)
+	}
+
+	return res
+}
+
+func artistsToProto(artists []models.SongArtist) []*libraryv1.Artist {
+	res := make([]*libraryv1.Artist, 0, len(artists))
+
+	for _, artist := range artists {
+		res = append(res, &libraryv1.Artist{Group: artist.Group, Role: artist.Role})
+	}
+
+	return res
+}
+
+func artistsFromProto(artists []*libraryv1.Artist) []models.SongArtist {
+	if len(artists) == 0 {
+		return nil
+	}
+
+	res := make([]models.SongArtist, 0, len(artists))
+
+	for _, artist := range artists {
+		res = append(res, models.SongArtist{Group: artist.GetGroup(), Role: artist.GetRole()})
+	}
+
+	return res
+}
+
+func groupToProto(group models.GroupResponse) *libraryv1.Group {
+	return &libraryv1.Group{
+		Id:        int64(group.Id),
+		Name:      group.Name,
+		Songs:     int64(group.Songs),
+		Albums:    int64(group.Albums),
+		Genres:    group.Genres,
+		Tags:      group.Tags,
+		CreatedAt: timestamppb.New(group.CreatedAt),
+		UpdatedAt: timestamppb.New(group.UpdatedAt),
+	}
+}
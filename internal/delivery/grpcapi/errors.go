@@ -0,0 +1,8 @@
+package grpcapi
+
+import "github.com/Magic-Kot/effective-mobile/internal/apperror"
+
+var (
+	errInvalidRequest = apperror.Validation("invalid_request", "invalid request")
+	errInvalidId      = apperror.Validation("invalid_id", "invalid id")
+)
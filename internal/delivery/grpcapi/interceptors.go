@@ -0,0 +1,98 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Magic-Kot/effective-mobile/internal/apperror"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// kindCodes - the gRPC code of each kind of the application errors
+var kindCodes = map[apperror.Kind]codes.Code{
+	apperror.KindNotFound:     codes.NotFound,
+	apperror.KindConflict:     codes.AlreadyExists,
+	apperror.KindValidation:   codes.InvalidArgument,
+	apperror.KindUnauthorized: codes.Unauthenticated,
+	apperror.KindForbidden:    codes.PermissionDenied,
+	apperror.KindRateLimited:  codes.ResourceExhausted,
+	apperror.KindUpstream:     codes.Unavailable,
+	apperror.KindInternal:     codes.Internal,
+}
+
+// UnaryInterceptor - passes the logger to the handlers, turns their errors and panics into gRPC statuses
+func UnaryInterceptor(logger *zerolog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+			}
+
+			err = statusError(logger, info.FullMethod, err)
+		}()
+
+		return handler(logger.WithContext(ctx), req)
+	}
+}
+
+// StreamInterceptor - the UnaryInterceptor of the streaming calls
+func StreamInterceptor(logger *zerolog.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+			}
+
+			err = statusError(logger, info.FullMethod, err)
+		}()
+
+		return handler(srv, &loggedStream{ServerStream: stream, ctx: logger.WithContext(stream.Context())})
+	}
+}
+
+type loggedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *loggedStream) Context() context.Context {
+	return s.ctx
+}
+
+// statusError - the status with the code of the error kind and the stable error code before the message.
+// The message of an internal error is the message of its sentinel only, like in the problem details.
+func statusError(logger *zerolog.Logger, method string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+
+	appErr := apperror.From(err)
+	code := kindCodes[appErr.Kind]
+
+	message := err.Error()
+	if code == codes.Internal {
+		logger.Error().Msgf("%s: %v", method, err)
+
+		message = appErr.Message
+	} else {
+		logger.Debug().Msgf("%s: %v", method, err)
+	}
+
+	return status.Errorf(code, "%s: %s", appErr.Code, message)
+}
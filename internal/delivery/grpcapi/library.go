@@ -0,0 +1,340 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+	"github.com/Magic-Kot/effective-mobile/internal/services/group"
+	"github.com/Magic-Kot/effective-mobile/internal/services/song"
+	libraryv1 "github.com/Magic-Kot/effective-mobile/pkg/api/library/v1"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+)
+
+// pageSize - the number of songs or music groups read from the database for one page of a stream
+const pageSize = 100
+
+type LibraryServer struct {
+	libraryv1.UnimplementedLibraryServiceServer
+
+	songService  song.SongService
+	groupService group.GroupService
+	logger       *zerolog.Logger
+	validator    *validator.Validate
+}
+
+func NewLibraryServer(songService *song.SongService, groupService *group.GroupService, logger *zerolog.Logger, validator *validator.Validate) *LibraryServer {
+	return &LibraryServer{
+		songService:  *songService,
+		groupService: *groupService,
+		logger:       logger,
+		validator:    validator,
+	}
+}
+
+// Register - register the library service on the gRPC server
+func (ls *LibraryServer) Register(server *grpc.Server) {
+	libraryv1.RegisterLibraryServiceServer(server, ls)
+}
+
+func (ls *LibraryServer) CreateSong(ctx context.Context, req *libraryv1.CreateSongRequest) (*libraryv1.Song, error) {
+	ls.logger.Debug().Msg("starting the handler 'CreateSong'")
+
+	create := models.CreateSong{
+		Group:       req.GetGroup(),
+		Song:        req.GetSong(),
+		AlbumId:     int(req.GetAlbumId()),
+		TrackNumber: int(req.GetTrackNumber()),
+		Artists:     artistsFromProto(req.GetArtists()),
+	}
+
+	if err := ls.validator.Struct(&create); err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	id, err := ls.songService.AddSong(ctx, create)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := ls.songService.GetSong(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return songToProto(result), nil
+}
+
+func (ls *LibraryServer) GetSong(ctx context.Context, req *libraryv1.GetSongRequest) (*libraryv1.Song, error) {
+	ls.logger.Debug().Msg("starting the handler 'GetSong'")
+
+	if req.GetId() <= 0 {
+		return nil, errInvalidId
+	}
+
+	result, err := ls.songService.GetSong(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, err
+	}
+
+	return songToProto(result), nil
+}
+
+func (ls *LibraryServer) UpdateSong(ctx context.Context, req *libraryv1.UpdateSongRequest) (*libraryv1.Song, error) {
+	ls.logger.Debug().Msg("starting the handler 'UpdateSong'")
+
+	if req.GetId() <= 0 {
+		return nil, errInvalidId
+	}
+
+	update := models.UpdateRequest{
+		Id:          int(req.GetId()),
+		Song:        req.GetSong(),
+		ReleaseDate: req.GetReleaseDate(),
+		Text:        req.GetText(),
+		Link:        req.GetLink(),
+		Artists:     artistsFromProto(req.GetArtists()),
+	}
+
+	if err := ls.validator.Struct(&update); err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	if err := ls.songService.UpdateSong(ctx, update); err != nil {
+		return nil, err
+	}
+
+	result, err := ls.songService.GetSong(ctx, update.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return songToProto(result), nil
+}
+
+func (ls *LibraryServer) DeleteSong(ctx context.Context, req *libraryv1.DeleteSongRequest) (*libraryv1.DeleteSongResponse, error) {
+	ls.logger.Debug().Msg("starting the handler 'DeleteSong'")
+
+	if req.GetId() <= 0 {
+		return nil, errInvalidId
+	}
+
+	if err := ls.songService.DeleteSong(ctx, int(req.GetId())); err != nil {
+		return nil, err
+	}
+
+	return &libraryv1.DeleteSongResponse{}, nil
+}
+
+// ListSongs - stream the songs page by page, the next page starts after the last song sent
+func (ls *LibraryServer) ListSongs(req *libraryv1.ListSongsRequest, stream libraryv1.LibraryService_ListSongsServer) error {
+	ctx := stream.Context()
+
+	ls.logger.Debug().Msg("starting the handler 'ListSongs'")
+
+	if req.GetAfter() < 0 || req.GetLimit() < 0 {
+		return fmt.Errorf("%w: after and limit must be non-negative numbers", errInvalidRequest)
+	}
+
+	filters := models.RequestGetAll{
+		Artist: req.GetArtist(),
+		Role:   req.GetRole(),
+		Genre:  req.GetGenre(),
+		Tag:    req.GetTag(),
+	}
+
+	after := int(req.GetAfter())
+	left := int(req.GetLimit())
+
+	for {
+		size := pageSize
+		if left > 0 && left < size {
+			size = left
+		}
+
+		filters.Id = strconv.Itoa(after)
+		filters.Limit = strconv.Itoa(size)
+
+		songs, err := ls.songService.GetAllSong(ctx, filters)
+		if err != nil {
+			return err
+		}
+
+		for _, s := range songs {
+			if err = stream.Send(songToProto(s)); err != nil {
+				return err
+			}
+		}
+
+		if left > 0 {
+			if left -= len(songs); left == 0 {
+				return nil
+			}
+		}
+
+		if len(songs) < size {
+			return nil
+		}
+
+		after = songs[len(songs)-1].Id
+	}
+}
+
+func (ls *LibraryServer) GetVerse(ctx context.Context, req *libraryv1.GetVerseRequest) (*libraryv1.Verse, error) {
+	ls.logger.Debug().Msg("starting the handler 'GetVerse'")
+
+	if req.GetSongId() <= 0 {
+		return nil, errInvalidId
+	}
+
+	text, err := ls.songService.GetLyricsSong(ctx, strconv.FormatInt(req.GetSongId(), 10), strconv.FormatInt(req.GetVerse(), 10))
+	if err != nil {
+		return nil, err
+	}
+
+	return &libraryv1.Verse{SongId: req.GetSongId(), Verse: req.GetVerse(), Text: text}, nil
+}
+
+func (ls *LibraryServer) GetLyrics(ctx context.Context, req *libraryv1.GetLyricsRequest) (*libraryv1.Lyrics, error) {
+	ls.logger.Debug().Msg("starting the handler 'GetLyrics'")
+
+	if req.GetSongId() <= 0 {
+		return nil, errInvalidId
+	}
+
+	result, err := ls.songService.GetSong(ctx, int(req.GetSongId()))
+	if err != nil {
+		return nil, err
+	}
+
+	return &libraryv1.Lyrics{SongId: req.GetSongId(), Verses: song.SplitVerses(result.Text)}, nil
+}
+
+func (ls *LibraryServer) CreateGroup(ctx context.Context, req *libraryv1.CreateGroupRequest) (*libraryv1.Group, error) {
+	ls.logger.Debug().Msg("starting the handler 'CreateGroup'")
+
+	create := models.CreateGroup{Name: req.GetName()}
+
+	if err := ls.validator.Struct(&create); err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	id, err := ls.groupService.CreateGroup(ctx, create)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := ls.groupService.GetGroup(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return groupToProto(result), nil
+}
+
+func (ls *LibraryServer) GetGroup(ctx context.Context, req *libraryv1.GetGroupRequest) (*libraryv1.Group, error) {
+	ls.logger.Debug().Msg("starting the handler 'GetGroup'")
+
+	if req.GetId() <= 0 {
+		return nil, errInvalidId
+	}
+
+	result, err := ls.groupService.GetGroup(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, err
+	}
+
+	return groupToProto(result), nil
+}
+
+func (ls *LibraryServer) UpdateGroup(ctx context.Context, req *libraryv1.UpdateGroupRequest) (*libraryv1.Group, error) {
+	ls.logger.Debug().Msg("starting the handler 'UpdateGroup'")
+
+	if req.GetId() <= 0 {
+		return nil, errInvalidId
+	}
+
+	update := models.UpdateGroup{Id: int(req.GetId()), Name: req.GetName()}
+
+	if err := ls.validator.Struct(&update); err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	if err := ls.groupService.UpdateGroup(ctx, update); err != nil {
+		return nil, err
+	}
+
+	result, err := ls.groupService.GetGroup(ctx, update.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return groupToProto(result), nil
+}
+
+func (ls *LibraryServer) DeleteGroup(ctx context.Context, req *libraryv1.DeleteGroupRequest) (*libraryv1.DeleteGroupResponse, error) {
+	ls.logger.Debug().Msg("starting the handler 'DeleteGroup'")
+
+	if req.GetId() <= 0 {
+		return nil, errInvalidId
+	}
+
+	if err := ls.groupService.DeleteGroup(ctx, int(req.GetId())); err != nil {
+		return nil, err
+	}
+
+	return &libraryv1.DeleteGroupResponse{}, nil
+}
+
+// ListGroups - stream the music groups page by page, the next page starts after the last group sent
+func (ls *LibraryServer) ListGroups(req *libraryv1.ListGroupsRequest, stream libraryv1.LibraryService_ListGroupsServer) error {
+	ctx := stream.Context()
+
+	ls.logger.Debug().Msg("starting the handler 'ListGroups'")
+
+	if req.GetAfter() < 0 || req.GetLimit() < 0 {
+		return fmt.Errorf("%w: after and limit must be non-negative numbers", errInvalidRequest)
+	}
+
+	filters := models.RequestGetAllGroups{Name: req.GetName()}
+
+	after := int(req.GetAfter())
+	left := int(req.GetLimit())
+
+	for {
+		size := pageSize
+		if left > 0 && left < size {
+			size = left
+		}
+
+		filters.Id = strconv.Itoa(after)
+		filters.Limit = strconv.Itoa(size)
+
+		groups, err := ls.groupService.GetAllGroups(ctx, filters)
+		if err != nil {
+			return err
+		}
+
+		for _, g := range groups {
+			if err = stream.Send(groupToProto(g)); err != nil {
+				return err
+			}
+		}
+
+		if left > 0 {
+			if left -= len(groups); left == 0 {
+				return nil
+			}
+		}
+
+		if len(groups) < size {
+			return nil
+		}
+
+		after = groups[len(groups)-1].Id
+	}
+}
@@ -0,0 +1,161 @@
+package grpcapi
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"github.com/Magic-Kot/effective-mobile/internal/apperror"
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+	"github.com/Magic-Kot/effective-mobile/internal/services/auth"
+	"github.com/Magic-Kot/effective-mobile/pkg/ratelimit"
+
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+var errRateLimited = apperror.RateLimited("rate_limited", "too many requests, retry after the time of the retry-after metadata")
+
+// RateLimitUnaryInterceptor - the RateLimit middleware of the HTTP server for the gRPC calls, it goes after
+// the AuthUnaryInterceptor so the clients are known. The limits are sent in the header metadata.
+func RateLimitUnaryInterceptor(limiter *ratelimit.Limiter, logger *zerolog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := takeTokens(ctx, limiter, logger, info.FullMethod); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// RateLimitStreamInterceptor - the RateLimitUnaryInterceptor of the streaming calls, a call takes one token
+func RateLimitStreamInterceptor(limiter *ratelimit.Limiter, logger *zerolog.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := takeTokens(stream.Context(), limiter, logger, info.FullMethod); err != nil {
+			return err
+		}
+
+		return handler(srv, stream)
+	}
+}
+
+// RateLimitFailuresUnaryInterceptor - the RateLimitFailures middleware of the HTTP server for the gRPC calls, it goes
+// before the AuthUnaryInterceptor so the failed authentications are charged to the address of the peer
+func RateLimitFailuresUnaryInterceptor(limiter *ratelimit.Limiter, logger *zerolog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		address := "ip:" + peerIP(ctx)
+
+		if err := checkFailures(ctx, limiter, logger, address); err != nil {
+			return nil, err
+		}
+
+		resp, err := handler(ctx, req)
+		addFailure(ctx, limiter, logger, address, err)
+
+		return resp, err
+	}
+}
+
+// RateLimitFailuresStreamInterceptor - the RateLimitFailuresUnaryInterceptor of the streaming calls
+func RateLimitFailuresStreamInterceptor(limiter *ratelimit.Limiter, logger *zerolog.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := stream.Context()
+		address := "ip:" + peerIP(ctx)
+
+		if err := checkFailures(ctx, limiter, logger, address); err != nil {
+			return err
+		}
+
+		err := handler(srv, stream)
+		addFailure(ctx, limiter, logger, address, err)
+
+		return err
+	}
+}
+
+func checkFailures(ctx context.Context, limiter *ratelimit.Limiter, logger *zerolog.Logger, address string) error {
+	res, err := limiter.CheckFailures(ctx, address)
+	if err != nil {
+		logger.Error().Msgf("rate limiting the failures of %s: %v", address, err)
+		return nil
+	}
+
+	if res.Allowed {
+		return nil
+	}
+
+	setRateLimit(ctx, logger, res)
+
+	return errRateLimited
+}
+
+// addFailure - charge the address if the error is a failed authentication
+func addFailure(ctx context.Context, limiter *ratelimit.Limiter, logger *zerolog.Logger, address string, err error) {
+	if err == nil || apperror.From(err).Kind != apperror.KindUnauthorized {
+		return
+	}
+
+	if _, err = limiter.AddFailure(ctx, address); err != nil {
+		logger.Error().Msgf("rate limiting the failures of %s: %v", address, err)
+	}
+}
+
+func takeTokens(ctx context.Context, limiter *ratelimit.Limiter, logger *zerolog.Logger, method string) error {
+	client := "ip:" + peerIP(ctx)
+	if principal, ok := auth.PrincipalFrom(ctx); ok {
+		client = auth.UserId(principal)
+	}
+
+	role, ok := methodRoles[method]
+	write := !ok || role != models.RoleReader
+
+	if strings.HasPrefix(method, publicPrefix) {
+		write = false
+	}
+
+	res, err := limiter.Take(ctx, client, write, 1)
+	if err != nil {
+		logger.Error().Msgf("rate limiting %s: %v", method, err)
+		return nil
+	}
+
+	setRateLimit(ctx, logger, res)
+
+	if !res.Allowed {
+		return errRateLimited
+	}
+
+	return nil
+}
+
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+
+	return host
+}
+
+// setRateLimit - send the limit in the header metadata
+func setRateLimit(ctx context.Context, logger *zerolog.Logger, res ratelimit.Result) {
+	md := make(metadata.MD)
+	for name, values := range res.Header() {
+		md.Set(name, values...)
+	}
+
+	if len(md) == 0 {
+		return
+	}
+
+	if err := grpc.SetHeader(ctx, md); err != nil {
+		logger.Debug().Msgf("sending the rate limit: %v", err)
+	}
+}
@@ -0,0 +1,20 @@
+package httpecho
+
+import (
+	"github.com/Magic-Kot/effective-mobile/internal/controllers"
+	"github.com/Magic-Kot/effective-mobile/internal/controllers/apiv2"
+
+	"github.com/labstack/echo/v4"
+)
+
+func SetAlbumRoutes(e *echo.Echo, albumController *controllers.AlbumController) {
+	album := e.Group("/album", Deprecated(v1Deprecation, apiv2.BasePath+"/albums"))
+	{
+		album.POST("/create", albumController.CreateAlbum, editor)
+		album.GET("/all", albumController.GetAllAlbums, reader)
+		album.GET("/get/:id", albumController.GetAlbum, reader)
+		album.PUT("/update/:id", albumController.UpdateAlbum, editor)
+		album.DELETE("/delete/:id", albumController.DeleteAlbum, editor)
+		album.PUT("/tracks/:id", albumController.SetAlbumTracks, editor)
+	}
+}
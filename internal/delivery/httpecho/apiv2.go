@@ -0,0 +1,104 @@
+package httpecho
+
+import (
+	"github.com/Magic-Kot/effective-mobile/internal/controllers"
+	"github.com/Magic-Kot/effective-mobile/internal/controllers/apiv2"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ApiV2Controllers - the handlers of the /api/v2 routes. The song batch, import and export and the
+// playlist export already answer with resources, so v2 serves them with the v1 handlers.
+type ApiV2Controllers struct {
+	Songs          *apiv2.SongController
+	Groups         *apiv2.GroupController
+	Albums         *apiv2.AlbumController
+	Playlists      *apiv2.PlaylistController
+	Classification *apiv2.ClassificationController
+	ApiKeys        *apiv2.ApiKeyController
+	Me             *apiv2.MeController
+	SongsV1        *controllers.ApiController
+	BulkV1         *controllers.BulkController
+	PlaylistsV1    *controllers.PlaylistController
+}
+
+func SetApiV2Routes(e *echo.Echo, c ApiV2Controllers) {
+	api := e.Group(apiv2.BasePath)
+
+	songs := api.Group("/songs")
+	{
+		songs.GET("", c.Songs.GetAllSongs, reader)
+		songs.POST("", c.Songs.CreateSong, editor)
+		songs.GET("/facets", c.Songs.GetSongFacets, reader)
+		songs.GET("/search", c.Songs.SearchSongs, reader)
+		songs.POST("/batch", c.SongsV1.Batch, editor)
+		songs.POST("/import", c.BulkV1.ImportSongs, editor)
+		songs.GET("/export", c.BulkV1.ExportSongs, reader)
+		songs.GET("/:id", c.Songs.GetSong, reader)
+		songs.PUT("/:id", c.Songs.UpdateSong, editor)
+		songs.DELETE("/:id", c.Songs.DeleteSong, editor)
+		songs.GET("/:id/verses/:verse", c.Songs.GetVerse, reader)
+		songs.PUT("/:id/:kinds/:name", c.Classification.AssignSongLabel, editor)
+		songs.DELETE("/:id/:kinds/:name", c.Classification.RemoveSongLabel, editor)
+	}
+
+	groups := api.Group("/groups")
+	{
+		groups.GET("", c.Groups.GetAllGroups, reader)
+		groups.GET("/:id", c.Groups.GetGroup, reader)
+		groups.GET("/:id/songs", c.Groups.GetGroupSongs, reader)
+		groups.GET("/:id/albums", c.Groups.GetGroupAlbums, reader)
+		groups.PUT("/:id/:kinds/:name", c.Classification.AssignGroupLabel, editor)
+		groups.DELETE("/:id/:kinds/:name", c.Classification.RemoveGroupLabel, editor)
+	}
+
+	albums := api.Group("/albums")
+	{
+		albums.GET("", c.Albums.GetAllAlbums, reader)
+		albums.POST("", c.Albums.CreateAlbum, editor)
+		albums.GET("/:id", c.Albums.GetAlbum, reader)
+		albums.PUT("/:id", c.Albums.UpdateAlbum, editor)
+		albums.DELETE("/:id", c.Albums.DeleteAlbum, editor)
+		albums.PUT("/:id/tracks", c.Albums.SetAlbumTracks, editor)
+	}
+
+	playlists := api.Group("/playlists")
+	{
+		playlists.GET("", c.Playlists.GetAllPlaylists, reader)
+		playlists.POST("", c.Playlists.CreatePlaylist, editor)
+		playlists.POST("/import", c.Playlists.ImportPlaylist, editor)
+		playlists.GET("/:id", c.Playlists.GetPlaylist, reader)
+		playlists.PUT("/:id", c.Playlists.UpdatePlaylist, editor)
+		playlists.DELETE("/:id", c.Playlists.DeletePlaylist, editor)
+		playlists.POST("/:id/entries", c.Playlists.AddEntries, editor)
+		playlists.PATCH("/:id/entries/:position", c.Playlists.MoveEntry, editor)
+		playlists.DELETE("/:id/entries/:position", c.Playlists.RemoveEntry, editor)
+		playlists.POST("/:id/copies", c.Playlists.DuplicatePlaylist, editor)
+		playlists.GET("/:id/export", c.PlaylistsV1.ExportPlaylist, reader)
+	}
+
+	api.GET("/genres", c.Classification.GetAllGenres, reader)
+	api.GET("/tags", c.Classification.GetAllTags, reader)
+
+	// the favorites, ratings and plays are the user's own, so they are not writes to the library
+	me := api.Group("/me", reader)
+	{
+		me.GET("/favorites", c.Me.GetFavorites)
+		me.PUT("/favorites/:id", c.Me.AddFavorite)
+		me.DELETE("/favorites/:id", c.Me.RemoveFavorite)
+		me.PUT("/ratings/:id", c.Me.RateSong)
+		me.DELETE("/ratings/:id", c.Me.DeleteRating)
+		me.GET("/plays", c.Me.GetPlays)
+		me.POST("/plays", c.Me.RecordPlay)
+	}
+
+	keys := api.Group("/admin/keys", admin)
+	{
+		keys.GET("", c.ApiKeys.GetAllApiKeys)
+		keys.POST("", c.ApiKeys.IssueApiKey)
+		keys.DELETE("/:id", c.ApiKeys.RevokeApiKey)
+		keys.GET("/:id/writes", c.ApiKeys.GetApiKeyWrites)
+	}
+
+	api.GET("/admin/writes", c.ApiKeys.GetSubjectWrites, admin)
+}
@@ -0,0 +1,123 @@
+package httpecho
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+	"github.com/Magic-Kot/effective-mobile/internal/services/auth"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// HeaderApiKey - the header carrying the API key of the caller
+	HeaderApiKey = "X-API-Key"
+	// bearerScheme - the scheme of the Authorization header carrying a token of the SSO
+	bearerScheme = "Bearer "
+	// contextRole - the role the route requires, set by Require
+	contextRole = "required_role"
+)
+
+// publicPrefixes - the routes open without an API key
+var publicPrefixes = []string{"/swagger/"}
+
+// The roles needed by the routes, see Require
+var (
+	reader = Require(models.RoleReader)
+	editor = Require(models.RoleEditor)
+	admin  = Require(models.RoleAdmin)
+)
+
+// Authenticate - reject the requests without a valid API key or bearer token, except the public routes,
+// and attribute every successful write to the key or the subject of the token it was made with. A write is a request changing data on a
+// route needing more than the reader role, so the read-only POST /graphql is not one.
+func Authenticate(authService *auth.AuthService, logger *zerolog.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if isPublic(c.Path()) {
+				return next(c)
+			}
+
+			req := c.Request()
+			ctx := logger.WithContext(req.Context())
+
+			principal, err := authenticate(ctx, authService, req.Header)
+			if err != nil {
+				return err
+			}
+
+			c.SetRequest(req.WithContext(auth.WithPrincipal(req.Context(), principal)))
+
+			if err = next(c); err != nil || !isWrite(c) {
+				return err
+			}
+
+			write := models.ApiKeyWrite{
+				KeyId:   principal.KeyId,
+				Subject: principal.Subject,
+				Method:  req.Method,
+				Route:   c.Path(),
+				Path:    req.URL.Path,
+				Status:  c.Response().Status,
+			}
+
+			// the write is done, so a failure to record it is only logged
+			if err = authService.RecordWrite(ctx, write); err != nil {
+				logger.Error().Msgf("attributing %s %s to %s: %v", write.Method, write.Path, principal.Name, err)
+			}
+
+			return nil
+		}
+	}
+}
+
+// Require - reject the requests of the callers whose role is lower than the role
+func Require(role string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if err := auth.Authorize(c.Request().Context(), role); err != nil {
+				return err
+			}
+
+			c.Set(contextRole, role)
+
+			return next(c)
+		}
+	}
+}
+
+// authenticate - the caller of the bearer token of the Authorization header, or else of the API key
+func authenticate(ctx context.Context, authService *auth.AuthService, header http.Header) (models.Principal, error) {
+	if authorization := header.Get(echo.HeaderAuthorization); len(authorization) >= len(bearerScheme) &&
+		strings.EqualFold(authorization[:len(bearerScheme)], bearerScheme) {
+		return authService.AuthenticateToken(ctx, strings.TrimSpace(authorization[len(bearerScheme):]))
+	}
+
+	return authService.Authenticate(ctx, header.Get(HeaderApiKey))
+}
+
+func isPublic(path string) bool {
+	for _, prefix := range publicPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isWrite(c echo.Context) bool {
+	if role, _ := c.Get(contextRole).(string); role == models.RoleReader {
+		return false
+	}
+
+	switch c.Request().Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+
+	return true
+}
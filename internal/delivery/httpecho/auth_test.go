@@ -0,0 +1,237 @@
+package httpecho
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Magic-Kot/effective-mobile/internal/apperror"
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+	"github.com/Magic-Kot/effective-mobile/internal/services/auth"
+	"github.com/Magic-Kot/effective-mobile/internal/validation"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+)
+
+// testApiKeys - the keys and the writes of the tests in memory
+type testApiKeys struct {
+	mu     sync.Mutex
+	keys   map[string]models.ApiKeyResponse
+	writes []models.ApiKeyWrite
+}
+
+func (r *testApiKeys) CreateApiKey(_ context.Context, req models.CreateApiKey, prefix string, hash string) (models.ApiKeyResponse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	res := models.ApiKeyResponse{Id: len(r.keys) + 1, Name: req.Name, Role: req.Role, Prefix: prefix}
+	r.keys[hash] = res
+
+	return res, nil
+}
+
+func (r *testApiKeys) GetApiKeyByHash(_ context.Context, hash string) (models.ApiKeyResponse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	res, ok := r.keys[hash]
+	if !ok {
+		return res, apperror.NotFound("api_key_not_found", "API key not found")
+	}
+
+	return res, nil
+}
+
+func (r *testApiKeys) GetAllApiKeys(context.Context) ([]models.ApiKeyResponse, error) {
+	return nil, nil
+}
+
+func (r *testApiKeys) RevokeApiKey(context.Context, int) error {
+	return nil
+}
+
+func (r *testApiKeys) AddWrite(_ context.Context, write models.ApiKeyWrite) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.writes = append(r.writes, write)
+
+	return nil
+}
+
+func (r *testApiKeys) GetApiKeyWrites(context.Context, models.RequestGetApiKeyWrites) ([]models.ApiKeyWrite, error) {
+	return nil, nil
+}
+
+func TestAuthenticate(t *testing.T) {
+	logger := zerolog.Nop()
+	ctx := logger.WithContext(context.Background())
+
+	translator, err := validation.NewTranslator(validation.New())
+	if err != nil {
+		t.Fatalf("NewTranslator: %v", err)
+	}
+
+	tokens := auth.NewTokenVerifier(auth.TokenDeps{StaticKey: []byte("the static key of the tests")})
+	repository := &testApiKeys{keys: make(map[string]models.ApiKeyResponse)}
+	authService := auth.NewAuthService(repository, tokens)
+
+	keys := make(map[string]string)
+
+	for _, role := range []string{models.RoleReader, models.RoleEditor} {
+		res, err := authService.IssueApiKey(ctx, models.CreateApiKey{Name: role, Role: role})
+		if err != nil {
+			t.Fatalf("IssueApiKey: %v", err)
+		}
+
+		keys[role] = res.Key
+	}
+
+	token, err := tokens.IssueToken("sso-user", []string{models.RoleEditor}, time.Minute)
+	if err != nil {
+		t.Fatalf("IssueToken: %v", err)
+	}
+
+	e := echo.New()
+	e.HTTPErrorHandler = ProblemErrorHandler(&logger, translator)
+	e.Use(Authenticate(authService, &logger))
+
+	ok := func(c echo.Context) error { return c.NoContent(http.StatusOK) }
+
+	e.GET("/songs", ok, reader)
+	e.POST("/songs", func(c echo.Context) error { return c.NoContent(http.StatusCreated) }, editor)
+	e.PUT("/songs/:id", func(c echo.Context) error { return apperror.NotFound("song_not_found", "song not found") }, editor)
+	e.POST("/graphql", ok, reader)
+	e.GET("/swagger/*", ok)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		header     http.Header
+		wantStatus int
+		wantWrite  *models.ApiKeyWrite
+	}{
+		{
+			name:       "no credentials",
+			method:     http.MethodGet,
+			path:       "/songs",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "unknown key",
+			method:     http.MethodGet,
+			path:       "/songs",
+			header:     http.Header{HeaderApiKey: {"sl_unknown"}},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "invalid token",
+			method:     http.MethodPost,
+			path:       "/songs",
+			header:     http.Header{echo.HeaderAuthorization: {"Bearer " + token + "x"}},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "public route",
+			method:     http.MethodGet,
+			path:       "/swagger/index.html",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "reader reads",
+			method:     http.MethodGet,
+			path:       "/songs",
+			header:     http.Header{HeaderApiKey: {keys[models.RoleReader]}},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "reader on an editor route",
+			method:     http.MethodPost,
+			path:       "/songs",
+			header:     http.Header{HeaderApiKey: {keys[models.RoleReader]}},
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "read-only POST",
+			method:     http.MethodPost,
+			path:       "/graphql",
+			header:     http.Header{HeaderApiKey: {keys[models.RoleReader]}},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "editor writes",
+			method:     http.MethodPost,
+			path:       "/songs",
+			header:     http.Header{HeaderApiKey: {keys[models.RoleEditor]}},
+			wantStatus: http.StatusCreated,
+			wantWrite: &models.ApiKeyWrite{
+				KeyId:  2,
+				Method: http.MethodPost,
+				Route:  "/songs",
+				Path:   "/songs",
+				Status: http.StatusCreated,
+			},
+		},
+		{
+			name:       "failed write",
+			method:     http.MethodPut,
+			path:       "/songs/7",
+			header:     http.Header{HeaderApiKey: {keys[models.RoleEditor]}},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "token writes",
+			method:     http.MethodPost,
+			path:       "/songs",
+			header:     http.Header{echo.HeaderAuthorization: {"bearer " + token}},
+			wantStatus: http.StatusCreated,
+			wantWrite: &models.ApiKeyWrite{
+				Subject: "sso-user",
+				Method:  http.MethodPost,
+				Route:   "/songs",
+				Path:    "/songs",
+				Status:  http.StatusCreated,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repository.mu.Lock()
+			repository.writes = nil
+			repository.mu.Unlock()
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			for name, values := range tt.header {
+				for _, value := range values {
+					req.Header.Add(name, value)
+				}
+			}
+
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status: got %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+
+			repository.mu.Lock()
+			writes := repository.writes
+			repository.mu.Unlock()
+
+			switch {
+			case tt.wantWrite == nil && len(writes) > 0:
+				t.Errorf("recorded the writes %+v, want none", writes)
+			case tt.wantWrite != nil && len(writes) != 1:
+				t.Errorf("recorded the writes %+v, want %+v", writes, *tt.wantWrite)
+			case tt.wantWrite != nil && writes[0] != *tt.wantWrite:
+				t.Errorf("recorded %+v, want %+v", writes[0], *tt.wantWrite)
+			}
+		})
+	}
+}
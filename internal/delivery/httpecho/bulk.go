@@ -0,0 +1,16 @@
+package httpecho
+
+import (
+	"github.com/Magic-Kot/effective-mobile/internal/controllers"
+	"github.com/Magic-Kot/effective-mobile/internal/controllers/apiv2"
+
+	"github.com/labstack/echo/v4"
+)
+
+func SetBulkRoutes(e *echo.Echo, bulkController *controllers.BulkController) {
+	song := e.Group("/song", Deprecated(v1Deprecation, apiv2.BasePath+"/songs"))
+	{
+		song.POST("/import", bulkController.ImportSongs, editor)
+		song.GET("/export", bulkController.ExportSongs, reader)
+	}
+}
@@ -0,0 +1,17 @@
+package httpecho
+
+import (
+	"github.com/Magic-Kot/effective-mobile/internal/controllers"
+	"github.com/Magic-Kot/effective-mobile/internal/controllers/apiv2"
+
+	"github.com/labstack/echo/v4"
+)
+
+func SetClassificationRoutes(e *echo.Echo, classificationController *controllers.ClassificationController) {
+	classify := e.Group("/classify", Deprecated(v1Deprecation, apiv2.BasePath+"/genres"))
+	{
+		classify.GET("/:kind/all", classificationController.GetAllLabels, reader)
+		classify.POST("/:kind/:target/:id", classificationController.AssignLabel, editor)
+		classify.DELETE("/:kind/:target/:id", classificationController.RemoveLabel, editor)
+	}
+}
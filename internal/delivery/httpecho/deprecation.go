@@ -0,0 +1,27 @@
+package httpecho
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// v1Deprecation - the date the verb-style v1 routes were superseded by /api/v2
+var v1Deprecation = time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)
+
+// Deprecated - mark the responses of the routes as deprecated (RFC 9745) with a link to the route replacing them
+func Deprecated(since time.Time, successor string) echo.MiddlewareFunc {
+	deprecation := fmt.Sprintf("@%d", since.Unix())
+	link := fmt.Sprintf(`<%s>; rel="successor-version"`, successor)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			header := c.Response().Header()
+			header.Set("Deprecation", deprecation)
+			header.Add("Link", link)
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package httpecho
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+func SetGraphQLRoutes(e *echo.Echo, graphqlHandler http.Handler) {
+	e.POST("/graphql", echo.WrapHandler(graphqlHandler), reader)
+}
@@ -0,0 +1,44 @@
+package httpecho
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Magic-Kot/effective-mobile/internal/metrics"
+
+	"github.com/labstack/echo/v4"
+)
+
+// unmatchedRoute - the route label of the requests matching no route, so the paths do not become labels
+const unmatchedRoute = "unmatched"
+
+// Metrics - count the requests and their time by route and status. It goes before Authenticate, so the requests
+// rejected by Authenticate and RateLimit are counted too. The error is answered here and still returned, so Tracing
+// records it.
+func Metrics(m *metrics.Metrics) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+
+			// the error is answered here, so the status of the response is known
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+
+			route := c.Path()
+			if route == "" || c.Response().Status == http.StatusNotFound && route == "/*" {
+				route = unmatchedRoute
+			}
+
+			m.ObserveHTTP(c.Request().Method, route, c.Response().Status, time.Since(start))
+
+			return err
+		}
+	}
+}
+
+// SetMetricsRoutes - the routes of the admin server
+func SetMetricsRoutes(e *echo.Echo, m *metrics.Metrics) {
+	e.GET("/metrics", echo.WrapHandler(m.Handler()))
+}
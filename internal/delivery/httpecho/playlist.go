@@ -0,0 +1,25 @@
+package httpecho
+
+import (
+	"github.com/Magic-Kot/effective-mobile/internal/controllers"
+	"github.com/Magic-Kot/effective-mobile/internal/controllers/apiv2"
+
+	"github.com/labstack/echo/v4"
+)
+
+func SetPlaylistRoutes(e *echo.Echo, playlistController *controllers.PlaylistController) {
+	playlist := e.Group("/playlist", Deprecated(v1Deprecation, apiv2.BasePath+"/playlists"))
+	{
+		playlist.POST("/create", playlistController.CreatePlaylist, editor)
+		playlist.GET("/all", playlistController.GetAllPlaylists, reader)
+		playlist.GET("/get/:id", playlistController.GetPlaylist, reader)
+		playlist.PUT("/update/:id", playlistController.UpdatePlaylist, editor)
+		playlist.DELETE("/delete/:id", playlistController.DeletePlaylist, editor)
+		playlist.POST("/entries/:id", playlistController.AddEntries, editor)
+		playlist.DELETE("/entries/:id", playlistController.RemoveEntry, editor)
+		playlist.PUT("/move/:id", playlistController.MoveEntry, editor)
+		playlist.POST("/duplicate/:id", playlistController.DuplicatePlaylist, editor)
+		playlist.GET("/export/:id", playlistController.ExportPlaylist, reader)
+		playlist.POST("/import", playlistController.ImportPlaylist, editor)
+	}
+}
@@ -0,0 +1,150 @@
+package httpecho
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Magic-Kot/effective-mobile/internal/apperror"
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+	"github.com/Magic-Kot/effective-mobile/internal/validation"
+
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// MIMEApplicationProblemJSON - the media type of the problem details
+	MIMEApplicationProblemJSON = "application/problem+json"
+	headerAcceptLanguage       = "Accept-Language"
+	headerContentLanguage      = "Content-Language"
+)
+
+// kindStatuses - the HTTP status of each kind of the application errors
+var kindStatuses = map[apperror.Kind]int{
+	apperror.KindNotFound:     http.StatusNotFound,
+	apperror.KindConflict:     http.StatusConflict,
+	apperror.KindValidation:   http.StatusBadRequest,
+	apperror.KindUnauthorized: http.StatusUnauthorized,
+	apperror.KindForbidden:    http.StatusForbidden,
+	apperror.KindRateLimited:  http.StatusTooManyRequests,
+	apperror.KindUpstream:     http.StatusBadGateway,
+	apperror.KindInternal:     http.StatusInternalServerError,
+}
+
+// ProblemErrorHandler - turns the errors returned by the handlers into problem details responses,
+// the validation messages are in the language of the Accept-Language header
+func ProblemErrorHandler(logger *zerolog.Logger, translator *validation.Translator) echo.HTTPErrorHandler {
+	return func(err error, c echo.Context) {
+		if c.Response().Committed {
+			logger.Error().Msgf("%s %s: error after the response was sent: %v", c.Request().Method, c.Request().URL.Path, err)
+			return
+		}
+
+		trans := translator.Find(c.Request().Header.Get(headerAcceptLanguage))
+
+		problem := NewProblem(err, trans)
+		problem.Instance = c.Request().URL.Path
+
+		if problem.Status >= http.StatusInternalServerError {
+			logger.Error().Msgf("%s %s: %v", c.Request().Method, c.Request().URL.Path, err)
+		} else {
+			logger.Debug().Msgf("%s %s: %v", c.Request().Method, c.Request().URL.Path, err)
+		}
+
+		header := c.Response().Header()
+		header.Del(echo.HeaderContentDisposition)
+		header.Set(echo.HeaderContentType, MIMEApplicationProblemJSON)
+
+		if problem.Errors != nil {
+			header.Set(headerContentLanguage, strings.ReplaceAll(trans.Locale(), "_", "-"))
+		}
+
+		if c.Request().Method == http.MethodHead {
+			err = c.NoContent(problem.Status)
+		} else {
+			err = c.JSON(problem.Status, problem)
+		}
+
+		if err != nil {
+			logger.Error().Msgf("writing the problem details: %v", err)
+		}
+	}
+}
+
+// NewProblem - the problem details of the error. The detail of an internal error is the message of
+// its sentinel only, so the causes coming from the database are never shown.
+func NewProblem(err error, trans ut.Translator) models.Problem {
+	var httpErr *echo.HTTPError
+	if !errors.As(err, new(*apperror.Error)) && errors.As(err, &httpErr) {
+		return models.Problem{
+			Type:   "about:blank",
+			Title:  http.StatusText(httpErr.Code),
+			Status: httpErr.Code,
+			Detail: fmt.Sprint(httpErr.Message),
+			Code:   statusCode(httpErr.Code),
+		}
+	}
+
+	appErr := apperror.From(err)
+
+	status, ok := kindStatuses[appErr.Kind]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+
+	fields := problemFields(err, trans)
+
+	// the fields describe the validation errors better than their joined text
+	detail := err.Error()
+	if appErr.Kind == apperror.KindInternal || fields != nil {
+		detail = appErr.Message
+	}
+
+	return models.Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   appErr.Code,
+		Errors: fields,
+	}
+}
+
+// problemFields - the fields breaking the validation rules, if the error comes from the validator
+func problemFields(err error, trans ut.Translator) []models.ProblemField {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return nil
+	}
+
+	fields := make([]models.ProblemField, 0, len(validationErrs))
+
+	for _, fieldErr := range validationErrs {
+		fields = append(fields, models.ProblemField{
+			Field:   fieldPath(fieldErr.Namespace()),
+			Rule:    fieldErr.Tag(),
+			Param:   fieldErr.Param(),
+			Message: fieldErr.Translate(trans),
+		})
+	}
+
+	return fields
+}
+
+// fieldPath - the namespace of the field without the name of the request struct: artists[0].role
+func fieldPath(namespace string) string {
+	if i := strings.IndexByte(namespace, '.'); i >= 0 {
+		return namespace[i+1:]
+	}
+
+	return namespace
+}
+
+// statusCode - the stable code of the errors raised by echo itself: not_found, method_not_allowed
+func statusCode(status int) string {
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+}
@@ -0,0 +1,115 @@
+package httpecho
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Magic-Kot/effective-mobile/internal/apperror"
+	"github.com/Magic-Kot/effective-mobile/internal/services/auth"
+	"github.com/Magic-Kot/effective-mobile/pkg/ratelimit"
+
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog"
+)
+
+// pageCost - the items of a listing costing one token, a larger limit costs one more token for each of them
+const pageCost = 100
+
+var (
+	errRateLimited = apperror.RateLimited("rate_limited", "too many requests, retry after the time of the Retry-After header")
+	errTooCostly   = apperror.Validation("request_too_costly", "the request costs more than the whole rate limit, ask for a smaller limit")
+)
+
+// readOnlyPosts - the POST routes only reading the library, they take from the read bucket
+var readOnlyPosts = map[string]bool{"/graphql": true}
+
+// RateLimit - take the tokens of every request from the read or the write bucket of its client: the API key or
+// the subject of the token, or the IP address of a request without them. It goes after Authenticate, so the
+// clients are known. The requests are let through when the store fails.
+func RateLimit(limiter *ratelimit.Limiter, logger *zerolog.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx := c.Request().Context()
+
+			client := "ip:" + c.RealIP()
+			if principal, ok := auth.PrincipalFrom(ctx); ok {
+				client = auth.UserId(principal)
+			}
+
+			res, err := limiter.Take(ctx, client, isChange(c), requestCost(c))
+			if errors.Is(err, ratelimit.ErrTooCostly) {
+				return fmt.Errorf("%w: %w", errTooCostly, err)
+			} else if err != nil {
+				logger.Error().Msgf("rate limiting %s %s: %v", c.Request().Method, c.Request().URL.Path, err)
+				return next(c)
+			}
+
+			setRateLimit(c, res)
+
+			if !res.Allowed {
+				return errRateLimited
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// RateLimitFailures - charge the IP address of every request failing to authenticate, and reject the requests of
+// an address whose bucket is empty before their credentials are checked. It goes before Authenticate, so the keys
+// cannot be guessed without limit, each guess costing a lookup of the key or of the key set.
+func RateLimitFailures(limiter *ratelimit.Limiter, logger *zerolog.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx := c.Request().Context()
+			address := "ip:" + c.RealIP()
+
+			res, err := limiter.CheckFailures(ctx, address)
+			if err != nil {
+				logger.Error().Msgf("rate limiting the failures of %s: %v", address, err)
+			} else if !res.Allowed {
+				setRateLimit(c, res)
+				return errRateLimited
+			}
+
+			err = next(c)
+			if err != nil && apperror.From(err).Kind == apperror.KindUnauthorized {
+				if _, errFailure := limiter.AddFailure(ctx, address); errFailure != nil {
+					logger.Error().Msgf("rate limiting the failures of %s: %v", address, errFailure)
+				}
+			}
+
+			return err
+		}
+	}
+}
+
+func setRateLimit(c echo.Context, res ratelimit.Result) {
+	header := c.Response().Header()
+	for name, values := range res.Header() {
+		header[name] = values
+	}
+}
+
+// isChange - the request changes data, unlike isWrite it does not need the role of the route
+func isChange(c echo.Context) bool {
+	switch c.Request().Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+
+	return !readOnlyPosts[c.Path()]
+}
+
+// requestCost - the tokens of the request, a token for every pageCost items of the limit query parameter,
+// so a huge page of the v1 routes costs as much as the pages it replaces
+func requestCost(c echo.Context) int {
+	limit, err := strconv.Atoi(c.QueryParam("limit"))
+	if err != nil || limit <= pageCost {
+		return 1
+	}
+
+	return (limit + pageCost - 1) / pageCost
+}
@@ -3,6 +3,7 @@ package httpecho
 import (
 	_ "github.com/Magic-Kot/effective-mobile/docs"
 	"github.com/Magic-Kot/effective-mobile/internal/controllers"
+	"github.com/Magic-Kot/effective-mobile/internal/controllers/apiv2"
 
 	"github.com/labstack/echo/v4"
 	echoSwagger "github.com/swaggo/echo-swagger"
@@ -11,12 +12,13 @@ import (
 func SetSongRoutes(e *echo.Echo, apiController *controllers.ApiController) {
 	e.GET("/swagger/*", echoSwagger.WrapHandler)
 
-	song := e.Group("/song")
+	song := e.Group("/song", Deprecated(v1Deprecation, apiv2.BasePath+"/songs"))
 	{
-		song.POST("/create", apiController.AddSong)
-		song.GET("/all", apiController.GetAllSong)
-		song.GET("/get/:id", apiController.GetLyricsSong)
-		song.PUT("/update/:id", apiController.UpdateSong)
-		song.DELETE("/delete/:id", apiController.DeleteSong)
+		song.POST("/create", apiController.AddSong, editor)
+		song.GET("/all", apiController.GetAllSong, reader)
+		song.GET("/get/:id", apiController.GetLyricsSong, reader)
+		song.PUT("/update/:id", apiController.UpdateSong, editor)
+		song.DELETE("/delete/:id", apiController.DeleteSong, editor)
+		song.POST("/batch", apiController.Batch, editor)
 	}
 }
@@ -0,0 +1,68 @@
+package graph
+
+import (
+	"context"
+
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+type albumResolver struct {
+	album models.AlbumNode
+}
+
+// loadAlbum - the album with the id, nil if there is none
+func loadAlbum(ctx context.Context, id int) (*albumResolver, error) {
+	if err := charge(ctx, 1); err != nil {
+		return nil, err
+	}
+
+	a, err := loadersFrom(ctx).albums.Load(ctx, id)()
+	if err != nil {
+		return nil, toResolverError(err)
+	}
+
+	if a == nil {
+		return nil, nil
+	}
+
+	return &albumResolver{album: *a}, nil
+}
+
+func (r *albumResolver) ID() graphql.ID {
+	return toId(r.album.Id)
+}
+
+func (r *albumResolver) Title() string {
+	return r.album.Title
+}
+
+func (r *albumResolver) ReleaseDate() string {
+	return r.album.ReleaseDate
+}
+
+func (r *albumResolver) CoverLink() string {
+	return r.album.CoverLink
+}
+
+func (r *albumResolver) Group(ctx context.Context) (*groupResolver, error) {
+	return loadGroup(ctx, r.album.GroupId)
+}
+
+func (r *albumResolver) Tracks(ctx context.Context) ([]*songResolver, error) {
+	ids, err := loadersFrom(ctx).albumTracks.Load(ctx, r.album.Id)()
+	if err != nil {
+		return nil, toResolverError(err)
+	}
+
+	return loadSongs(ctx, ids)
+}
+
+func (r *albumResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: r.album.CreatedAt}
+}
+
+func (r *albumResolver) UpdatedAt() graphql.Time {
+	return graphql.Time{Time: r.album.UpdatedAt}
+}
@@ -0,0 +1,126 @@
+// Package graph - the GraphQL endpoint over the songs, the music groups and the albums. The nested
+// fields are looked up with dataloaders, so a query costs a fixed number of database queries per
+// level of nesting however many songs or groups it returns.
+package graph
+
+import (
+	_ "embed"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Magic-Kot/effective-mobile/internal/apperror"
+	"github.com/Magic-Kot/effective-mobile/internal/services/group"
+	"github.com/Magic-Kot/effective-mobile/internal/services/song"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// maxDepth - the deepest nesting of the fields a query may ask for
+	maxDepth = 6
+
+	// maxNodes - the most songs, groups, albums and credits a query may resolve, since every level
+	// of nesting may multiply them by its page
+	maxNodes = 2000
+
+	// maxQuerySize - the largest request body accepted
+	maxQuerySize = 1 << 20
+)
+
+var (
+	errInvalidRequest = apperror.Validation("invalid_request", "invalid request")
+	errInvalidId      = apperror.Validation("invalid_id", "invalid id")
+	errTooComplex     = apperror.Validation("query_too_complex", "the query resolves too many objects")
+)
+
+//go:embed schema.graphql
+var schema string
+
+// Handler - serves the GraphQL queries posted as JSON
+type Handler struct {
+	relay           *relay.Handler
+	graphRepository GraphRepository
+	songRepository  SongRepository
+	groupRepository GroupRepository
+	logger          *zerolog.Logger
+}
+
+func NewHandler(songService *song.SongService, groupService *group.GroupService, graphRepository GraphRepository,
+	songRepository SongRepository, groupRepository GroupRepository, logger *zerolog.Logger) (*Handler, error) {
+	resolver := &queryResolver{
+		songService:  *songService,
+		groupService: *groupService,
+	}
+
+	parsed, err := graphql.ParseSchema(schema, resolver, graphql.MaxDepth(maxDepth))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Handler{
+		relay:           &relay.Handler{Schema: parsed},
+		graphRepository: graphRepository,
+		songRepository:  songRepository,
+		groupRepository: groupRepository,
+		logger:          logger,
+	}, nil
+}
+
+// ServeHTTP - run the query with the loaders of this request only, so nothing is cached between requests
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.logger.Debug().Msg("starting the handler 'GraphQL'")
+
+	ctx := h.logger.WithContext(r.Context())
+	ctx = withLoaders(ctx, newLoaders(h.graphRepository, h.songRepository, h.groupRepository))
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxQuerySize)
+
+	h.relay.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// resolverError - the application error with its code in the extensions of the GraphQL error.
+// The message of an internal error is the message of its sentinel only, like in the problem details.
+type resolverError struct {
+	message string
+	code    string
+}
+
+func (e resolverError) Error() string {
+	return e.message
+}
+
+func (e resolverError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.code}
+}
+
+func toResolverError(err error) error {
+	var resErr resolverError
+	if errors.As(err, &resErr) {
+		return err
+	}
+
+	appErr := apperror.From(err)
+
+	message := err.Error()
+	if appErr.Kind == apperror.KindInternal {
+		message = appErr.Message
+	}
+
+	return resolverError{message: message, code: appErr.Code}
+}
+
+func parseId(id graphql.ID) (int, error) {
+	n, err := strconv.Atoi(string(id))
+	if err != nil || n <= 0 {
+		return 0, toResolverError(errInvalidId)
+	}
+
+	return n, nil
+}
+
+func toId(id int) graphql.ID {
+	return graphql.ID(strconv.Itoa(id))
+}
@@ -0,0 +1,127 @@
+package graph
+
+import (
+	"context"
+
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+type groupResolver struct {
+	group models.GroupResponse
+	// filled - the genres and tags of the group were read with it, they are not loaded again
+	filled bool
+}
+
+// newGroupResolvers - the music groups of the services, which come with their genres and tags
+func newGroupResolvers(groups []models.GroupResponse) []*groupResolver {
+	res := make([]*groupResolver, 0, len(groups))
+	for _, g := range groups {
+		res = append(res, &groupResolver{group: g, filled: true})
+	}
+
+	return res
+}
+
+// loadGroup - the music group with the id, nil if there is none
+func loadGroup(ctx context.Context, id int) (*groupResolver, error) {
+	if err := charge(ctx, 1); err != nil {
+		return nil, err
+	}
+
+	g, err := loadersFrom(ctx).groups.Load(ctx, id)()
+	if err != nil {
+		return nil, toResolverError(err)
+	}
+
+	if g == nil {
+		return nil, nil
+	}
+
+	return &groupResolver{group: *g}, nil
+}
+
+func (r *groupResolver) ID() graphql.ID {
+	return toId(r.group.Id)
+}
+
+func (r *groupResolver) Name() string {
+	return r.group.Name
+}
+
+func (r *groupResolver) SongCount() int32 {
+	return int32(r.group.Songs)
+}
+
+func (r *groupResolver) AlbumCount() int32 {
+	return int32(r.group.Albums)
+}
+
+func (r *groupResolver) Genres(ctx context.Context) ([]string, error) {
+	l, err := r.labels(ctx)
+
+	return l.genres, err
+}
+
+func (r *groupResolver) Tags(ctx context.Context) ([]string, error) {
+	l, err := r.labels(ctx)
+
+	return l.tags, err
+}
+
+func (r *groupResolver) labels(ctx context.Context) (labels, error) {
+	if r.filled {
+		return labels{genres: r.group.Genres, tags: r.group.Tags}, nil
+	}
+
+	l, err := loadersFrom(ctx).groupLabels.Load(ctx, r.group.Id)()
+	if err != nil {
+		return l, toResolverError(err)
+	}
+
+	return l, nil
+}
+
+func (r *groupResolver) Songs(ctx context.Context, args struct{ Limit int32 }) ([]*songResolver, error) {
+	if err := checkPage(0, args.Limit); err != nil {
+		return nil, err
+	}
+
+	ids, err := loadersFrom(ctx).groupSongs.Load(ctx, r.group.Id)()
+	if err != nil {
+		return nil, toResolverError(err)
+	}
+
+	if len(ids) > int(args.Limit) {
+		ids = ids[:args.Limit]
+	}
+
+	return loadSongs(ctx, ids)
+}
+
+func (r *groupResolver) Albums(ctx context.Context) ([]*albumResolver, error) {
+	albums, err := loadersFrom(ctx).groupAlbums.Load(ctx, r.group.Id)()
+	if err != nil {
+		return nil, toResolverError(err)
+	}
+
+	if err := charge(ctx, len(albums)); err != nil {
+		return nil, err
+	}
+
+	res := make([]*albumResolver, 0, len(albums))
+	for _, album := range albums {
+		res = append(res, &albumResolver{album: album})
+	}
+
+	return res, nil
+}
+
+func (r *groupResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: r.group.CreatedAt}
+}
+
+func (r *groupResolver) UpdatedAt() graphql.Time {
+	return graphql.Time{Time: r.group.UpdatedAt}
+}
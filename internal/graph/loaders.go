@@ -0,0 +1,189 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+
+	"github.com/graph-gophers/dataloader/v7"
+)
+
+const (
+	// loaderWait - how long a loader collects the keys before running one query for all of them
+	loaderWait = 2 * time.Millisecond
+
+	// maxGroupSongs - the most songs loaded for a music group
+	maxGroupSongs = 100
+
+	// maxRelated - the most related songs loaded for a song
+	maxRelated = 20
+)
+
+type GraphRepository interface {
+	GetSongsByIds(ctx context.Context, ids []int) ([]models.SongsResponse, error)
+	GetGroupsByIds(ctx context.Context, ids []int) ([]models.GroupResponse, error)
+	GetAlbumsByIds(ctx context.Context, ids []int) ([]models.AlbumNode, error)
+	GetGroupAlbums(ctx context.Context, groupIds []int) ([]models.AlbumNode, error)
+	GetSongCredits(ctx context.Context, songIds []int) ([]models.SongCredit, error)
+	GetGroupSongIds(ctx context.Context, groupIds []int, limit int) (map[int][]int, error)
+	GetAlbumTrackIds(ctx context.Context, albumIds []int) (map[int][]int, error)
+	GetRelatedSongIds(ctx context.Context, songIds []int, limit int) (map[int][]int, error)
+}
+
+type SongRepository interface {
+	GetSongLabels(ctx context.Context, songIds []int) (map[int][]string, map[int][]string, error)
+}
+
+type GroupRepository interface {
+	GetGroupLabels(ctx context.Context, groupIds []int) (map[int][]string, map[int][]string, error)
+}
+
+// labels - the genres and the tags of a song or a music group
+type labels struct {
+	genres []string
+	tags   []string
+}
+
+// loaders - the dataloaders of one request. Every nested field asks its loader for one key, the loader
+// collects the keys of all the fields resolved at the same time and looks them up in one query.
+type loaders struct {
+	songs        *dataloader.Loader[int, *models.SongsResponse]
+	groups       *dataloader.Loader[int, *models.GroupResponse]
+	albums       *dataloader.Loader[int, *models.AlbumNode]
+	songLabels   *dataloader.Loader[int, labels]
+	groupLabels  *dataloader.Loader[int, labels]
+	songCredits  *dataloader.Loader[int, []models.SongCredit]
+	groupSongs   *dataloader.Loader[int, []int]
+	groupAlbums  *dataloader.Loader[int, []models.AlbumNode]
+	albumTracks  *dataloader.Loader[int, []int]
+	relatedSongs *dataloader.Loader[int, []int]
+
+	// nodes - the objects resolved by the query so far, the fields are resolved in parallel
+	nodes atomic.Int64
+}
+
+type loadersKey struct{}
+
+func newLoaders(graphRepository GraphRepository, songRepository SongRepository, groupRepository GroupRepository) *loaders {
+	return &loaders{
+		songs: newLoader(func(ctx context.Context, ids []int) (map[int]*models.SongsResponse, error) {
+			songs, err := graphRepository.GetSongsByIds(ctx, ids)
+
+			res := make(map[int]*models.SongsResponse, len(songs))
+			for i := range songs {
+				res[songs[i].Id] = &songs[i]
+			}
+
+			return res, err
+		}),
+		groups: newLoader(func(ctx context.Context, ids []int) (map[int]*models.GroupResponse, error) {
+			groups, err := graphRepository.GetGroupsByIds(ctx, ids)
+
+			res := make(map[int]*models.GroupResponse, len(groups))
+			for i := range groups {
+				res[groups[i].Id] = &groups[i]
+			}
+
+			return res, err
+		}),
+		albums: newLoader(func(ctx context.Context, ids []int) (map[int]*models.AlbumNode, error) {
+			albums, err := graphRepository.GetAlbumsByIds(ctx, ids)
+
+			res := make(map[int]*models.AlbumNode, len(albums))
+			for i := range albums {
+				res[albums[i].Id] = &albums[i]
+			}
+
+			return res, err
+		}),
+		songLabels: newLoader(func(ctx context.Context, ids []int) (map[int]labels, error) {
+			genres, tags, err := songRepository.GetSongLabels(ctx, ids)
+
+			return mergeLabels(ids, genres, tags), err
+		}),
+		groupLabels: newLoader(func(ctx context.Context, ids []int) (map[int]labels, error) {
+			genres, tags, err := groupRepository.GetGroupLabels(ctx, ids)
+
+			return mergeLabels(ids, genres, tags), err
+		}),
+		songCredits: newLoader(func(ctx context.Context, ids []int) (map[int][]models.SongCredit, error) {
+			credits, err := graphRepository.GetSongCredits(ctx, ids)
+
+			res := make(map[int][]models.SongCredit)
+			for _, credit := range credits {
+				res[credit.SongId] = append(res[credit.SongId], credit)
+			}
+
+			return res, err
+		}),
+		groupSongs: newLoader(func(ctx context.Context, ids []int) (map[int][]int, error) {
+			return graphRepository.GetGroupSongIds(ctx, ids, maxGroupSongs)
+		}),
+		groupAlbums: newLoader(func(ctx context.Context, ids []int) (map[int][]models.AlbumNode, error) {
+			albums, err := graphRepository.GetGroupAlbums(ctx, ids)
+
+			res := make(map[int][]models.AlbumNode)
+			for _, album := range albums {
+				res[album.GroupId] = append(res[album.GroupId], album)
+			}
+
+			return res, err
+		}),
+		albumTracks: newLoader(func(ctx context.Context, ids []int) (map[int][]int, error) {
+			return graphRepository.GetAlbumTrackIds(ctx, ids)
+		}),
+		relatedSongs: newLoader(func(ctx context.Context, ids []int) (map[int][]int, error) {
+			return graphRepository.GetRelatedSongIds(ctx, ids, maxRelated)
+		}),
+	}
+}
+
+// newLoader - a loader looking up all the keys collected within the wait in one call of fetch,
+// a key fetch does not return gets the zero value
+func newLoader[V any](fetch func(ctx context.Context, keys []int) (map[int]V, error)) *dataloader.Loader[int, V] {
+	batch := func(ctx context.Context, keys []int) []*dataloader.Result[V] {
+		values, err := fetch(ctx, keys)
+
+		results := make([]*dataloader.Result[V], len(keys))
+		for i, key := range keys {
+			if err != nil {
+				results[i] = &dataloader.Result[V]{Error: err}
+			} else {
+				results[i] = &dataloader.Result[V]{Data: values[key]}
+			}
+		}
+
+		return results
+	}
+
+	return dataloader.NewBatchedLoader(batch, dataloader.WithWait[int, V](loaderWait))
+}
+
+func mergeLabels(ids []int, genres map[int][]string, tags map[int][]string) map[int]labels {
+	res := make(map[int]labels, len(ids))
+	for _, id := range ids {
+		res[id] = labels{genres: genres[id], tags: tags[id]}
+	}
+
+	return res
+}
+
+// charge - count n more objects resolved by the query, failing the field once there are more than maxNodes
+func charge(ctx context.Context, n int) error {
+	if loadersFrom(ctx).nodes.Add(int64(n)) > maxNodes {
+		return toResolverError(fmt.Errorf("%w: at most %d objects", errTooComplex, maxNodes))
+	}
+
+	return nil
+}
+
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
@@ -0,0 +1,180 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+	"github.com/Magic-Kot/effective-mobile/internal/services/group"
+	"github.com/Magic-Kot/effective-mobile/internal/services/song"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+// maxLimit - the largest page of the listings
+const maxLimit = 100
+
+type queryResolver struct {
+	songService  song.SongService
+	groupService group.GroupService
+}
+
+func checkPage(after int32, limit int32) error {
+	if after < 0 {
+		return toResolverError(fmt.Errorf("%w: after must be a non-negative number", errInvalidRequest))
+	}
+
+	if limit < 1 || limit > maxLimit {
+		return toResolverError(fmt.Errorf("%w: limit must be a number from 1 to %d", errInvalidRequest, maxLimit))
+	}
+
+	return nil
+}
+
+func (q *queryResolver) Song(ctx context.Context, args struct{ Id graphql.ID }) (*songResolver, error) {
+	id, err := parseId(args.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return loadSong(ctx, id)
+}
+
+func (q *queryResolver) Songs(ctx context.Context, args struct {
+	After  int32
+	Limit  int32
+	Artist *string
+	Role   *string
+	Genre  *string
+	Tag    *string
+}) ([]*songResolver, error) {
+	if err := checkPage(args.After, args.Limit); err != nil {
+		return nil, err
+	}
+
+	req := models.RequestGetAll{
+		Id:     strconv.Itoa(int(args.After)),
+		Limit:  strconv.Itoa(int(args.Limit)),
+		Artist: valueOf(args.Artist),
+		Role:   valueOf(args.Role),
+		Genre:  valueOf(args.Genre),
+		Tag:    valueOf(args.Tag),
+	}
+
+	songs, err := q.songService.GetAllSong(ctx, req)
+	if err != nil {
+		return nil, toResolverError(err)
+	}
+
+	if err := charge(ctx, len(songs)); err != nil {
+		return nil, err
+	}
+
+	return newSongResolvers(songs), nil
+}
+
+func (q *queryResolver) Group(ctx context.Context, args struct{ Id graphql.ID }) (*groupResolver, error) {
+	id, err := parseId(args.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return loadGroup(ctx, id)
+}
+
+func (q *queryResolver) Groups(ctx context.Context, args struct {
+	After int32
+	Limit int32
+	Name  *string
+}) ([]*groupResolver, error) {
+	if err := checkPage(args.After, args.Limit); err != nil {
+		return nil, err
+	}
+
+	req := models.RequestGetAllGroups{
+		Id:    strconv.Itoa(int(args.After)),
+		Limit: strconv.Itoa(int(args.Limit)),
+		Name:  valueOf(args.Name),
+	}
+
+	groups, err := q.groupService.GetAllGroups(ctx, req)
+	if err != nil {
+		return nil, toResolverError(err)
+	}
+
+	if err := charge(ctx, len(groups)); err != nil {
+		return nil, err
+	}
+
+	return newGroupResolvers(groups), nil
+}
+
+func (q *queryResolver) Album(ctx context.Context, args struct{ Id graphql.ID }) (*albumResolver, error) {
+	id, err := parseId(args.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return loadAlbum(ctx, id)
+}
+
+func (q *queryResolver) Search(ctx context.Context, args struct {
+	Text  string
+	Limit int32
+}) ([]*searchResultResolver, error) {
+	if err := checkPage(0, args.Limit); err != nil {
+		return nil, err
+	}
+
+	songs, err := q.songService.SearchSongs(ctx, args.Text, int(args.Limit))
+	if err != nil {
+		return nil, toResolverError(err)
+	}
+
+	groups, err := q.groupService.GetAllGroups(ctx, models.RequestGetAllGroups{
+		Id:    "0",
+		Limit: strconv.Itoa(int(args.Limit)),
+		Name:  args.Text,
+	})
+	if err != nil {
+		return nil, toResolverError(err)
+	}
+
+	if err := charge(ctx, len(groups)+len(songs)); err != nil {
+		return nil, err
+	}
+
+	res := make([]*searchResultResolver, 0, len(groups)+len(songs))
+	for _, g := range newGroupResolvers(groups) {
+		res = append(res, &searchResultResolver{group: g})
+	}
+
+	for _, s := range newSongResolvers(songs) {
+		res = append(res, &searchResultResolver{song: s})
+	}
+
+	return res, nil
+}
+
+// searchResultResolver - a song or a music group found by the search
+type searchResultResolver struct {
+	song  *songResolver
+	group *groupResolver
+}
+
+func (r *searchResultResolver) ToSong() (*songResolver, bool) {
+	return r.song, r.song != nil
+}
+
+func (r *searchResultResolver) ToGroup() (*groupResolver, bool) {
+	return r.group, r.group != nil
+}
+
+func valueOf(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
@@ -0,0 +1,212 @@
+package graph
+
+import (
+	"context"
+
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+	"github.com/Magic-Kot/effective-mobile/internal/services/song"
+
+	"github.com/graph-gophers/graphql-go"
+)
+
+type songResolver struct {
+	song models.SongsResponse
+	// filled - the genres and tags of the song were read with it, they are not loaded again
+	filled bool
+}
+
+// newSongResolvers - the songs of the services, which come with their genres and tags
+func newSongResolvers(songs []models.SongsResponse) []*songResolver {
+	res := make([]*songResolver, 0, len(songs))
+	for _, s := range songs {
+		res = append(res, &songResolver{song: s, filled: true})
+	}
+
+	return res
+}
+
+// loadSong - the song with the id, nil if there is none
+func loadSong(ctx context.Context, id int) (*songResolver, error) {
+	if err := charge(ctx, 1); err != nil {
+		return nil, err
+	}
+
+	s, err := loadersFrom(ctx).songs.Load(ctx, id)()
+	if err != nil {
+		return nil, toResolverError(err)
+	}
+
+	if s == nil {
+		return nil, nil
+	}
+
+	return &songResolver{song: *s}, nil
+}
+
+// loadSongs - the songs with the ids in their order, the missing ones are left out
+func loadSongs(ctx context.Context, ids []int) ([]*songResolver, error) {
+	if err := charge(ctx, len(ids)); err != nil {
+		return nil, err
+	}
+
+	songs, errs := loadersFrom(ctx).songs.LoadMany(ctx, ids)()
+	for _, err := range errs {
+		if err != nil {
+			return nil, toResolverError(err)
+		}
+	}
+
+	res := make([]*songResolver, 0, len(songs))
+	for _, s := range songs {
+		if s != nil {
+			res = append(res, &songResolver{song: *s})
+		}
+	}
+
+	return res, nil
+}
+
+func (r *songResolver) ID() graphql.ID {
+	return toId(r.song.Id)
+}
+
+func (r *songResolver) Title() string {
+	return r.song.Song
+}
+
+func (r *songResolver) ReleaseDate() string {
+	return r.song.ReleaseDate
+}
+
+func (r *songResolver) Link() string {
+	return r.song.Link
+}
+
+func (r *songResolver) Lyrics() string {
+	return r.song.Text
+}
+
+func (r *songResolver) Verses() []string {
+	return song.SplitVerses(r.song.Text)
+}
+
+func (r *songResolver) Verse(args struct{ Number int32 }) *string {
+	verses := song.SplitVerses(r.song.Text)
+	if args.Number < 0 || int(args.Number) >= len(verses) {
+		return nil
+	}
+
+	return &verses[args.Number]
+}
+
+func (r *songResolver) Group(ctx context.Context) (*groupResolver, error) {
+	credits, err := loadersFrom(ctx).songCredits.Load(ctx, r.song.Id)()
+	if err != nil {
+		return nil, toResolverError(err)
+	}
+
+	// the primary group is credited first
+	if len(credits) == 0 {
+		return nil, nil
+	}
+
+	return loadGroup(ctx, credits[0].GroupId)
+}
+
+func (r *songResolver) Artists(ctx context.Context) ([]*creditResolver, error) {
+	credits, err := loadersFrom(ctx).songCredits.Load(ctx, r.song.Id)()
+	if err != nil {
+		return nil, toResolverError(err)
+	}
+
+	if err := charge(ctx, len(credits)); err != nil {
+		return nil, err
+	}
+
+	res := make([]*creditResolver, 0, len(credits))
+	for _, credit := range credits {
+		res = append(res, &creditResolver{credit: credit})
+	}
+
+	return res, nil
+}
+
+func (r *songResolver) Album(ctx context.Context) (*albumResolver, error) {
+	if r.song.AlbumId == nil {
+		return nil, nil
+	}
+
+	return loadAlbum(ctx, *r.song.AlbumId)
+}
+
+func (r *songResolver) TrackNumber() *int32 {
+	if r.song.TrackNumber == nil {
+		return nil
+	}
+
+	n := int32(*r.song.TrackNumber)
+
+	return &n
+}
+
+func (r *songResolver) Genres(ctx context.Context) ([]string, error) {
+	l, err := r.labels(ctx)
+
+	return l.genres, err
+}
+
+func (r *songResolver) Tags(ctx context.Context) ([]string, error) {
+	l, err := r.labels(ctx)
+
+	return l.tags, err
+}
+
+func (r *songResolver) labels(ctx context.Context) (labels, error) {
+	if r.filled {
+		return labels{genres: r.song.Genres, tags: r.song.Tags}, nil
+	}
+
+	l, err := loadersFrom(ctx).songLabels.Load(ctx, r.song.Id)()
+	if err != nil {
+		return l, toResolverError(err)
+	}
+
+	return l, nil
+}
+
+func (r *songResolver) Related(ctx context.Context, args struct{ Limit int32 }) ([]*songResolver, error) {
+	if err := checkPage(0, args.Limit); err != nil {
+		return nil, err
+	}
+
+	ids, err := loadersFrom(ctx).relatedSongs.Load(ctx, r.song.Id)()
+	if err != nil {
+		return nil, toResolverError(err)
+	}
+
+	if len(ids) > int(args.Limit) {
+		ids = ids[:args.Limit]
+	}
+
+	return loadSongs(ctx, ids)
+}
+
+func (r *songResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: r.song.CreatedAt}
+}
+
+func (r *songResolver) UpdatedAt() graphql.Time {
+	return graphql.Time{Time: r.song.UpdatedAt}
+}
+
+type creditResolver struct {
+	credit models.SongCredit
+}
+
+func (r *creditResolver) Group(ctx context.Context) (*groupResolver, error) {
+	return loadGroup(ctx, r.credit.GroupId)
+}
+
+func (r *creditResolver) Role() string {
+	return r.credit.Role
+}
@@ -0,0 +1,153 @@
+// Package metrics - the Prometheus metrics of the library, served on the admin port
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog"
+)
+
+const (
+	namespace = "songlib"
+	// totalsTimeout - the time the library is counted in during a scrape
+	totalsTimeout = 5 * time.Second
+)
+
+// TotalsRepository - counts the library at every scrape, see postgres.MetricsRepository
+type TotalsRepository interface {
+	GetLibraryTotals(ctx context.Context) (models.LibraryTotals, error)
+}
+
+type Metrics struct {
+	registry          *prometheus.Registry
+	httpRequests      *prometheus.CounterVec
+	httpDuration      *prometheus.HistogramVec
+	musicInfoCalls    *prometheus.CounterVec
+	musicInfoDuration *prometheus.HistogramVec
+}
+
+// NewMetrics - the metrics of the requests and the music info calls, with the ones of the Go runtime and the process
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "The HTTP requests by method, route and status.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "The time of the HTTP requests by method, route and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		musicInfoCalls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "musicinfo_calls_total",
+			Help:      "The calls of the music info service by outcome: ok, error, bad_status or invalid_response.",
+		}, []string{"outcome"}),
+		musicInfoDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "musicinfo_call_duration_seconds",
+			Help:      "The time of the calls of the music info service by outcome.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"outcome"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.musicInfoCalls,
+		m.musicInfoDuration,
+	)
+
+	return m
+}
+
+// RegisterDB - report the stats of the connection pool of the database
+func (m *Metrics) RegisterDB(db *sql.DB, name string) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterTotals - report the number of the songs, groups, albums and playlists, counted at every scrape
+func (m *Metrics) RegisterTotals(repository TotalsRepository, logger *zerolog.Logger) {
+	m.registry.MustRegister(newTotalsCollector(repository, logger))
+}
+
+// ObserveHTTP - count the request, the route is the pattern of echo: /api/v2/songs/:id
+func (m *Metrics) ObserveHTTP(method string, route string, status int, duration time.Duration) {
+	labels := prometheus.Labels{"method": method, "route": route, "status": strconv.Itoa(status)}
+
+	m.httpRequests.With(labels).Inc()
+	m.httpDuration.With(labels).Observe(duration.Seconds())
+}
+
+// ObserveMusicInfo - count the call of the music info service, see musicinfo.ConfigDeps
+func (m *Metrics) ObserveMusicInfo(outcome string, duration time.Duration) {
+	m.musicInfoCalls.WithLabelValues(outcome).Inc()
+	m.musicInfoDuration.WithLabelValues(outcome).Observe(duration.Seconds())
+}
+
+// Handler - the metrics in the exposition format of Prometheus
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// totalsCollector - the gauges of the size of the library
+type totalsCollector struct {
+	repository TotalsRepository
+	logger     *zerolog.Logger
+	songs      *prometheus.Desc
+	groups     *prometheus.Desc
+	albums     *prometheus.Desc
+	playlists  *prometheus.Desc
+}
+
+func newTotalsCollector(repository TotalsRepository, logger *zerolog.Logger) *totalsCollector {
+	return &totalsCollector{
+		repository: repository,
+		logger:     logger,
+		songs:      prometheus.NewDesc(namespace+"_songs", "The songs of the library.", nil, nil),
+		groups:     prometheus.NewDesc(namespace+"_groups", "The music groups of the library.", nil, nil),
+		albums:     prometheus.NewDesc(namespace+"_albums", "The albums of the library.", nil, nil),
+		playlists:  prometheus.NewDesc(namespace+"_playlists", "The playlists of the library.", nil, nil),
+	}
+}
+
+func (c *totalsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.songs
+	ch <- c.groups
+	ch <- c.albums
+	ch <- c.playlists
+}
+
+// Collect - a failed count reports the gauges as invalid, so the scrape shows the error instead of stale values
+func (c *totalsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(c.logger.WithContext(context.Background()), totalsTimeout)
+	defer cancel()
+
+	totals, err := c.repository.GetLibraryTotals(ctx)
+	if err != nil {
+		c.logger.Error().Msgf("counting the library for the metrics: %v", err)
+
+		ch <- prometheus.NewInvalidMetric(c.songs, err)
+
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.songs, prometheus.GaugeValue, float64(totals.Songs))
+	ch <- prometheus.MustNewConstMetric(c.groups, prometheus.GaugeValue, float64(totals.Groups))
+	ch <- prometheus.MustNewConstMetric(c.albums, prometheus.GaugeValue, float64(totals.Albums))
+	ch <- prometheus.MustNewConstMetric(c.playlists, prometheus.GaugeValue, float64(totals.Playlists))
+}
@@ -0,0 +1,46 @@
+package models
+
+import "time"
+
+type CreateAlbum struct {
+	Group       string `json:"group"         validate:"required,min=2,max=20"`
+	Title       string `json:"title"         validate:"required"`
+	ReleaseDate string `json:"release_date"`
+	CoverLink   string `json:"cover_link"    validate:"omitempty,url"`
+}
+
+type RequestGetAllAlbums struct {
+	Id    string `json:"id"`
+	Limit string `json:"limit"`
+	Group string `json:"group"`
+}
+
+type UpdateAlbum struct {
+	Id          int    `json:"id" db:"id"`
+	Title       string `json:"title" db:"title" validate:"required"`
+	ReleaseDate string `json:"release_date" db:"release_date"`
+	CoverLink   string `json:"cover_link" db:"cover_link" validate:"omitempty,url"`
+}
+
+type AlbumTracksRequest struct {
+	Id     int   `json:"id"`
+	Tracks []int `json:"tracks" validate:"dive,gt=0"`
+}
+
+type AlbumResponse struct {
+	Id          int          `json:"id" db:"id"`
+	Group       string       `json:"group" db:"group_name"`
+	Title       string       `json:"title" db:"title"`
+	ReleaseDate string       `json:"release_date" db:"release_date"`
+	CoverLink   string       `json:"cover_link" db:"cover_link"`
+	CreatedAt   time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at" db:"updated_at"`
+	Tracks      []AlbumTrack `json:"tracks,omitempty" db:"-"`
+}
+
+type AlbumTrack struct {
+	TrackNumber int    `json:"track_number" db:"track_number"`
+	SongId      int    `json:"song_id" db:"song_id"`
+	Song        string `json:"song" db:"song"`
+	ReleaseDate string `json:"release_date" db:"release_date"`
+}
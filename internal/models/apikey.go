@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// Roles of the API keys, each one allows everything the previous one does
+const (
+	RoleReader = "reader"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+// Principal - the authenticated caller of the API, a caller with a bearer token has the subject of
+// the token and no key
+type Principal struct {
+	KeyId   int    `json:"key_id,omitempty"`
+	Subject string `json:"subject,omitempty"`
+	Name    string `json:"name"`
+	Role    string `json:"role"`
+}
+
+type CreateApiKey struct {
+	Name string `json:"name"    validate:"required,max=100"`
+	Role string `json:"role"    validate:"required,oneof=reader editor admin"`
+	// CreatedBy, CreatedBySubject - the key or the subject of the token of the admin issuing the key
+	CreatedBy        *int   `json:"-"`
+	CreatedBySubject string `json:"-"`
+}
+
+// ApiKeyResponse - the key itself is never stored, only its hash, so it is returned once when it is issued
+type ApiKeyResponse struct {
+	Id               int        `json:"id" db:"id"`
+	Name             string     `json:"name" db:"name"`
+	Role             string     `json:"role" db:"role"`
+	Prefix           string     `json:"prefix" db:"prefix"`
+	Key              string     `json:"key,omitempty" db:"-"`
+	CreatedBy        *int       `json:"created_by" db:"created_by"`
+	CreatedBySubject string     `json:"created_by_subject,omitempty" db:"created_by_subject"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+	RevokedAt        *time.Time `json:"revoked_at" db:"revoked_at"`
+}
+
+// ApiKeyWrite - a write of the API made with the key, or with the bearer token of the subject
+type ApiKeyWrite struct {
+	Id        int64     `json:"id" db:"id"`
+	KeyId     int       `json:"key_id,omitempty" db:"key_id"`
+	Subject   string    `json:"subject,omitempty" db:"subject"`
+	Method    string    `json:"method" db:"method"`
+	Route     string    `json:"route" db:"route"`
+	Path      string    `json:"path" db:"path"`
+	Status    int       `json:"status" db:"status"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// RequestGetApiKeyWrites - the writes of the key, or of the subject of the bearer tokens if it is set
+type RequestGetApiKeyWrites struct {
+	KeyId   int
+	Subject string
+	After   int64
+	Limit   int
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// BackupFormat - the name and the version of the archive layout written by the backup
+const (
+	BackupFormat        = "online-song-library-backup"
+	BackupFormatVersion = 1
+)
+
+// Results of restoring a row
+const (
+	RestoreInserted  = "inserted"
+	RestoreUnchanged = "unchanged"
+	RestoreConflict  = "conflict"
+)
+
+// BackupHeader - the first record of the archive
+type BackupHeader struct {
+	Format        string    `json:"format"`
+	Version       int       `json:"version"`
+	SchemaVersion int64     `json:"schema_version"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// BackupRecord - one line of the archive after the header: a table row, or the trailer with the row counts
+type BackupRecord struct {
+	Table string          `json:"table,omitempty"`
+	Row   json.RawMessage `json:"row,omitempty"`
+	End   bool            `json:"end,omitempty"`
+	Rows  map[string]int  `json:"rows,omitempty"`
+}
+
+type BackupReport struct {
+	SchemaVersion int64          `json:"schema_version"`
+	Rows          map[string]int `json:"rows"`
+}
+
+type RestoreRequest struct {
+	DryRun bool `json:"dry_run"`
+}
+
+// RestoreTableReport - a row equal to the stored one is unchanged, a row clashing with a different stored one is a conflict
+type RestoreTableReport struct {
+	Inserted  int `json:"inserted"`
+	Unchanged int `json:"unchanged"`
+	Conflicts int `json:"conflicts"`
+}
+
+type RestoreReport struct {
+	DryRun        bool                          `json:"dry_run"`
+	SchemaVersion int64                         `json:"schema_version"`
+	Tables        map[string]RestoreTableReport `json:"tables"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import "encoding/json"
+
+// Operations of the song batch
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+// Statuses of a batch operation
+const (
+	BatchOk         = "ok"
+	BatchInvalid    = "invalid"
+	BatchFailed     = "failed"
+	BatchRolledBack = "rolled_back"
+)
+
+type BatchRequest struct {
+	BestEffort bool             `json:"best_effort"`
+	Operations []BatchOperation `json:"operations"    validate:"required,min=1,max=1000"`
+}
+
+// BatchOperation - the song is a CreateSong for create and an UpdateRequest for update, delete only needs the id
+type BatchOperation struct {
+	Op   string          `json:"op" enums:"create,update,delete"`
+	Id   int             `json:"id"`
+	Song json.RawMessage `json:"song" swaggertype:"object"`
+}
+
+type BatchResult struct {
+	Index  int    `json:"index"`
+	Op     string `json:"op"`
+	Status string `json:"status"`
+	Id     int    `json:"id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// BatchResponse - succeeded counts the applied operations and failed the rest. In the atomic mode
+// either every operation is ok or none was applied.
+type BatchResponse struct {
+	BestEffort bool          `json:"best_effort"`
+	Succeeded  int           `json:"succeeded"`
+	Failed     int           `json:"failed"`
+	Results    []BatchResult `json:"results"`
+}
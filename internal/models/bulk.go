@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// Formats of the bulk import and export
+const (
+	FormatCSV    = "csv"
+	FormatNDJSON = "ndjson"
+	FormatJSON   = "json"
+)
+
+// Statuses of an imported row
+const (
+	RowCreated   = "created"
+	RowDuplicate = "duplicate"
+	RowInvalid   = "invalid"
+	RowFailed    = "failed"
+)
+
+type ImportSongsRequest struct {
+	Format    string `json:"format"        validate:"required,oneof=csv ndjson"`
+	DryRun    bool   `json:"dry_run"`
+	Enrich    bool   `json:"enrich"`
+	BatchSize int    `json:"batch_size"    validate:"gte=0,lte=1000"`
+}
+
+// ImportRow - one song of the import file, the CSV header names the same columns
+type ImportRow struct {
+	Group       string `json:"group"           validate:"required,min=2,max=20"`
+	Song        string `json:"song"            validate:"required,min=2"`
+	ReleaseDate string `json:"release_date"`
+	Text        string `json:"text"            validate:"max=50000"`
+	Link        string `json:"link"`
+}
+
+type ImportRowResult struct {
+	Row    int    `json:"row"`
+	Status string `json:"status"`
+	Id     int    `json:"id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type ImportSongsReport struct {
+	DryRun    bool              `json:"dry_run"`
+	Created   int               `json:"created"`
+	Duplicate int               `json:"duplicate"`
+	Invalid   int               `json:"invalid"`
+	Failed    int               `json:"failed"`
+	Rows      []ImportRowResult `json:"rows"`
+}
+
+// ExportSong - one song of the export, the group, song, release_date, text and link columns can be imported back
+type ExportSong struct {
+	Id          int          `json:"id"`
+	Group       string       `json:"group"`
+	Song        string       `json:"song"`
+	ReleaseDate string       `json:"release_date"`
+	Text        string       `json:"text"`
+	Link        string       `json:"link"`
+	AlbumId     *int         `json:"album_id"`
+	TrackNumber *int         `json:"track_number"`
+	Artists     []SongArtist `json:"artists"`
+	Genres      []string     `json:"genres"`
+	Tags        []string     `json:"tags"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
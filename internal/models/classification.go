@@ -0,0 +1,42 @@
+package models
+
+// Kinds of song and music group classification
+const (
+	KindGenre = "genre"
+	KindTag   = "tag"
+)
+
+// What a genre or a tag is assigned to
+const (
+	TargetSong  = "song"
+	TargetGroup = "group"
+)
+
+type LabelRequest struct {
+	Kind     string `json:"-"`
+	Target   string `json:"-"`
+	TargetId int    `json:"-"`
+	Name     string `json:"name"    validate:"required,max=50"`
+}
+
+type LabelResponse struct {
+	Id    int    `json:"id" db:"id"`
+	Name  string `json:"name" db:"name"`
+	Songs int    `json:"songs" db:"songs"`
+}
+
+type FacetCount struct {
+	Name  string `json:"name" db:"name"`
+	Count int    `json:"count" db:"count"`
+}
+
+type SongFacets struct {
+	Genres []FacetCount `json:"genres"`
+	Tags   []FacetCount `json:"tags"`
+}
+
+// SongsFacetedResponse - a page of songs with the genre and tag counts of all the songs matching the filters
+type SongsFacetedResponse struct {
+	Songs  []SongsResponse `json:"songs"`
+	Facets SongFacets      `json:"facets"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// SongCredit - the music group credited on the song, referenced by id
+type SongCredit struct {
+	SongId  int    `db:"song_id"`
+	GroupId int    `db:"group_id"`
+	Role    string `db:"role"`
+}
+
+// AlbumNode - the album referencing its music group by id
+type AlbumNode struct {
+	Id          int       `db:"id"`
+	GroupId     int       `db:"group_id"`
+	Title       string    `db:"title"`
+	ReleaseDate string    `db:"release_date"`
+	CoverLink   string    `db:"cover_link"`
+	CreatedAt   time.Time `db:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
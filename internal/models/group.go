@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+type RequestGetAllGroups struct {
+	Id    string `json:"id"`
+	Limit string `json:"limit"`
+	Name  string `json:"name"`
+}
+
+type GroupResponse struct {
+	Id        int       `json:"id" db:"id"`
+	Name      string    `json:"name" db:"group_name"`
+	Songs     int       `json:"songs" db:"songs"`
+	Albums    int       `json:"albums" db:"albums"`
+	Genres    []string  `json:"genres" db:"-"`
+	Tags      []string  `json:"tags" db:"-"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type CreateGroup struct {
+	Name string `json:"name"    validate:"required,min=2,max=20"`
+}
+
+type UpdateGroup struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"    validate:"required,min=2,max=20"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+type RateSong struct {
+	SongId int `json:"-"`
+	Rating int `json:"rating"    validate:"required,min=1,max=5"`
+}
+
+type RatingResponse struct {
+	SongId    int       `json:"song_id" db:"song_id"`
+	Rating    int       `json:"rating" db:"rating"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+type RecordPlay struct {
+	SongId int `json:"song_id"    validate:"required,gt=0"`
+}
+
+// PlayResponse - a play of the listening history with the song it is of
+type PlayResponse struct {
+	Id        int64     `json:"id" db:"id"`
+	SongId    int       `json:"song_id" db:"song_id"`
+	GroupSong string    `json:"group_song" db:"group_song"`
+	Song      string    `json:"song" db:"song"`
+	PlayedAt  time.Time `json:"played_at" db:"played_at"`
+}
+
+type RequestGetFavorites struct {
+	UserId string
+	After  int
+	Limit  int
+}
+
+// RequestGetPlays - the plays older than the one with the id Before, all of them if it is 0
+type RequestGetPlays struct {
+	UserId string
+	Before int64
+	Limit  int
+}
+
+// SongStatsDelta - the change of the aggregates of a song
+type SongStatsDelta struct {
+	Favorites int
+	Ratings   int
+	RatingSum int
+	Plays     int
+}
@@ -0,0 +1,9 @@
+package models
+
+// LibraryTotals - the size of the library reported by the metrics
+type LibraryTotals struct {
+	Songs     int64 `db:"songs"`
+	Groups    int64 `db:"groups"`
+	Albums    int64 `db:"albums"`
+	Playlists int64 `db:"playlists"`
+}
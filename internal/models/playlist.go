@@ -0,0 +1,83 @@
+package models
+
+import "time"
+
+type CreatePlaylist struct {
+	Name        string `json:"name"           validate:"required,max=100"`
+	Owner       string `json:"owner"          validate:"required,max=100"`
+	Description string `json:"description"    validate:"max=1000"`
+	Songs       []int  `json:"songs"          validate:"dive,gt=0"`
+}
+
+type RequestGetAllPlaylists struct {
+	Id    string `json:"id"`
+	Limit string `json:"limit"`
+	Owner string `json:"owner"`
+}
+
+type UpdatePlaylist struct {
+	Id          int    `json:"id"`
+	Name        string `json:"name"           validate:"required,max=100"`
+	Description string `json:"description"    validate:"max=1000"`
+}
+
+// PlaylistEntriesRequest - songs to insert starting at the position, 0 appends them to the end
+type PlaylistEntriesRequest struct {
+	Id       int   `json:"id"`
+	Songs    []int `json:"songs"       validate:"required,min=1,dive,gt=0"`
+	Position int   `json:"position"    validate:"gte=0"`
+}
+
+// MoveEntryRequest - move the entry at the position 'from' to the position 'to'
+type MoveEntryRequest struct {
+	Id   int `json:"id"`
+	From int `json:"from"    validate:"required,gt=0"`
+	To   int `json:"to"      validate:"required,gt=0"`
+}
+
+// DuplicatePlaylist - the copy keeps the name and owner of the original unless they are given
+type DuplicatePlaylist struct {
+	Id    int    `json:"id"`
+	Name  string `json:"name"     validate:"max=100"`
+	Owner string `json:"owner"    validate:"max=100"`
+}
+
+type PlaylistResponse struct {
+	Id          int             `json:"id" db:"id"`
+	Name        string          `json:"name" db:"name"`
+	Owner       string          `json:"owner" db:"owner"`
+	Description string          `json:"description" db:"description"`
+	Songs       int             `json:"songs" db:"songs"`
+	CreatedAt   time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at" db:"updated_at"`
+	Entries     []PlaylistEntry `json:"entries,omitempty" db:"-"`
+}
+
+type PlaylistEntry struct {
+	Position  int    `json:"position" db:"position"`
+	SongId    int    `json:"song_id" db:"song_id"`
+	GroupSong string `json:"group_song" db:"group_song"`
+	Song      string `json:"song" db:"song"`
+	Link      string `json:"link" db:"link"`
+}
+
+// ImportPlaylist - the playlist file is read from the request body, an empty name is taken from the file
+type ImportPlaylist struct {
+	Format string `json:"format"    validate:"required,oneof=m3u8 xspf jspf"`
+	Name   string `json:"name"      validate:"max=100"`
+	Owner  string `json:"owner"     validate:"required,max=100"`
+}
+
+// TrackRef - an imported playlist entry to be matched with a library song
+type TrackRef struct {
+	Position int    `json:"position"`
+	Group    string `json:"group"`
+	Song     string `json:"song"`
+	Location string `json:"location"`
+}
+
+type ImportPlaylistResponse struct {
+	Id        int        `json:"id"`
+	Matched   int        `json:"matched"`
+	Unmatched []TrackRef `json:"unmatched"`
+}
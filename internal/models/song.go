@@ -1,30 +1,78 @@
 package models
 
+import "time"
+
+// Roles a music group can be credited with on a song
+const (
+	RolePrimary  = "primary"
+	RoleFeatured = "featured"
+	RoleComposer = "composer"
+	RoleLyricist = "lyricist"
+)
+
 type CreateSong struct {
-	Group string `json:"group"       validate:"required,min=2,max=20"`
-	Song  string `json:"song"        validate:"required,min=2"`
+	Group       string       `json:"group"           validate:"required,min=2,max=20"`
+	Song        string       `json:"song"            validate:"required,min=2"`
+	AlbumId     int          `json:"album_id"        validate:"omitempty,gt=0"`
+	TrackNumber int          `json:"track_number"    validate:"omitempty,gt=0"`
+	Artists     []SongArtist `json:"artists"         validate:"omitempty,dive"`
+}
+
+// SongArtist - a music group credited on the song with the role
+type SongArtist struct {
+	Group string `json:"group" db:"group_name" validate:"required,min=2,max=20"`
+	Role  string `json:"role" db:"role" validate:"required,oneof=primary featured composer lyricist"`
 }
 
 type RequestGetAll struct {
-	Id     string `json:"id"`
-	Limit  string `json:"limit"`
-	Filter string `json:"filter"`
-	Value  string `json:"value"`
+	Id            string `json:"id"`
+	Limit         string `json:"limit"`
+	Filter        string `json:"filter"`
+	Value         string `json:"value"`
+	CreatedAfter  string `json:"created_after"`
+	CreatedBefore string `json:"created_before"`
+	UpdatedAfter  string `json:"updated_after"`
+	UpdatedBefore string `json:"updated_before"`
+	Artist        string `json:"artist"`
+	Role          string `json:"role"`
+	Genre         string `json:"genre"`
+	Tag           string `json:"tag"`
+	Facets        bool   `json:"facets"`
+	Sort          string `json:"sort"`
 }
 
 type UpdateRequest struct {
-	Id          int    `json:"id" db:"id"`
-	Song        string `json:"song" db:"song_name"`
-	ReleaseDate string `json:"release_date" db:"release_date"`
-	Text        string `json:"text" db:"text"`
-	Link        string `json:"link" db:"link"`
+	Id          int          `json:"id" db:"id"`
+	Song        string       `json:"song" db:"song_name"`
+	ReleaseDate string       `json:"release_date" db:"release_date"`
+	Text        string       `json:"text" db:"text" validate:"max=50000"`
+	Link        string       `json:"link" db:"link"`
+	Artists     []SongArtist `json:"artists" db:"-" validate:"omitempty,dive"`
 }
 
 type SongsResponse struct {
-	Id          int    `json:"id" db:"id"`
-	GroupSong   string `json:"group_song" db:"group_song"`
-	Song        string `json:"song" db:"song"`
-	ReleaseDate string `json:"release_date" db:"release_date"`
-	Text        string `json:"text" db:"text"`
-	Link        string `json:"link" db:"link"`
+	Id          int          `json:"id" db:"id"`
+	GroupSong   string       `json:"group_song" db:"group_song"`
+	Song        string       `json:"song" db:"song"`
+	ReleaseDate string       `json:"release_date" db:"release_date"`
+	Text        string       `json:"text" db:"text"`
+	Link        string       `json:"link" db:"link"`
+	AlbumId     *int         `json:"album_id" db:"album_id"`
+	TrackNumber *int         `json:"track_number" db:"track_number"`
+	Artists     []SongArtist `json:"artists" db:"-"`
+	Genres      []string     `json:"genres" db:"-"`
+	Tags        []string     `json:"tags" db:"-"`
+	Rating      float64      `json:"rating" db:"rating"`
+	Ratings     int          `json:"ratings" db:"ratings"`
+	Plays       int64        `json:"plays" db:"plays"`
+	Favorites   int          `json:"favorites" db:"favorites"`
+	CreatedAt   time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at" db:"updated_at"`
+}
+
+// VerseResponse - a verse of the lyrics, numbered from 0
+type VerseResponse struct {
+	SongId int    `json:"song_id"`
+	Verse  int    `json:"verse"`
+	Text   string `json:"text"`
 }
@@ -0,0 +1,239 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Magic-Kot/effective-mobile/internal/apperror"
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+	"github.com/Magic-Kot/effective-mobile/pkg/client/postg"
+
+	"github.com/lib/pq"
+	"github.com/rs/zerolog"
+)
+
+var (
+	errAlbumNotFound  = apperror.NotFound("album_not_found", "album not found")
+	errCreateAlbum    = apperror.Internal("create_album_failed", "failed to create album")
+	errGetAllAlbums   = apperror.Internal("get_albums_failed", "error getting all albums")
+	errGetAlbum       = apperror.Internal("get_album_failed", "failed to get album")
+	errUpdateAlbum    = apperror.Internal("update_album_failed", "failed to update album")
+	errDeleteAlbum    = apperror.Internal("delete_album_failed", "failed to delete album")
+	errSetAlbumTracks = apperror.Internal("set_album_tracks_failed", "failed to set album tracks")
+)
+
+type AlbumRepository struct {
+	client postg.Client
+}
+
+func NewAlbumRepository(client postg.Client) *AlbumRepository {
+	return &AlbumRepository{
+		client: client,
+	}
+}
+
+func (a *AlbumRepository) conn(ctx context.Context) postg.Querier {
+	return postg.QuerierFromContext(ctx, a.client)
+}
+
+// CreateAlbum - add a new album of the music group
+func (a *AlbumRepository) CreateAlbum(ctx context.Context, groupId int, req models.CreateAlbum) (int, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'CreateAlbum' method")
+
+	query := fmt.Sprint(`INSERT INTO albums (group_id, title, release_date, cover_link) VALUES ($1, $2, $3, $4) RETURNING id`)
+
+	var id int
+
+	if err := a.conn(ctx).QueryRowx(query, groupId, req.Title, req.ReleaseDate, req.CoverLink).Scan(&id); err != nil {
+		logger.Debug().Msgf("error writing to the 'albums' table. err: %s", err)
+		return 0, dbError(errCreateAlbum, err)
+	}
+
+	return id, nil
+}
+
+// GetAllAlbums - get all the albums, optionally of one music group
+func (a *AlbumRepository) GetAllAlbums(ctx context.Context, req models.RequestGetAllAlbums) ([]models.AlbumResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'GetAllAlbums' method")
+	logger.Debug().Msgf("postgres: get albums by request: %+v", req)
+
+	query := fmt.Sprint(`
+		SELECT a.id, g.group_name, a.title, a.release_date, a.cover_link, a.created_at, a.updated_at
+		FROM albums a
+		JOIN music_group g ON g.id = a.group_id
+		WHERE a.id > $1 AND ($2 = '' OR g.group_name = $2)
+		ORDER BY a.id
+		LIMIT $3
+	`)
+
+	var albums []models.AlbumResponse
+
+	if err := a.conn(ctx).Select(&albums, query, req.Id, req.Group, req.Limit); err != nil {
+		logger.Debug().Msgf("error getting all albums. err: %s", err)
+		return nil, dbError(errGetAllAlbums, err)
+	}
+
+	return albums, nil
+}
+
+// GetAlbum - get the album by id
+func (a *AlbumRepository) GetAlbum(ctx context.Context, id int) (models.AlbumResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'GetAlbum' method")
+
+	query := fmt.Sprint(`
+		SELECT a.id, g.group_name, a.title, a.release_date, a.cover_link, a.created_at, a.updated_at
+		FROM albums a
+		JOIN music_group g ON g.id = a.group_id
+		WHERE a.id = $1
+	`)
+
+	var album models.AlbumResponse
+
+	err := a.conn(ctx).QueryRowx(query, id).StructScan(&album)
+	if errors.Is(err, sql.ErrNoRows) {
+		return album, errAlbumNotFound
+	} else if err != nil {
+		logger.Debug().Msgf("error getting the album. err: %s", err)
+		return album, dbError(errGetAlbum, err)
+	}
+
+	return album, nil
+}
+
+// GetAlbumTracks - get the songs of the album ordered by track number.
+// A track without its own release date takes the release date of the album.
+func (a *AlbumRepository) GetAlbumTracks(ctx context.Context, id int) ([]models.AlbumTrack, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'GetAlbumTracks' method")
+
+	query := fmt.Sprint(`
+		SELECT s.track_number, s.id AS song_id, s.song_name AS song,
+			COALESCE(NULLIF(s.release_date, ''), a.release_date) AS release_date
+		FROM songs s
+		JOIN albums a ON a.id = s.album_id
+		WHERE s.album_id = $1
+		ORDER BY s.track_number
+	`)
+
+	var tracks []models.AlbumTrack
+
+	if err := a.conn(ctx).Select(&tracks, query, id); err != nil {
+		logger.Debug().Msgf("error getting album tracks. err: %s", err)
+		return nil, dbError(errGetAlbum, err)
+	}
+
+	return tracks, nil
+}
+
+// UpdateAlbum - update information about the album
+func (a *AlbumRepository) UpdateAlbum(ctx context.Context, req models.UpdateAlbum) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'UpdateAlbum' method")
+
+	query := fmt.Sprint(`UPDATE albums SET title = $2, release_date = $3, cover_link = $4, updated_at = now() WHERE id = $1`)
+
+	commandTag, err := a.conn(ctx).Exec(query, req.Id, req.Title, req.ReleaseDate, req.CoverLink)
+	if err != nil {
+		logger.Debug().Msgf("failed album update: %s", err)
+		return dbError(errUpdateAlbum, err)
+	}
+
+	if rows, _ := commandTag.RowsAffected(); rows != 1 {
+		return errAlbumNotFound
+	}
+
+	return nil
+}
+
+// DeleteAlbum - delete the album, its songs stay in the library without an album
+func (a *AlbumRepository) DeleteAlbum(ctx context.Context, id int) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'DeleteAlbum' method")
+
+	releaseQuery := fmt.Sprint(`UPDATE songs SET album_id = NULL, track_number = NULL, updated_at = now() WHERE album_id = $1`)
+
+	if _, err := a.conn(ctx).Exec(releaseQuery, id); err != nil {
+		logger.Debug().Msgf("failed to release album songs: %s", err)
+		return dbError(errDeleteAlbum, err)
+	}
+
+	commandTag, err := a.conn(ctx).Exec(`DELETE FROM albums WHERE id = $1`, id)
+	if err != nil {
+		return dbError(errDeleteAlbum, err)
+	}
+
+	if rows, _ := commandTag.RowsAffected(); rows != 1 {
+		return errAlbumNotFound
+	}
+
+	return nil
+}
+
+// SetAlbumTracks - replace the track list of the album, the track number is the position in songIds.
+// Songs of the album missing from songIds are removed from it.
+func (a *AlbumRepository) SetAlbumTracks(ctx context.Context, id int, songIds []int) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'SetAlbumTracks' method")
+	logger.Debug().Msgf("postgres: set album %d tracks: %v", id, songIds)
+
+	if err := lockAlbum(ctx, a.conn(ctx), id); err != nil {
+		logger.Debug().Msgf("failed to lock the album: %s", err)
+		return dbError(errSetAlbumTracks, err)
+	}
+
+	ids := pq.Array(songIds)
+
+	removeQuery := fmt.Sprint(`
+		UPDATE songs SET album_id = NULL, track_number = NULL, updated_at = now()
+		WHERE album_id = $1 AND NOT (id = ANY($2::int[]))
+	`)
+
+	if _, err := a.conn(ctx).Exec(removeQuery, id, ids); err != nil {
+		logger.Debug().Msgf("failed to remove album tracks: %s", err)
+		return dbError(errSetAlbumTracks, err)
+	}
+
+	setQuery := fmt.Sprint(`
+		UPDATE songs s SET album_id = $1, track_number = t.track_number, updated_at = now()
+		FROM unnest($2::int[]) WITH ORDINALITY AS t(id, track_number)
+		WHERE s.id = t.id
+	`)
+
+	commandTag, err := a.conn(ctx).Exec(setQuery, id, ids)
+	if err != nil {
+		logger.Debug().Msgf("failed to set album tracks: %s", err)
+		return dbError(errSetAlbumTracks, err)
+	}
+
+	if rows, _ := commandTag.RowsAffected(); rows != int64(len(songIds)) {
+		return errSongNotFound
+	}
+
+	return nil
+}
+
+// lockAlbum - lock the album until the transaction ends, so its track list is changed by one transaction at a time
+func lockAlbum(ctx context.Context, conn postg.Querier, id int) error {
+	zerolog.Ctx(ctx).Debug().Msgf("postgres: lock album %d", id)
+
+	_, err := conn.Exec(`SELECT id FROM albums WHERE id = $1 FOR UPDATE`, id)
+
+	return err
+}
+
+// checkTrackNumbers - the track numbers are unique at the commit only, so a reorder can swap them. Check the
+// pending changes now, so a taken track number is a conflict of the statement instead of a failed commit.
+func checkTrackNumbers(conn postg.Querier) error {
+	if _, err := conn.Exec(`SET CONSTRAINTS songs_album_id_track_number_key IMMEDIATE`); err != nil {
+		return err
+	}
+
+	_, err := conn.Exec(`SET CONSTRAINTS songs_album_id_track_number_key DEFERRED`)
+
+	return err
+}
@@ -0,0 +1,168 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Magic-Kot/effective-mobile/internal/apperror"
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+	"github.com/Magic-Kot/effective-mobile/pkg/client/postg"
+
+	"github.com/rs/zerolog"
+)
+
+var (
+	errApiKeyNotFound = apperror.NotFound("api_key_not_found", "API key not found")
+	errCreateApiKey   = apperror.Internal("create_api_key_failed", "failed to create API key")
+	errGetApiKey      = apperror.Internal("get_api_key_failed", "failed to get API key")
+	errGetAllApiKeys  = apperror.Internal("get_api_keys_failed", "error getting all API keys")
+	errRevokeApiKey   = apperror.Internal("revoke_api_key_failed", "failed to revoke API key")
+	errAddWrite       = apperror.Internal("add_api_key_write_failed", "failed to record the write of the API key")
+	errGetWrites      = apperror.Internal("get_api_key_writes_failed", "error getting the writes of the API key")
+)
+
+type ApiKeyRepository struct {
+	client postg.Client
+}
+
+func NewApiKeyRepository(client postg.Client) *ApiKeyRepository {
+	return &ApiKeyRepository{
+		client: client,
+	}
+}
+
+func (r *ApiKeyRepository) conn(ctx context.Context) postg.Querier {
+	return postg.QuerierFromContext(ctx, r.client)
+}
+
+// CreateApiKey - save the hash of a new key
+func (r *ApiKeyRepository) CreateApiKey(ctx context.Context, req models.CreateApiKey, prefix string, hash string) (models.ApiKeyResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'CreateApiKey' method")
+
+	query := fmt.Sprint(`
+		INSERT INTO api_keys (name, role, prefix, key_hash, created_by, created_by_subject)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''))
+		RETURNING id, name, role, prefix, created_by, COALESCE(created_by_subject, '') AS created_by_subject, created_at, revoked_at
+	`)
+
+	var key models.ApiKeyResponse
+
+	if err := r.conn(ctx).QueryRowx(query, req.Name, req.Role, prefix, hash, req.CreatedBy, req.CreatedBySubject).StructScan(&key); err != nil {
+		logger.Debug().Msgf("error writing to the 'api_keys' table. err: %s", err)
+		return key, dbError(errCreateApiKey, err)
+	}
+
+	return key, nil
+}
+
+// GetApiKeyByHash - the key that is not revoked with the hash
+func (r *ApiKeyRepository) GetApiKeyByHash(ctx context.Context, hash string) (models.ApiKeyResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'GetApiKeyByHash' method")
+
+	query := fmt.Sprint(`
+		SELECT id, name, role, prefix, created_by, COALESCE(created_by_subject, '') AS created_by_subject, created_at, revoked_at
+		FROM api_keys
+		WHERE key_hash = $1 AND revoked_at IS NULL
+	`)
+
+	var key models.ApiKeyResponse
+
+	err := r.conn(ctx).QueryRowx(query, hash).StructScan(&key)
+	if errors.Is(err, sql.ErrNoRows) {
+		return key, errApiKeyNotFound
+	} else if err != nil {
+		logger.Debug().Msgf("error getting the API key. err: %s", err)
+		return key, dbError(errGetApiKey, err)
+	}
+
+	return key, nil
+}
+
+// GetAllApiKeys - get all the keys with the revoked ones
+func (r *ApiKeyRepository) GetAllApiKeys(ctx context.Context) ([]models.ApiKeyResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'GetAllApiKeys' method")
+
+	query := fmt.Sprint(`
+		SELECT id, name, role, prefix, created_by, COALESCE(created_by_subject, '') AS created_by_subject, created_at, revoked_at
+		FROM api_keys
+		ORDER BY id
+	`)
+
+	var keys []models.ApiKeyResponse
+
+	if err := r.conn(ctx).Select(&keys, query); err != nil {
+		logger.Debug().Msgf("error getting all API keys. err: %s", err)
+		return nil, dbError(errGetAllApiKeys, err)
+	}
+
+	return keys, nil
+}
+
+// RevokeApiKey - revoke the key, a revoked key stays for the attribution of its writes
+func (r *ApiKeyRepository) RevokeApiKey(ctx context.Context, id int) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'RevokeApiKey' method")
+
+	commandTag, err := r.conn(ctx).Exec(`UPDATE api_keys SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		logger.Debug().Msgf("error updating the 'api_keys' table. err: %s", err)
+		return dbError(errRevokeApiKey, err)
+	}
+
+	if rows, _ := commandTag.RowsAffected(); rows != 1 {
+		return errApiKeyNotFound
+	}
+
+	return nil
+}
+
+// AddWrite - record the write made with the key or the bearer token
+func (r *ApiKeyRepository) AddWrite(ctx context.Context, write models.ApiKeyWrite) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'AddWrite' method")
+
+	query := fmt.Sprint(`
+		INSERT INTO api_key_writes (key_id, subject, method, route, path, status)
+		VALUES (NULLIF($1, 0), NULLIF($2, ''), $3, $4, $5, $6)
+	`)
+
+	if _, err := r.conn(ctx).Exec(query, write.KeyId, write.Subject, write.Method, write.Route, write.Path, write.Status); err != nil {
+		logger.Debug().Msgf("error writing to the 'api_key_writes' table. err: %s", err)
+		return dbError(errAddWrite, err)
+	}
+
+	return nil
+}
+
+// GetApiKeyWrites - a page of the writes made with the key, or with the tokens of the subject, in id order
+func (r *ApiKeyRepository) GetApiKeyWrites(ctx context.Context, req models.RequestGetApiKeyWrites) ([]models.ApiKeyWrite, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'GetApiKeyWrites' method")
+
+	caller, arg := "key_id", interface{}(req.KeyId)
+	if req.Subject != "" {
+		caller, arg = "subject", req.Subject
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, COALESCE(key_id, 0) AS key_id, COALESCE(subject, '') AS subject, method, route, path, status, created_at
+		FROM api_key_writes
+		WHERE %s = $1 AND id > $2
+		ORDER BY id
+		LIMIT $3
+	`, caller)
+
+	var writes []models.ApiKeyWrite
+
+	if err := r.conn(ctx).Select(&writes, query, arg, req.After, req.Limit); err != nil {
+		logger.Debug().Msgf("error getting the writes of the API key. err: %s", err)
+		return nil, dbError(errGetWrites, err)
+	}
+
+	return writes, nil
+}
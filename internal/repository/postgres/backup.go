@@ -0,0 +1,213 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Magic-Kot/effective-mobile/internal/apperror"
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+	"github.com/Magic-Kot/effective-mobile/pkg/client/postg"
+
+	"github.com/rs/zerolog"
+)
+
+var (
+	errUnknownTable = apperror.Internal("unknown_table", "unknown table")
+	errDumpTable    = apperror.Internal("backup_failed", "error reading the table")
+	errRestoreRow   = apperror.Internal("restore_failed", "failed to restore the row")
+)
+
+// backupTable - a table of the library with the columns its rows are dumped in order of
+type backupTable struct {
+	name    string
+	orderBy string
+	serial  bool
+}
+
+// backupTables - every table of the library, referenced tables first so the rows can be restored in this order.
+// The song_stats are not backed up, they are rebuilt from the favorites, ratings and plays by RebuildSongStats.
+var backupTables = []backupTable{
+	{name: "music_group", orderBy: "id", serial: true},
+	{name: "albums", orderBy: "id", serial: true},
+	{name: "songs", orderBy: "id", serial: true},
+	{name: "song_favorites", orderBy: "user_id, song_id"},
+	{name: "song_ratings", orderBy: "user_id, song_id"},
+	{name: "song_plays", orderBy: "id", serial: true},
+	{name: "mgs", orderBy: "id", serial: true},
+	{name: "genres", orderBy: "id", serial: true},
+	{name: "tags", orderBy: "id", serial: true},
+	{name: "song_genres", orderBy: "song_id, genre_id"},
+	{name: "song_tags", orderBy: "song_id, tag_id"},
+	{name: "group_genres", orderBy: "group_id, genre_id"},
+	{name: "group_tags", orderBy: "group_id, tag_id"},
+	{name: "playlists", orderBy: "id", serial: true},
+	{name: "playlist_entries", orderBy: "id", serial: true},
+	// the keys are restored in id order, so the key a key was created by comes first
+	{name: "api_keys", orderBy: "id", serial: true},
+	{name: "api_key_writes", orderBy: "id", serial: true},
+}
+
+type BackupRepository struct {
+	client postg.Client
+}
+
+func NewBackupRepository(client postg.Client) *BackupRepository {
+	return &BackupRepository{
+		client: client,
+	}
+}
+
+func (r *BackupRepository) conn(ctx context.Context) postg.Querier {
+	return postg.QuerierFromContext(ctx, r.client)
+}
+
+func lookupBackupTable(name string) (backupTable, error) {
+	for _, table := range backupTables {
+		if table.name == name {
+			return table, nil
+		}
+	}
+
+	return backupTable{}, fmt.Errorf("%w: %s", errUnknownTable, name)
+}
+
+// Tables - the names of the tables of the library in restore order
+func (r *BackupRepository) Tables() []string {
+	names := make([]string, 0, len(backupTables))
+	for _, table := range backupTables {
+		names = append(names, table.name)
+	}
+
+	return names
+}
+
+// DumpTable - pass every row of the table to fn as a JSON object, the rows are read as fn consumes them
+func (r *BackupRepository) DumpTable(ctx context.Context, name string, fn func(row json.RawMessage) error) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msgf("accessing Postgres using the 'DumpTable' method, table: %s", name)
+
+	table, err := lookupBackupTable(name)
+	if err != nil {
+		return err
+	}
+
+	rows, err := r.conn(ctx).Queryx(fmt.Sprintf(`SELECT row_to_json(t) FROM %s t ORDER BY %s`, table.name, table.orderBy))
+	if err != nil {
+		logger.Debug().Msgf("error reading the '%s' table. err: %s", table.name, err)
+		return dbError(errDumpTable, err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var row []byte
+
+		if err = rows.Scan(&row); err != nil {
+			return dbError(errDumpTable, err)
+		}
+
+		if err = fn(row); err != nil {
+			return err
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.Debug().Msgf("error reading the '%s' table. err: %s", table.name, err)
+		return dbError(errDumpTable, err)
+	}
+
+	return nil
+}
+
+// RestoreRow - insert the row unless it clashes with a stored row. The clash is RestoreUnchanged
+// if the stored row is equal to it and RestoreConflict otherwise.
+func (r *BackupRepository) RestoreRow(ctx context.Context, name string, row json.RawMessage) (string, error) {
+	table, err := lookupBackupTable(name)
+	if err != nil {
+		return "", err
+	}
+
+	insertQuery := fmt.Sprintf(`
+		INSERT INTO %[1]s SELECT * FROM json_populate_record(NULL::%[1]s, $1)
+		ON CONFLICT DO NOTHING
+	`, table.name)
+
+	commandTag, err := r.conn(ctx).Exec(insertQuery, []byte(row))
+	if err != nil {
+		return "", dbError(errRestoreRow, fmt.Errorf("%s: %s: %w", table.name, row, err))
+	}
+
+	if inserted, _ := commandTag.RowsAffected(); inserted > 0 {
+		return models.RestoreInserted, nil
+	}
+
+	equalQuery := fmt.Sprintf(`
+		SELECT EXISTS (
+			SELECT 1 FROM %[1]s t WHERE to_jsonb(t) = to_jsonb(json_populate_record(NULL::%[1]s, $1))
+		)
+	`, table.name)
+
+	var equal bool
+
+	if err = r.conn(ctx).QueryRowx(equalQuery, []byte(row)).Scan(&equal); err != nil {
+		return "", dbError(errRestoreRow, err)
+	}
+
+	if equal {
+		return models.RestoreUnchanged, nil
+	}
+
+	return models.RestoreConflict, nil
+}
+
+// ResetSequences - move the id sequences past the restored rows
+func (r *BackupRepository) ResetSequences(ctx context.Context) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'ResetSequences' method")
+
+	for _, table := range backupTables {
+		if !table.serial {
+			continue
+		}
+
+		query := fmt.Sprintf(`
+			SELECT setval(pg_get_serial_sequence('%[1]s', 'id'), COALESCE(MAX(id), 1), MAX(id) IS NOT NULL) FROM %[1]s
+		`, table.name)
+
+		if _, err := r.conn(ctx).Exec(query); err != nil {
+			logger.Debug().Msgf("error resetting the '%s' sequence. err: %s", table.name, err)
+			return dbError(errRestoreRow, err)
+		}
+	}
+
+	return nil
+}
+
+// RebuildSongStats - count the aggregates of the songs again from the favorites, ratings and plays
+func (r *BackupRepository) RebuildSongStats(ctx context.Context) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'RebuildSongStats' method")
+
+	query := fmt.Sprint(`
+		INSERT INTO song_stats (song_id, favorite_count, rating_count, rating_sum, play_count)
+		SELECT s.id,
+			(SELECT COUNT(*) FROM song_favorites f WHERE f.song_id = s.id),
+			(SELECT COUNT(*) FROM song_ratings r WHERE r.song_id = s.id),
+			(SELECT COALESCE(SUM(r.rating), 0) FROM song_ratings r WHERE r.song_id = s.id),
+			(SELECT COUNT(*) FROM song_plays p WHERE p.song_id = s.id)
+		FROM songs s
+		ON CONFLICT (song_id) DO UPDATE SET
+			favorite_count = EXCLUDED.favorite_count,
+			rating_count = EXCLUDED.rating_count,
+			rating_sum = EXCLUDED.rating_sum,
+			play_count = EXCLUDED.play_count
+	`)
+
+	if _, err := r.conn(ctx).Exec(query); err != nil {
+		logger.Debug().Msgf("error rebuilding the 'song_stats' table. err: %s", err)
+		return dbError(errRestoreRow, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,174 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Magic-Kot/effective-mobile/internal/apperror"
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+	"github.com/Magic-Kot/effective-mobile/pkg/client/postg"
+
+	"github.com/lib/pq"
+	"github.com/rs/zerolog"
+)
+
+var (
+	errLabelNotFound  = apperror.NotFound("label_not_found", "genre or tag not found")
+	errTargetNotFound = apperror.NotFound("target_not_found", "song or music group not found")
+	errUnknownLabel   = apperror.Validation("unknown_classification", "unknown classification")
+	errGetLabels      = apperror.Internal("get_labels_failed", "error getting genres or tags")
+	errAssignLabel    = apperror.Internal("assign_label_failed", "failed to assign genre or tag")
+	errRemoveLabel    = apperror.Internal("remove_label_failed", "failed to remove genre or tag")
+)
+
+// labelTables - tables holding one kind of classification
+type labelTables struct {
+	labels string
+	column string
+	links  map[string]string
+}
+
+var labelKinds = map[string]labelTables{
+	models.KindGenre: {
+		labels: "genres",
+		column: "genre_id",
+		links:  map[string]string{models.TargetSong: "song_genres", models.TargetGroup: "group_genres"},
+	},
+	models.KindTag: {
+		labels: "tags",
+		column: "tag_id",
+		links:  map[string]string{models.TargetSong: "song_tags", models.TargetGroup: "group_tags"},
+	},
+}
+
+// targetColumns - the column referencing the song or the music group in the link tables
+var targetColumns = map[string]string{
+	models.TargetSong:  "song_id",
+	models.TargetGroup: "group_id",
+}
+
+type ClassificationRepository struct {
+	client postg.Client
+}
+
+func NewClassificationRepository(client postg.Client) *ClassificationRepository {
+	return &ClassificationRepository{
+		client: client,
+	}
+}
+
+func (r *ClassificationRepository) conn(ctx context.Context) postg.Querier {
+	return postg.QuerierFromContext(ctx, r.client)
+}
+
+func lookupLabel(kind string, target string) (labelTables, string, string, error) {
+	tables, ok := labelKinds[kind]
+	if !ok {
+		return tables, "", "", errUnknownLabel
+	}
+
+	links, ok := tables.links[target]
+	if !ok {
+		return tables, "", "", errUnknownLabel
+	}
+
+	return tables, links, targetColumns[target], nil
+}
+
+// GetAllLabels - get all the genres or tags with the number of songs of each
+func (r *ClassificationRepository) GetAllLabels(ctx context.Context, kind string) ([]models.LabelResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'GetAllLabels' method")
+
+	tables, links, _, err := lookupLabel(kind, models.TargetSong)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(`
+		SELECT l.id, l.name, COUNT(sl.song_id) AS songs
+		FROM %[1]s l
+		LEFT JOIN %[2]s sl ON sl.%[3]s = l.id
+		GROUP BY l.id
+		ORDER BY l.name
+	`, tables.labels, links, tables.column)
+
+	var labels []models.LabelResponse
+
+	if err = r.conn(ctx).Select(&labels, query); err != nil {
+		logger.Debug().Msgf("error getting %s list. err: %s", kind, err)
+		return nil, dbError(errGetLabels, err)
+	}
+
+	return labels, nil
+}
+
+// AssignLabel - assign the genre or tag to the song or music group, creating it if it does not exist
+func (r *ClassificationRepository) AssignLabel(ctx context.Context, req models.LabelRequest) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'AssignLabel' method")
+	logger.Debug().Msgf("postgres: assign %s '%s' to %s %d", req.Kind, req.Name, req.Target, req.TargetId)
+
+	tables, links, column, err := lookupLabel(req.Kind, req.Target)
+	if err != nil {
+		return err
+	}
+
+	labelQuery := fmt.Sprintf(`
+		INSERT INTO %s (name) VALUES ($1)
+		ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+		RETURNING id
+	`, tables.labels)
+
+	var labelId int
+
+	if err = r.conn(ctx).QueryRowx(labelQuery, req.Name).Scan(&labelId); err != nil {
+		logger.Debug().Msgf("error writing to the '%s' table. err: %s", tables.labels, err)
+		return dbError(errAssignLabel, err)
+	}
+
+	linkQuery := fmt.Sprintf(`INSERT INTO %s (%s, %s) VALUES ($1, $2) ON CONFLICT DO NOTHING`, links, column, tables.column)
+
+	if _, err = r.conn(ctx).Exec(linkQuery, req.TargetId, labelId); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == codeForeignKeyViolation {
+			return errTargetNotFound
+		}
+
+		logger.Debug().Msgf("error writing to the '%s' table. err: %s", links, err)
+
+		return dbError(errAssignLabel, err)
+	}
+
+	return nil
+}
+
+// RemoveLabel - remove the genre or tag from the song or music group
+func (r *ClassificationRepository) RemoveLabel(ctx context.Context, req models.LabelRequest) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'RemoveLabel' method")
+	logger.Debug().Msgf("postgres: remove %s '%s' from %s %d", req.Kind, req.Name, req.Target, req.TargetId)
+
+	tables, links, column, err := lookupLabel(req.Kind, req.Target)
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(`
+		DELETE FROM %[1]s t USING %[2]s l
+		WHERE t.%[3]s = l.id AND t.%[4]s = $1 AND l.name = $2
+	`, links, tables.labels, tables.column, column)
+
+	commandTag, err := r.conn(ctx).Exec(query, req.TargetId, req.Name)
+	if err != nil {
+		logger.Debug().Msgf("error deleting from the '%s' table. err: %s", links, err)
+		return dbError(errRemoveLabel, err)
+	}
+
+	if rows, _ := commandTag.RowsAffected(); rows == 0 {
+		return errLabelNotFound
+	}
+
+	return nil
+}
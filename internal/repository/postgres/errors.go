@@ -0,0 +1,45 @@
+package postgres
+
+import (
+	"errors"
+
+	"github.com/Magic-Kot/effective-mobile/internal/apperror"
+	"github.com/Magic-Kot/effective-mobile/pkg/client/postg"
+
+	"github.com/lib/pq"
+)
+
+// Postgres error codes of the broken constraints
+const (
+	codeStringTooLong       = "22001"
+	codeForeignKeyViolation = "23503"
+	codeUniqueViolation     = "23505"
+	codeCheckViolation      = "23514"
+)
+
+var (
+	errConflict         = apperror.Conflict("conflict", "the change conflicts with the stored data")
+	errInvalidReference = apperror.Validation("invalid_reference", "a referenced resource does not exist")
+	errConstraint       = apperror.Validation("constraint_violation", "the value breaks a rule of the library")
+	errTooLong          = apperror.Validation("value_too_long", "a value is longer than the library allows")
+)
+
+// dbError - the sentinel of the failed query with the Postgres error as the cause.
+// A broken constraint is reported as the client's error instead.
+func dbError(sentinel error, err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case codeUniqueViolation:
+			return postg.WithCause(errConflict, err)
+		case codeForeignKeyViolation:
+			return postg.WithCause(errInvalidReference, err)
+		case codeCheckViolation:
+			return postg.WithCause(errConstraint, err)
+		case codeStringTooLong:
+			return postg.WithCause(errTooLong, err)
+		}
+	}
+
+	return postg.WithCause(sentinel, err)
+}
@@ -0,0 +1,213 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Magic-Kot/effective-mobile/internal/apperror"
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+	"github.com/Magic-Kot/effective-mobile/pkg/client/postg"
+
+	"github.com/lib/pq"
+	"github.com/rs/zerolog"
+)
+
+var errGraphLookup = apperror.Internal("graph_lookup_failed", "failed to load the related data")
+
+// GraphRepository - the lookups of many songs, groups and albums at once, one query for all the
+// keys collected by the GraphQL dataloaders
+type GraphRepository struct {
+	client postg.Client
+}
+
+func NewGraphRepository(client postg.Client) *GraphRepository {
+	return &GraphRepository{
+		client: client,
+	}
+}
+
+func (r *GraphRepository) conn(ctx context.Context) postg.Querier {
+	return postg.QuerierFromContext(ctx, r.client)
+}
+
+// GetSongsByIds - get the songs with the ids, a missing song is left out
+func (r *GraphRepository) GetSongsByIds(ctx context.Context, ids []int) ([]models.SongsResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'GetSongsByIds' method")
+
+	query := fmt.Sprintf(`%s
+		WHERE s.id = ANY($1::int[])
+	`, selectSongs)
+
+	var songs []models.SongsResponse
+
+	if err := r.conn(ctx).Select(&songs, query, pq.Array(ids)); err != nil {
+		logger.Debug().Msgf("error getting songs by ids. err: %s", err)
+		return nil, dbError(errGraphLookup, err)
+	}
+
+	return songs, nil
+}
+
+// GetGroupsByIds - get the music groups with the ids, a missing group is left out
+func (r *GraphRepository) GetGroupsByIds(ctx context.Context, ids []int) ([]models.GroupResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'GetGroupsByIds' method")
+
+	query := fmt.Sprintf(`%s
+		WHERE g.id = ANY($1::int[])
+	`, selectGroups)
+
+	var groups []models.GroupResponse
+
+	if err := r.conn(ctx).Select(&groups, query, pq.Array(ids)); err != nil {
+		logger.Debug().Msgf("error getting music groups by ids. err: %s", err)
+		return nil, dbError(errGraphLookup, err)
+	}
+
+	return groups, nil
+}
+
+// GetAlbumsByIds - get the albums with the ids, a missing album is left out
+func (r *GraphRepository) GetAlbumsByIds(ctx context.Context, ids []int) ([]models.AlbumNode, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'GetAlbumsByIds' method")
+
+	query := fmt.Sprint(`
+		SELECT id, group_id, title, release_date, cover_link, created_at, updated_at
+		FROM albums
+		WHERE id = ANY($1::int[])
+	`)
+
+	var albums []models.AlbumNode
+
+	if err := r.conn(ctx).Select(&albums, query, pq.Array(ids)); err != nil {
+		logger.Debug().Msgf("error getting albums by ids. err: %s", err)
+		return nil, dbError(errGraphLookup, err)
+	}
+
+	return albums, nil
+}
+
+// GetGroupAlbums - get the albums of the music groups ordered by id
+func (r *GraphRepository) GetGroupAlbums(ctx context.Context, groupIds []int) ([]models.AlbumNode, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'GetGroupAlbums' method")
+
+	query := fmt.Sprint(`
+		SELECT id, group_id, title, release_date, cover_link, created_at, updated_at
+		FROM albums
+		WHERE group_id = ANY($1::int[])
+		ORDER BY id
+	`)
+
+	var albums []models.AlbumNode
+
+	if err := r.conn(ctx).Select(&albums, query, pq.Array(groupIds)); err != nil {
+		logger.Debug().Msgf("error getting albums of music groups. err: %s", err)
+		return nil, dbError(errGraphLookup, err)
+	}
+
+	return albums, nil
+}
+
+// GetSongCredits - get the music groups credited on the songs in the order they were credited
+func (r *GraphRepository) GetSongCredits(ctx context.Context, songIds []int) ([]models.SongCredit, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'GetSongCredits' method")
+
+	query := fmt.Sprint(`
+		SELECT song_id, group_id, role
+		FROM mgs
+		WHERE song_id = ANY($1::int[])
+		ORDER BY song_id, role = 'primary' DESC, id
+	`)
+
+	var credits []models.SongCredit
+
+	if err := r.conn(ctx).Select(&credits, query, pq.Array(songIds)); err != nil {
+		logger.Debug().Msgf("error getting song credits. err: %s", err)
+		return nil, dbError(errGraphLookup, err)
+	}
+
+	return credits, nil
+}
+
+// GetGroupSongIds - get the ids of at most limit songs crediting each music group, keyed by group id
+func (r *GraphRepository) GetGroupSongIds(ctx context.Context, groupIds []int, limit int) (map[int][]int, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'GetGroupSongIds' method")
+
+	query := fmt.Sprint(`
+		SELECT group_id AS key, song_id AS id FROM (
+			SELECT group_id, song_id, ROW_NUMBER() OVER (PARTITION BY group_id ORDER BY song_id) AS rn
+			FROM (SELECT DISTINCT group_id, song_id FROM mgs WHERE group_id = ANY($1::int[])) credited
+		) ranked
+		WHERE rn <= $2
+		ORDER BY group_id, rn
+	`)
+
+	return r.selectIds(ctx, query, pq.Array(groupIds), limit)
+}
+
+// GetAlbumTrackIds - get the song ids of the albums in track order, keyed by album id
+func (r *GraphRepository) GetAlbumTrackIds(ctx context.Context, albumIds []int) (map[int][]int, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'GetAlbumTrackIds' method")
+
+	query := fmt.Sprint(`
+		SELECT album_id AS key, id
+		FROM songs
+		WHERE album_id = ANY($1::int[])
+		ORDER BY album_id, track_number
+	`)
+
+	return r.selectIds(ctx, query, pq.Array(albumIds))
+}
+
+// GetRelatedSongIds - get the ids of at most limit songs sharing a music group, a genre or a tag with
+// each song, the songs sharing the most come first. Keyed by song id.
+func (r *GraphRepository) GetRelatedSongIds(ctx context.Context, songIds []int, limit int) (map[int][]int, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'GetRelatedSongIds' method")
+
+	query := fmt.Sprint(`
+		WITH labels AS (
+			SELECT song_id, 'group:' || group_id AS label FROM mgs
+			UNION SELECT song_id, 'genre:' || genre_id FROM song_genres
+			UNION SELECT song_id, 'tag:' || tag_id FROM song_tags
+		)
+		SELECT key, id FROM (
+			SELECT l.song_id AS key, r.song_id AS id,
+				ROW_NUMBER() OVER (PARTITION BY l.song_id ORDER BY COUNT(*) DESC, r.song_id) AS rn
+			FROM labels l
+			JOIN labels r ON r.label = l.label AND r.song_id <> l.song_id
+			WHERE l.song_id = ANY($1::int[])
+			GROUP BY l.song_id, r.song_id
+		) ranked
+		WHERE rn <= $2
+		ORDER BY key, rn
+	`)
+
+	return r.selectIds(ctx, query, pq.Array(songIds), limit)
+}
+
+// selectIds - read the key and id pairs of the query into lists of ids keyed in the order of the rows
+func (r *GraphRepository) selectIds(ctx context.Context, query string, args ...interface{}) (map[int][]int, error) {
+	var rows []struct {
+		Key int `db:"key"`
+		Id  int `db:"id"`
+	}
+
+	if err := r.conn(ctx).Select(&rows, query, args...); err != nil {
+		zerolog.Ctx(ctx).Debug().Msgf("error getting related ids. err: %s", err)
+		return nil, dbError(errGraphLookup, err)
+	}
+
+	ids := make(map[int][]int)
+	for _, row := range rows {
+		ids[row.Key] = append(ids[row.Key], row.Id)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,195 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Magic-Kot/effective-mobile/internal/apperror"
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+	"github.com/Magic-Kot/effective-mobile/pkg/client/postg"
+
+	"github.com/lib/pq"
+	"github.com/rs/zerolog"
+)
+
+var (
+	errGroupNotFound = apperror.NotFound("group_not_found", "music group not found")
+	errGetAllGroups  = apperror.Internal("get_groups_failed", "error getting all music groups")
+	errGetGroup      = apperror.Internal("get_group_failed", "failed to get music group")
+	errGroupExists   = apperror.Conflict("group_exists", "music group already exists")
+	errUpdateGroup   = apperror.Internal("update_group_failed", "failed to update music group")
+	errDeleteGroup   = apperror.Internal("delete_group_failed", "failed to delete music group")
+)
+
+// selectGroups - the music groups with the number of credited songs and of albums
+const selectGroups = `
+	SELECT g.id, g.group_name, g.created_at, g.updated_at,
+		(SELECT COUNT(DISTINCT mgs.song_id) FROM mgs WHERE mgs.group_id = g.id) AS songs,
+		(SELECT COUNT(*) FROM albums a WHERE a.group_id = g.id) AS albums
+	FROM music_group g`
+
+type GroupRepository struct {
+	client postg.Client
+}
+
+func NewGroupRepository(client postg.Client) *GroupRepository {
+	return &GroupRepository{
+		client: client,
+	}
+}
+
+func (r *GroupRepository) conn(ctx context.Context) postg.Querier {
+	return postg.QuerierFromContext(ctx, r.client)
+}
+
+// CreateGroup - add a new music group
+func (r *GroupRepository) CreateGroup(ctx context.Context, req models.CreateGroup) (int, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'CreateGroup' method")
+
+	query := fmt.Sprint(`INSERT INTO music_group (group_name) VALUES ($1) RETURNING id`)
+
+	var id int
+
+	if err := r.conn(ctx).QueryRowx(query, req.Name).Scan(&id); err != nil {
+		logger.Debug().Msgf("error writing to the 'music_group' table. err: %s", err)
+		return 0, groupError(errCreateGroup, err)
+	}
+
+	return id, nil
+}
+
+// GetAllGroups - get all the music groups, optionally those with the name containing the search string
+func (r *GroupRepository) GetAllGroups(ctx context.Context, req models.RequestGetAllGroups) ([]models.GroupResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'GetAllGroups' method")
+	logger.Debug().Msgf("postgres: get music groups by request: %+v", req)
+
+	query := fmt.Sprintf(`%s
+		WHERE g.id > $1 AND ($2 = '' OR g.group_name ILIKE '%%' || $2 || '%%')
+		ORDER BY g.id
+		LIMIT $3
+	`, selectGroups)
+
+	var groups []models.GroupResponse
+
+	if err := r.conn(ctx).Select(&groups, query, req.Id, req.Name, req.Limit); err != nil {
+		logger.Debug().Msgf("error getting all music groups. err: %s", err)
+		return nil, dbError(errGetAllGroups, err)
+	}
+
+	return groups, nil
+}
+
+// GetGroup - get the music group by id
+func (r *GroupRepository) GetGroup(ctx context.Context, id int) (models.GroupResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'GetGroup' method")
+
+	query := fmt.Sprintf(`%s
+		WHERE g.id = $1
+	`, selectGroups)
+
+	var group models.GroupResponse
+
+	err := r.conn(ctx).QueryRowx(query, id).StructScan(&group)
+	if errors.Is(err, sql.ErrNoRows) {
+		return group, errGroupNotFound
+	} else if err != nil {
+		logger.Debug().Msgf("error getting the music group. err: %s", err)
+		return group, dbError(errGetGroup, err)
+	}
+
+	return group, nil
+}
+
+// GetGroupLabels - get the genres and tags of the music groups, keyed by group id
+func (r *GroupRepository) GetGroupLabels(ctx context.Context, groupIds []int) (map[int][]string, map[int][]string, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'GetGroupLabels' method")
+
+	query := fmt.Sprint(`
+		SELECT 'genre' AS kind, gg.group_id, gn.name
+		FROM group_genres gg JOIN genres gn ON gn.id = gg.genre_id
+		WHERE gg.group_id = ANY($1::int[])
+		UNION ALL
+		SELECT 'tag' AS kind, gt.group_id, t.name
+		FROM group_tags gt JOIN tags t ON t.id = gt.tag_id
+		WHERE gt.group_id = ANY($1::int[])
+		ORDER BY name
+	`)
+
+	var rows []struct {
+		Kind    string `db:"kind"`
+		GroupId int    `db:"group_id"`
+		Name    string `db:"name"`
+	}
+
+	if err := r.conn(ctx).Select(&rows, query, pq.Array(groupIds)); err != nil {
+		logger.Debug().Msgf("error getting music group labels. err: %s", err)
+		return nil, nil, dbError(errGetAllGroups, err)
+	}
+
+	genres := make(map[int][]string)
+	tags := make(map[int][]string)
+
+	for _, row := range rows {
+		if row.Kind == models.KindGenre {
+			genres[row.GroupId] = append(genres[row.GroupId], row.Name)
+		} else {
+			tags[row.GroupId] = append(tags[row.GroupId], row.Name)
+		}
+	}
+
+	return genres, tags, nil
+}
+
+// UpdateGroup - rename the music group
+func (r *GroupRepository) UpdateGroup(ctx context.Context, req models.UpdateGroup) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'UpdateGroup' method")
+
+	query := fmt.Sprint(`UPDATE music_group SET group_name = $2, updated_at = now() WHERE id = $1`)
+
+	commandTag, err := r.conn(ctx).Exec(query, req.Id, req.Name)
+	if err != nil {
+		logger.Debug().Msgf("error updating the 'music_group' table. err: %s", err)
+		return groupError(errUpdateGroup, err)
+	}
+
+	if rows, _ := commandTag.RowsAffected(); rows == 0 {
+		return errGroupNotFound
+	}
+
+	return nil
+}
+
+// DeleteGroup - delete the music group, its albums, genres and tags go with it
+func (r *GroupRepository) DeleteGroup(ctx context.Context, id int) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'DeleteGroup' method")
+
+	commandTag, err := r.conn(ctx).Exec(`DELETE FROM music_group WHERE id = $1`, id)
+	if err != nil {
+		logger.Debug().Msgf("error deleting from the 'music_group' table. err: %s", err)
+		return dbError(errDeleteGroup, err)
+	}
+
+	if rows, _ := commandTag.RowsAffected(); rows == 0 {
+		return errGroupNotFound
+	}
+
+	return nil
+}
+
+// groupError - a taken name is reported as such, the other errors as by dbError
+func groupError(sentinel error, err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == codeUniqueViolation {
+		return postg.WithCause(errGroupExists, err)
+	}
+
+	return dbError(sentinel, err)
+}
@@ -0,0 +1,258 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Magic-Kot/effective-mobile/internal/apperror"
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+	"github.com/Magic-Kot/effective-mobile/pkg/client/postg"
+
+	"github.com/lib/pq"
+	"github.com/rs/zerolog"
+)
+
+var (
+	errRatingNotFound = apperror.NotFound("rating_not_found", "rating not found")
+	errAddFavorite    = apperror.Internal("add_favorite_failed", "failed to add favorite")
+	errRemoveFavorite = apperror.Internal("remove_favorite_failed", "failed to remove favorite")
+	errGetFavorites   = apperror.Internal("get_favorites_failed", "error getting favorites")
+	errGetRating      = apperror.Internal("get_rating_failed", "failed to get rating")
+	errSetRating      = apperror.Internal("set_rating_failed", "failed to rate song")
+	errDeleteRating   = apperror.Internal("delete_rating_failed", "failed to delete rating")
+	errAddPlay        = apperror.Internal("add_play_failed", "failed to record play")
+	errGetPlays       = apperror.Internal("get_plays_failed", "error getting listening history")
+	errSongStats      = apperror.Internal("update_song_stats_failed", "failed to update song aggregates")
+)
+
+// selectPlays - the plays of the table with the title and the primary group of their song
+const selectPlays = `
+	SELECT p.id, p.song_id, COALESCE(g.group_name, '') AS group_song, s.song_name AS song, p.played_at
+	FROM %s p
+	JOIN songs s ON s.id = p.song_id
+	LEFT JOIN LATERAL (
+		SELECT mg.group_name FROM mgs JOIN music_group mg ON mg.id = mgs.group_id
+		WHERE mgs.song_id = s.id ORDER BY mgs.role = 'primary' DESC, mgs.id LIMIT 1
+	) g ON true`
+
+type ListeningRepository struct {
+	client postg.Client
+}
+
+func NewListeningRepository(client postg.Client) *ListeningRepository {
+	return &ListeningRepository{
+		client: client,
+	}
+}
+
+func (r *ListeningRepository) conn(ctx context.Context) postg.Querier {
+	return postg.QuerierFromContext(ctx, r.client)
+}
+
+// AddFavorite - add the song to the favorites of the user, false if it is already there
+func (r *ListeningRepository) AddFavorite(ctx context.Context, userId string, songId int) (bool, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'AddFavorite' method")
+
+	commandTag, err := r.conn(ctx).Exec(`
+		INSERT INTO song_favorites (user_id, song_id) VALUES ($1, $2) ON CONFLICT DO NOTHING
+	`, userId, songId)
+	if err != nil {
+		logger.Debug().Msgf("error writing to the 'song_favorites' table. err: %s", err)
+		return false, songError(errAddFavorite, err)
+	}
+
+	rows, _ := commandTag.RowsAffected()
+
+	return rows == 1, nil
+}
+
+// RemoveFavorite - remove the song from the favorites of the user, false if it is not there
+func (r *ListeningRepository) RemoveFavorite(ctx context.Context, userId string, songId int) (bool, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'RemoveFavorite' method")
+
+	commandTag, err := r.conn(ctx).Exec(`DELETE FROM song_favorites WHERE user_id = $1 AND song_id = $2`, userId, songId)
+	if err != nil {
+		logger.Debug().Msgf("error deleting from the 'song_favorites' table. err: %s", err)
+		return false, dbError(errRemoveFavorite, err)
+	}
+
+	rows, _ := commandTag.RowsAffected()
+
+	return rows == 1, nil
+}
+
+// GetFavorites - a page of the favorite songs of the user in id order
+func (r *ListeningRepository) GetFavorites(ctx context.Context, req models.RequestGetFavorites) ([]models.SongsResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'GetFavorites' method")
+
+	query := fmt.Sprintf(`%s
+		JOIN song_favorites f ON f.song_id = s.id AND f.user_id = $1
+		WHERE s.id > $2
+		ORDER BY s.id
+		LIMIT $3
+	`, selectSongs)
+
+	var songs []models.SongsResponse
+
+	if err := r.conn(ctx).Select(&songs, query, req.UserId, req.After, req.Limit); err != nil {
+		logger.Debug().Msgf("error getting the favorites. err: %s", err)
+		return nil, dbError(errGetFavorites, err)
+	}
+
+	return songs, nil
+}
+
+// LockSongStats - lock the aggregates of the song until the end of the transaction, so the
+// ratings of the song are changed one at a time
+func (r *ListeningRepository) LockSongStats(ctx context.Context, songId int) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'LockSongStats' method")
+
+	query := fmt.Sprint(`
+		INSERT INTO song_stats (song_id) VALUES ($1)
+		ON CONFLICT (song_id) DO UPDATE SET song_id = EXCLUDED.song_id
+	`)
+
+	if _, err := r.conn(ctx).Exec(query, songId); err != nil {
+		logger.Debug().Msgf("error locking the 'song_stats' row. err: %s", err)
+		return songError(errSongStats, err)
+	}
+
+	return nil
+}
+
+// GetRating - the rating of the song by the user, errRatingNotFound if they have not rated it
+func (r *ListeningRepository) GetRating(ctx context.Context, userId string, songId int) (models.RatingResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'GetRating' method")
+
+	query := fmt.Sprint(`SELECT song_id, rating, updated_at FROM song_ratings WHERE user_id = $1 AND song_id = $2`)
+
+	var rating models.RatingResponse
+
+	err := r.conn(ctx).QueryRowx(query, userId, songId).StructScan(&rating)
+	if errors.Is(err, sql.ErrNoRows) {
+		return rating, errRatingNotFound
+	} else if err != nil {
+		logger.Debug().Msgf("error getting the rating. err: %s", err)
+		return rating, dbError(errGetRating, err)
+	}
+
+	return rating, nil
+}
+
+// SetRating - save the rating of the song by the user, replacing the previous one
+func (r *ListeningRepository) SetRating(ctx context.Context, userId string, req models.RateSong) (models.RatingResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'SetRating' method")
+
+	query := fmt.Sprint(`
+		INSERT INTO song_ratings (user_id, song_id, rating) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, song_id) DO UPDATE SET rating = EXCLUDED.rating, updated_at = now()
+		RETURNING song_id, rating, updated_at
+	`)
+
+	var rating models.RatingResponse
+
+	if err := r.conn(ctx).QueryRowx(query, userId, req.SongId, req.Rating).StructScan(&rating); err != nil {
+		logger.Debug().Msgf("error writing to the 'song_ratings' table. err: %s", err)
+		return rating, songError(errSetRating, err)
+	}
+
+	return rating, nil
+}
+
+func (r *ListeningRepository) DeleteRating(ctx context.Context, userId string, songId int) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'DeleteRating' method")
+
+	if _, err := r.conn(ctx).Exec(`DELETE FROM song_ratings WHERE user_id = $1 AND song_id = $2`, userId, songId); err != nil {
+		logger.Debug().Msgf("error deleting from the 'song_ratings' table. err: %s", err)
+		return dbError(errDeleteRating, err)
+	}
+
+	return nil
+}
+
+// AddPlay - record that the user played the song
+func (r *ListeningRepository) AddPlay(ctx context.Context, userId string, songId int) (models.PlayResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'AddPlay' method")
+
+	query := fmt.Sprintf(`
+		WITH added AS (
+			INSERT INTO song_plays (user_id, song_id) VALUES ($1, $2)
+			RETURNING id, song_id, played_at
+		)
+		%s
+	`, fmt.Sprintf(selectPlays, "added"))
+
+	var play models.PlayResponse
+
+	if err := r.conn(ctx).QueryRowx(query, userId, songId).StructScan(&play); err != nil {
+		logger.Debug().Msgf("error writing to the 'song_plays' table. err: %s", err)
+		return play, songError(errAddPlay, err)
+	}
+
+	return play, nil
+}
+
+// GetPlays - a page of the listening history of the user, the latest play first
+func (r *ListeningRepository) GetPlays(ctx context.Context, req models.RequestGetPlays) ([]models.PlayResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'GetPlays' method")
+
+	query := fmt.Sprintf(`
+		%s
+		WHERE p.user_id = $1 AND ($2::bigint = 0 OR p.id < $2::bigint)
+		ORDER BY p.id DESC
+		LIMIT $3
+	`, fmt.Sprintf(selectPlays, "song_plays"))
+
+	var plays []models.PlayResponse
+
+	if err := r.conn(ctx).Select(&plays, query, req.UserId, req.Before, req.Limit); err != nil {
+		logger.Debug().Msgf("error getting the listening history. err: %s", err)
+		return nil, dbError(errGetPlays, err)
+	}
+
+	return plays, nil
+}
+
+// AddSongStats - add the delta to the aggregates of the song
+func (r *ListeningRepository) AddSongStats(ctx context.Context, songId int, delta models.SongStatsDelta) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'AddSongStats' method")
+
+	query := fmt.Sprint(`
+		INSERT INTO song_stats (song_id, favorite_count, rating_count, rating_sum, play_count) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (song_id) DO UPDATE SET
+			favorite_count = song_stats.favorite_count + EXCLUDED.favorite_count,
+			rating_count = song_stats.rating_count + EXCLUDED.rating_count,
+			rating_sum = song_stats.rating_sum + EXCLUDED.rating_sum,
+			play_count = song_stats.play_count + EXCLUDED.play_count
+	`)
+
+	_, err := r.conn(ctx).Exec(query, songId, delta.Favorites, delta.Ratings, delta.RatingSum, delta.Plays)
+	if err != nil {
+		logger.Debug().Msgf("error writing to the 'song_stats' table. err: %s", err)
+		return songError(errSongStats, err)
+	}
+
+	return nil
+}
+
+// songError - a missing song is reported as not found instead of a broken reference
+func songError(sentinel error, err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == codeForeignKeyViolation {
+		return postg.WithCause(errSongNotFound, err)
+	}
+
+	return dbError(sentinel, err)
+}
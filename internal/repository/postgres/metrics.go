@@ -0,0 +1,48 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Magic-Kot/effective-mobile/internal/apperror"
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+	"github.com/Magic-Kot/effective-mobile/pkg/client/postg"
+
+	"github.com/rs/zerolog"
+)
+
+var errGetTotals = apperror.Internal("get_totals_failed", "error counting the library")
+
+type MetricsRepository struct {
+	client postg.Client
+}
+
+func NewMetricsRepository(client postg.Client) *MetricsRepository {
+	return &MetricsRepository{
+		client: client,
+	}
+}
+
+// GetLibraryTotals - the number of the songs, groups, albums and playlists. The count runs on the pool with the
+// context, so the query is canceled when the scrape times out.
+func (r *MetricsRepository) GetLibraryTotals(ctx context.Context) (models.LibraryTotals, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'GetLibraryTotals' method")
+
+	query := fmt.Sprint(`
+		SELECT
+			(SELECT COUNT(*) FROM songs) AS songs,
+			(SELECT COUNT(*) FROM music_group) AS groups,
+			(SELECT COUNT(*) FROM albums) AS albums,
+			(SELECT COUNT(*) FROM playlists) AS playlists
+	`)
+
+	var totals models.LibraryTotals
+
+	if err := r.client.QueryRowxContext(ctx, query).StructScan(&totals); err != nil {
+		logger.Debug().Msgf("error counting the library. err: %s", err)
+		return totals, dbError(errGetTotals, err)
+	}
+
+	return totals, nil
+}
@@ -0,0 +1,350 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/Magic-Kot/effective-mobile/internal/apperror"
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+	"github.com/Magic-Kot/effective-mobile/pkg/client/postg"
+
+	"github.com/lib/pq"
+	"github.com/rs/zerolog"
+)
+
+var (
+	errPlaylistNotFound = apperror.NotFound("playlist_not_found", "playlist not found")
+	errEntryNotFound    = apperror.NotFound("playlist_entry_not_found", "playlist entry not found")
+	errCreatePlaylist   = apperror.Internal("create_playlist_failed", "failed to create playlist")
+	errGetAllPlaylists  = apperror.Internal("get_playlists_failed", "error getting all playlists")
+	errGetPlaylist      = apperror.Internal("get_playlist_failed", "failed to get playlist")
+	errUpdatePlaylist   = apperror.Internal("update_playlist_failed", "failed to update playlist")
+	errDeletePlaylist   = apperror.Internal("delete_playlist_failed", "failed to delete playlist")
+)
+
+type PlaylistRepository struct {
+	client postg.Client
+}
+
+func NewPlaylistRepository(client postg.Client) *PlaylistRepository {
+	return &PlaylistRepository{
+		client: client,
+	}
+}
+
+func (p *PlaylistRepository) conn(ctx context.Context) postg.Querier {
+	return postg.QuerierFromContext(ctx, p.client)
+}
+
+// playlistQuery - playlist columns with the number of entries
+const playlistQuery = `
+	SELECT p.id, p.name, p.owner, p.description, p.created_at, p.updated_at,
+		(SELECT COUNT(*) FROM playlist_entries e WHERE e.playlist_id = p.id) AS songs
+	FROM playlists p
+`
+
+// CreatePlaylist - add a new empty playlist
+func (p *PlaylistRepository) CreatePlaylist(ctx context.Context, req models.CreatePlaylist) (int, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'CreatePlaylist' method")
+
+	query := fmt.Sprint(`INSERT INTO playlists (name, owner, description) VALUES ($1, $2, $3) RETURNING id`)
+
+	var id int
+
+	if err := p.conn(ctx).QueryRowx(query, req.Name, req.Owner, req.Description).Scan(&id); err != nil {
+		logger.Debug().Msgf("error writing to the 'playlists' table. err: %s", err)
+		return 0, dbError(errCreatePlaylist, err)
+	}
+
+	return id, nil
+}
+
+// GetAllPlaylists - get all the playlists, optionally of one owner
+func (p *PlaylistRepository) GetAllPlaylists(ctx context.Context, req models.RequestGetAllPlaylists) ([]models.PlaylistResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'GetAllPlaylists' method")
+	logger.Debug().Msgf("postgres: get playlists by request: %+v", req)
+
+	query := playlistQuery + `WHERE p.id > $1 AND ($2 = '' OR p.owner = $2) ORDER BY p.id LIMIT $3`
+
+	var playlists []models.PlaylistResponse
+
+	if err := p.conn(ctx).Select(&playlists, query, req.Id, req.Owner, req.Limit); err != nil {
+		logger.Debug().Msgf("error getting all playlists. err: %s", err)
+		return nil, dbError(errGetAllPlaylists, err)
+	}
+
+	return playlists, nil
+}
+
+// GetPlaylist - get the playlist by id
+func (p *PlaylistRepository) GetPlaylist(ctx context.Context, id int) (models.PlaylistResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'GetPlaylist' method")
+
+	var playlist models.PlaylistResponse
+
+	err := p.conn(ctx).QueryRowx(playlistQuery+`WHERE p.id = $1`, id).StructScan(&playlist)
+	if errors.Is(err, sql.ErrNoRows) {
+		return playlist, errPlaylistNotFound
+	} else if err != nil {
+		logger.Debug().Msgf("error getting the playlist. err: %s", err)
+		return playlist, dbError(errGetPlaylist, err)
+	}
+
+	return playlist, nil
+}
+
+// GetPlaylistEntries - get the songs of the playlist in order
+func (p *PlaylistRepository) GetPlaylistEntries(ctx context.Context, id int) ([]models.PlaylistEntry, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'GetPlaylistEntries' method")
+
+	query := fmt.Sprint(`
+		SELECT e.position, e.song_id, COALESCE(g.group_name, '') AS group_song, s.song_name AS song, s.link
+		FROM playlist_entries e
+		JOIN songs s ON s.id = e.song_id
+		LEFT JOIN LATERAL (
+			SELECT mg.group_name FROM mgs JOIN music_group mg ON mg.id = mgs.group_id
+			WHERE mgs.song_id = s.id ORDER BY mgs.role = 'primary' DESC, mgs.id LIMIT 1
+		) g ON true
+		WHERE e.playlist_id = $1
+		ORDER BY e.position
+	`)
+
+	var entries []models.PlaylistEntry
+
+	if err := p.conn(ctx).Select(&entries, query, id); err != nil {
+		logger.Debug().Msgf("error getting playlist entries. err: %s", err)
+		return nil, dbError(errGetPlaylist, err)
+	}
+
+	return entries, nil
+}
+
+// LockPlaylist - lock the playlist until the end of the transaction and get the number of its entries
+func (p *PlaylistRepository) LockPlaylist(ctx context.Context, id int) (int, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'LockPlaylist' method")
+
+	query := fmt.Sprint(`
+		SELECT (SELECT COUNT(*) FROM playlist_entries WHERE playlist_id = p.id)
+		FROM playlists p WHERE p.id = $1
+		FOR UPDATE
+	`)
+
+	var count int
+
+	err := p.conn(ctx).QueryRowx(query, id).Scan(&count)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, errPlaylistNotFound
+	} else if err != nil {
+		logger.Debug().Msgf("error locking the playlist. err: %s", err)
+		return 0, dbError(errUpdatePlaylist, err)
+	}
+
+	return count, nil
+}
+
+// UpdatePlaylist - update the name and the description of the playlist
+func (p *PlaylistRepository) UpdatePlaylist(ctx context.Context, req models.UpdatePlaylist) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'UpdatePlaylist' method")
+
+	query := fmt.Sprint(`UPDATE playlists SET name = $2, description = $3, updated_at = now() WHERE id = $1`)
+
+	commandTag, err := p.conn(ctx).Exec(query, req.Id, req.Name, req.Description)
+	if err != nil {
+		logger.Debug().Msgf("failed playlist update: %s", err)
+		return dbError(errUpdatePlaylist, err)
+	}
+
+	if rows, _ := commandTag.RowsAffected(); rows != 1 {
+		return errPlaylistNotFound
+	}
+
+	return nil
+}
+
+// TouchPlaylist - mark the playlist as updated after its entries changed
+func (p *PlaylistRepository) TouchPlaylist(ctx context.Context, id int) error {
+	if _, err := p.conn(ctx).Exec(`UPDATE playlists SET updated_at = now() WHERE id = $1`, id); err != nil {
+		return dbError(errUpdatePlaylist, err)
+	}
+
+	return nil
+}
+
+// DeletePlaylist - delete the playlist with its entries
+func (p *PlaylistRepository) DeletePlaylist(ctx context.Context, id int) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'DeletePlaylist' method")
+
+	commandTag, err := p.conn(ctx).Exec(`DELETE FROM playlists WHERE id = $1`, id)
+	if err != nil {
+		logger.Debug().Msgf("failed playlist delete: %s", err)
+		return dbError(errDeletePlaylist, err)
+	}
+
+	if rows, _ := commandTag.RowsAffected(); rows != 1 {
+		return errPlaylistNotFound
+	}
+
+	return nil
+}
+
+// InsertEntries - insert the songs starting at the position, moving the following entries down.
+// The playlist must be locked and the position must be between 1 and the number of entries + 1.
+func (p *PlaylistRepository) InsertEntries(ctx context.Context, id int, position int, songIds []int) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'InsertEntries' method")
+	logger.Debug().Msgf("postgres: insert songs %v into playlist %d at %d", songIds, id, position)
+
+	shiftQuery := fmt.Sprint(`UPDATE playlist_entries SET position = position + $3 WHERE playlist_id = $1 AND position >= $2`)
+
+	if _, err := p.conn(ctx).Exec(shiftQuery, id, position, len(songIds)); err != nil {
+		logger.Debug().Msgf("failed to shift playlist entries: %s", err)
+		return dbError(errUpdatePlaylist, err)
+	}
+
+	insertQuery := fmt.Sprint(`
+		INSERT INTO playlist_entries (playlist_id, position, song_id)
+		SELECT $1, $2 + t.n - 1, t.song_id FROM unnest($3::int[]) WITH ORDINALITY AS t(song_id, n)
+	`)
+
+	if _, err := p.conn(ctx).Exec(insertQuery, id, position, pq.Array(songIds)); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == codeForeignKeyViolation {
+			return errSongNotFound
+		}
+
+		logger.Debug().Msgf("error writing to the 'playlist_entries' table. err: %s", err)
+
+		return dbError(errUpdatePlaylist, err)
+	}
+
+	return nil
+}
+
+// RemoveEntry - remove the entry at the position, moving the following entries up
+func (p *PlaylistRepository) RemoveEntry(ctx context.Context, id int, position int) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'RemoveEntry' method")
+
+	commandTag, err := p.conn(ctx).Exec(`DELETE FROM playlist_entries WHERE playlist_id = $1 AND position = $2`, id, position)
+	if err != nil {
+		logger.Debug().Msgf("failed to remove playlist entry: %s", err)
+		return dbError(errUpdatePlaylist, err)
+	}
+
+	if rows, _ := commandTag.RowsAffected(); rows != 1 {
+		return errEntryNotFound
+	}
+
+	shiftQuery := fmt.Sprint(`UPDATE playlist_entries SET position = position - 1 WHERE playlist_id = $1 AND position > $2`)
+
+	if _, err = p.conn(ctx).Exec(shiftQuery, id, position); err != nil {
+		logger.Debug().Msgf("failed to shift playlist entries: %s", err)
+		return dbError(errUpdatePlaylist, err)
+	}
+
+	return nil
+}
+
+// MoveEntry - move the entry from one position to another, shifting the entries between them.
+// The playlist must be locked and both positions must exist.
+func (p *PlaylistRepository) MoveEntry(ctx context.Context, id int, from int, to int) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'MoveEntry' method")
+
+	query := fmt.Sprint(`
+		UPDATE playlist_entries
+		SET position = CASE WHEN position = $2 THEN $3 WHEN $2 < $3 THEN position - 1 ELSE position + 1 END
+		WHERE playlist_id = $1 AND position BETWEEN LEAST($2, $3)::int AND GREATEST($2, $3)::int
+	`)
+
+	if _, err := p.conn(ctx).Exec(query, id, from, to); err != nil {
+		logger.Debug().Msgf("failed to move playlist entry: %s", err)
+		return dbError(errUpdatePlaylist, err)
+	}
+
+	return nil
+}
+
+// DuplicatePlaylist - copy the playlist with its entries, empty name and owner are taken from the original
+func (p *PlaylistRepository) DuplicatePlaylist(ctx context.Context, req models.DuplicatePlaylist) (int, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'DuplicatePlaylist' method")
+
+	copyQuery := fmt.Sprint(`
+		INSERT INTO playlists (name, owner, description)
+		SELECT COALESCE(NULLIF($2, ''), name), COALESCE(NULLIF($3, ''), owner), description
+		FROM playlists WHERE id = $1
+		RETURNING id
+	`)
+
+	var newId int
+
+	err := p.conn(ctx).QueryRowx(copyQuery, req.Id, req.Name, req.Owner).Scan(&newId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, errPlaylistNotFound
+	} else if err != nil {
+		logger.Debug().Msgf("failed to copy the playlist: %s", err)
+		return 0, dbError(errCreatePlaylist, err)
+	}
+
+	entriesQuery := fmt.Sprint(`
+		INSERT INTO playlist_entries (playlist_id, position, song_id)
+		SELECT $2, position, song_id FROM playlist_entries WHERE playlist_id = $1
+	`)
+
+	if _, err = p.conn(ctx).Exec(entriesQuery, req.Id, newId); err != nil {
+		logger.Debug().Msgf("failed to copy the playlist entries: %s", err)
+		return 0, dbError(errCreatePlaylist, err)
+	}
+
+	return newId, nil
+}
+
+// MatchSongs - find the library song of every track by group and title, ignoring case,
+// or by link when the track has no match. Unmatched tracks get song id 0.
+func (p *PlaylistRepository) MatchSongs(ctx context.Context, tracks []models.TrackRef) ([]int, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'MatchSongs' method")
+
+	groups := make([]string, 0, len(tracks))
+	titles := make([]string, 0, len(tracks))
+	links := make([]string, 0, len(tracks))
+
+	for _, track := range tracks {
+		groups = append(groups, track.Group)
+		titles = append(titles, track.Song)
+		links = append(links, track.Location)
+	}
+
+	query := fmt.Sprint(`
+		SELECT COALESCE(
+			(SELECT s.id FROM songs s
+				JOIN mgs ON mgs.song_id = s.id
+				JOIN music_group g ON g.id = mgs.group_id
+				WHERE lower(s.song_name) = lower(t.title) AND lower(g.group_name) = lower(t.group_name)
+				ORDER BY s.id LIMIT 1),
+			(SELECT s.id FROM songs s WHERE t.link <> '' AND s.link = t.link ORDER BY s.id LIMIT 1),
+			0
+		) AS song_id
+		FROM unnest($1::varchar[], $2::varchar[], $3::varchar[]) WITH ORDINALITY AS t(group_name, title, link, n)
+		ORDER BY t.n
+	`)
+
+	var songIds []int
+
+	if err := p.conn(ctx).Select(&songIds, query, pq.Array(groups), pq.Array(titles), pq.Array(links)); err != nil {
+		logger.Debug().Msgf("error matching playlist songs. err: %s", err)
+		return nil, dbError(errGetPlaylist, err)
+	}
+
+	return songIds, nil
+}
@@ -3,26 +3,47 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 
+	"github.com/Magic-Kot/effective-mobile/internal/apperror"
 	"github.com/Magic-Kot/effective-mobile/internal/models"
 	"github.com/Magic-Kot/effective-mobile/pkg/client/postg"
 	"github.com/Magic-Kot/effective-mobile/pkg/musicinfo"
 
+	"github.com/lib/pq"
 	"github.com/rs/zerolog"
 )
 
 var (
-	errTransaction  = errors.New("transaction error")
-	errSongNotFound = errors.New("song not found")
-	errCreateSong   = errors.New("failed to create song")
-	errGetAllSong   = errors.New("error getting all songs")
-	errGetSong      = errors.New("failed to get song")
-	errUpdateSong   = errors.New("failed to update song")
-	errDeleteSong   = errors.New("failed to delete song")
+	errSongNotFound  = apperror.NotFound("song_not_found", "song not found")
+	errCreateGroup   = apperror.Internal("create_group_failed", "failed to create music group")
+	errCreateSong    = apperror.Internal("create_song_failed", "failed to create song")
+	errGetAllSong    = apperror.Internal("get_songs_failed", "error getting all songs")
+	errInvalidFilter = apperror.Validation("invalid_filter", "invalid filter")
+	errInvalidSort   = apperror.Validation("invalid_sort", "invalid sort, use rating, plays or favorites with an optional - before it")
+	errGetSong       = apperror.Internal("get_song_failed", "failed to get song")
+	errUpdateSong    = apperror.Internal("update_song_failed", "failed to update song")
+	errDeleteSong    = apperror.Internal("delete_song_failed", "failed to delete song")
+	errExportSongs   = apperror.Internal("export_songs_failed", "error exporting songs")
 )
 
+// selectSongs - the songs with the primary group, the release date of the album if they have none
+// and the aggregates of the favorites, ratings and plays
+const selectSongs = `
+	SELECT s.id, COALESCE(g.group_name, '') AS group_song, s.song_name AS song,
+		COALESCE(NULLIF(s.release_date, ''), a.release_date, '') AS release_date, s.text, s.link,
+		s.album_id, s.track_number, COALESCE(st.rating, 0) AS rating, COALESCE(st.rating_count, 0) AS ratings,
+		COALESCE(st.play_count, 0) AS plays, COALESCE(st.favorite_count, 0) AS favorites, s.created_at, s.updated_at
+	FROM songs s
+	LEFT JOIN albums a ON a.id = s.album_id
+	LEFT JOIN song_stats st ON st.song_id = s.id
+	LEFT JOIN LATERAL (
+		SELECT mg.group_name FROM mgs JOIN music_group mg ON mg.id = mgs.group_id
+		WHERE mgs.song_id = s.id ORDER BY mgs.role = 'primary' DESC, mgs.id LIMIT 1
+	) g ON true`
+
 type SongRepository struct {
 	client postg.Client
 }
@@ -33,102 +54,424 @@ func NewSongRepository(client postg.Client) *SongRepository {
 	}
 }
 
-// AddSong - add a new song
-func (s *SongRepository) AddSong(ctx context.Context, req models.CreateSong, res musicinfo.SongDetail) (int, error) {
+// conn - the transaction started by the service, if there is one, otherwise the connection pool
+func (s *SongRepository) conn(ctx context.Context) postg.Querier {
+	return postg.QuerierFromContext(ctx, s.client)
+}
+
+// GetOrCreateGroup - get the music group id by name, creating the group if it does not exist
+func (s *SongRepository) GetOrCreateGroup(ctx context.Context, group string) (int, error) {
 	logger := zerolog.Ctx(ctx)
-	logger.Debug().Msg("accessing Postgres using the 'AddSong' method")
+	logger.Debug().Msg("accessing Postgres using the 'GetOrCreateGroup' method")
 
-	tx, err := s.client.Begin()
-	if err != nil {
-		logger.Debug().Msgf("transaction creation error. err: %s", err)
-		return 0, errTransaction
+	checkGroupQuery := fmt.Sprint(`SELECT id FROM music_group WHERE group_name = $1`)
+
+	var idGroup int
+
+	err := s.conn(ctx).QueryRowx(checkGroupQuery, group).Scan(&idGroup)
+	if err == nil {
+		return idGroup, nil
 	}
 
-	checkGroupQuery := fmt.Sprint(`SELECT id FROM music_group WHERE group_name = $1`)
+	if !errors.Is(err, sql.ErrNoRows) {
+		logger.Debug().Msgf("error getting a music group. err: %s", err)
+		return 0, dbError(errCreateGroup, err)
+	}
 
-	var idGroup, idSong int
+	logger.Debug().Msgf("music group not found, creating: %s", group)
 
-	rowGroup := s.client.QueryRowx(checkGroupQuery, req.Group).Scan(&idGroup)
+	addGroupQuery := fmt.Sprint(`
+		INSERT INTO music_group (group_name) VALUES ($1)
+		ON CONFLICT (group_name) DO UPDATE SET group_name = EXCLUDED.group_name
+		RETURNING id
+	`)
 
-	if errors.Is(rowGroup, sql.ErrNoRows) {
-		logger.Debug().Msgf("music group not found. err: %s", err)
+	if err = s.conn(ctx).QueryRowx(addGroupQuery, group).Scan(&idGroup); err != nil {
+		logger.Debug().Msgf("error writing to the 'music_group' table. err: %s", err)
+		return 0, dbError(errCreateGroup, err)
+	}
 
-		addGroupQuery := fmt.Sprint("INSERT INTO music_group (group_name) VALUES ($1) RETURNING id")
+	return idGroup, nil
+}
 
-		row := tx.QueryRow(addGroupQuery, req.Group)
-		if err = row.Scan(&idGroup); err != nil {
-			logger.Debug().Msgf("error writing to the 'music_group' table. err: %s", err)
+// AddSong - add a new song. A song of an album must be added in a transaction, the album stays locked
+// until it ends.
+func (s *SongRepository) AddSong(ctx context.Context, req models.CreateSong, res musicinfo.SongDetail) (int, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'AddSong' method")
 
-			tx.Rollback()
-			return 0, errTransaction
-		}
+	// a song added to an album without a track number becomes its last track
+	addSongQuery := fmt.Sprint(`
+		INSERT INTO songs (song_name, release_date, text, link, album_id, track_number)
+		VALUES ($1, $2, $3, $4, $5::int, CASE WHEN $5::int IS NULL THEN NULL
+			ELSE COALESCE($6::int, (SELECT COALESCE(MAX(track_number), 0) + 1 FROM songs WHERE album_id = $5::int)) END)
+		RETURNING id
+	`)
+
+	var albumId, trackNumber *int
+	if req.AlbumId != 0 {
+		albumId = &req.AlbumId
+	}
 
-		return 0, errSongNotFound
-	} else if rowGroup != nil {
-		logger.Debug().Msgf("error getting a music group. err: %s", err)
+	if req.TrackNumber != 0 {
+		trackNumber = &req.TrackNumber
+	}
 
-		tx.Rollback()
-		return 0, errTransaction
+	// the songs appended to the album at the same time wait for each other, so they get different track numbers
+	if albumId != nil && trackNumber == nil {
+		if err := lockAlbum(ctx, s.conn(ctx), *albumId); err != nil {
+			return 0, dbError(errCreateSong, err)
+		}
 	}
 
-	addSongQuery := fmt.Sprint("INSERT INTO songs (song_name, release_date, text, link) VALUES ($1, $2, $3, $4) RETURNING id")
+	var idSong int
 
-	rowSong := tx.QueryRow(addSongQuery, req.Song, res.ReleaseData, res.Text, res.Link)
-	if err = rowSong.Scan(&idSong); err != nil {
+	err := s.conn(ctx).QueryRowx(addSongQuery, req.Song, res.ReleaseData, res.Text, res.Link, albumId, trackNumber).Scan(&idSong)
+	if err != nil {
 		logger.Debug().Msgf("error writing to the 'songs' table. err: %s", err)
+		return 0, dbError(errCreateSong, err)
+	}
 
-		tx.Rollback()
-		return 0, errTransaction
+	if albumId != nil {
+		if err = checkTrackNumbers(s.conn(ctx)); err != nil {
+			logger.Debug().Msgf("the track number is taken. err: %s", err)
+			return 0, dbError(errCreateSong, err)
+		}
 	}
 
-	addMgsQuery := fmt.Sprint("INSERT INTO mgs (group_id, song_id) VALUES ($1, $2)")
-	_, err = tx.Exec(addMgsQuery, idGroup, idSong)
-	if err != nil {
+	return idSong, nil
+}
+
+// LinkSongGroup - credit the music group on the song with the role
+func (s *SongRepository) LinkSongGroup(ctx context.Context, groupId int, songId int, role string) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'LinkSongGroup' method")
+
+	addMgsQuery := fmt.Sprint("INSERT INTO mgs (group_id, song_id, role) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING")
+
+	if _, err := s.conn(ctx).Exec(addMgsQuery, groupId, songId, role); err != nil {
 		logger.Debug().Msgf("error writing to the 'mgs' table. err: %s", err)
+		return dbError(errCreateSong, err)
+	}
 
-		tx.Rollback()
-		return 0, errTransaction
+	return nil
+}
+
+// UnlinkSongGroups - remove all the credits of the song
+func (s *SongRepository) UnlinkSongGroups(ctx context.Context, songId int) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'UnlinkSongGroups' method")
+
+	if _, err := s.conn(ctx).Exec(`DELETE FROM mgs WHERE song_id = $1`, songId); err != nil {
+		logger.Debug().Msgf("error deleting from the 'mgs' table. err: %s", err)
+		return dbError(errUpdateSong, err)
+	}
+
+	return nil
+}
+
+// GetSongArtists - get the credited music groups of the songs, keyed by song id
+func (s *SongRepository) GetSongArtists(ctx context.Context, songIds []int) (map[int][]models.SongArtist, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'GetSongArtists' method")
+
+	query := fmt.Sprint(`
+		SELECT mgs.song_id, g.group_name, mgs.role
+		FROM mgs
+		JOIN music_group g ON g.id = mgs.group_id
+		WHERE mgs.song_id = ANY($1::int[])
+		ORDER BY mgs.song_id, mgs.id
+	`)
+
+	var rows []struct {
+		SongId int `db:"song_id"`
+		models.SongArtist
+	}
+
+	if err := s.conn(ctx).Select(&rows, query, pq.Array(songIds)); err != nil {
+		logger.Debug().Msgf("error getting song artists. err: %s", err)
+		return nil, dbError(errGetAllSong, err)
+	}
+
+	artists := make(map[int][]models.SongArtist, len(songIds))
+	for _, row := range rows {
+		artists[row.SongId] = append(artists[row.SongId], row.SongArtist)
+	}
+
+	return artists, nil
+}
+
+// FindSong - get the id of the song of the music group by name ignoring case, 0 if there is none
+func (s *SongRepository) FindSong(ctx context.Context, group string, song string) (int, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'FindSong' method")
+
+	query := fmt.Sprint(`
+		SELECT s.id FROM songs s
+		JOIN mgs ON mgs.song_id = s.id
+		JOIN music_group g ON g.id = mgs.group_id
+		WHERE lower(g.group_name) = lower($1) AND lower(s.song_name) = lower($2)
+		ORDER BY s.id
+		LIMIT 1
+	`)
+
+	var id int
+
+	err := s.conn(ctx).QueryRowx(query, group, song).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	} else if err != nil {
+		logger.Debug().Msgf("error finding the song. err: %s", err)
+		return 0, dbError(errGetSong, err)
 	}
 
-	return idSong, tx.Commit()
+	return id, nil
 }
 
-// GetAllSong - get all the songs
+// GetAllSong - get all the songs matching the filters
 func (s *SongRepository) GetAllSong(ctx context.Context, req models.RequestGetAll) ([]models.SongsResponse, error) {
 	logger := zerolog.Ctx(ctx)
 	logger.Debug().Msg("accessing Postgres using the 'GetAllSong' method")
-	logger.Debug().Msgf("postgres: get songs by id: %s, limit: %s", req.Id, req.Limit)
+	logger.Debug().Msgf("postgres: get songs by request: %+v", req)
 
-	var songs []models.SongsResponse
+	q, err := newSongsQuery(req)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := q.orderAfter(req.Sort, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	args := append(q.args, req.Limit)
+
+	query := fmt.Sprintf(`%s
+		WHERE %s
+		ORDER BY %s
+		LIMIT $%d
+	`, selectSongs, q.whereClause(), order, len(args))
 
-	query := fmt.Sprintf(`SELECT id, song_name, release_date, text, link FROM songs WHERE id > %s ORDER BY id LIMIT %s`, req.Id, req.Limit)
+	var songs []models.SongsResponse
 
-	err := s.client.Select(&songs, query)
+	err = s.conn(ctx).Select(&songs, query, args...)
 	if err != nil {
 		logger.Debug().Msgf("error getting all songs. err: %s", err)
-		return nil, errGetAllSong
+		return nil, dbError(errGetAllSong, err)
 	}
 
 	return songs, nil
 }
 
-// GetAllSongFilter - get all the songs using the filter
-func (s *SongRepository) GetAllSongFilter(ctx context.Context, req models.RequestGetAll) ([]models.SongsResponse, error) {
+// GetSong - get the song by id
+func (s *SongRepository) GetSong(ctx context.Context, id int) (models.SongsResponse, error) {
 	logger := zerolog.Ctx(ctx)
-	logger.Debug().Msg("accessing Postgres using the 'GetAllSongFilter' method")
-	logger.Debug().Msgf("postgres: get songs by id: %s, limit: %s, filter: %s, value: %s", req.Id, req.Limit, req.Filter, req.Value)
+	logger.Debug().Msg("accessing Postgres using the 'GetSong' method")
+
+	query := fmt.Sprintf(`%s
+		WHERE s.id = $1
+	`, selectSongs)
+
+	var song models.SongsResponse
+
+	err := s.conn(ctx).QueryRowx(query, id).StructScan(&song)
+	if errors.Is(err, sql.ErrNoRows) {
+		return song, errSongNotFound
+	} else if err != nil {
+		logger.Debug().Msgf("error getting the song. err: %s", err)
+		return song, dbError(errGetSong, err)
+	}
+
+	return song, nil
+}
+
+// SearchSongs - get the songs with the title, the primary group or the lyrics containing the text
+// ignoring case, the title matches come first
+func (s *SongRepository) SearchSongs(ctx context.Context, text string, limit int) ([]models.SongsResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'SearchSongs' method")
+	logger.Debug().Msgf("postgres: search songs by text: %s", text)
+
+	query := fmt.Sprintf(`%s
+		WHERE s.song_name ILIKE '%%' || $1 || '%%' OR g.group_name ILIKE '%%' || $1 || '%%' OR s.text ILIKE '%%' || $1 || '%%'
+		ORDER BY s.song_name ILIKE '%%' || $1 || '%%' DESC, s.id
+		LIMIT $2
+	`, selectSongs)
 
 	var songs []models.SongsResponse
 
-	query := fmt.Sprintf(`SELECT id, song_name, release_date, text, link FROM songs WHERE %s = $1 AND id > %s ORDER BY id LIMIT %s`, req.Filter, req.Id, req.Limit)
+	if err := s.conn(ctx).Select(&songs, query, text, limit); err != nil {
+		logger.Debug().Msgf("error searching songs. err: %s", err)
+		return nil, dbError(errGetAllSong, err)
+	}
+
+	return songs, nil
+}
 
-	err := s.client.Select(&songs, query, req.Value)
+// ExportSongs - pass every song matching the filters to fn in id order. The rows are read from the
+// database as fn consumes them, so the whole library is never held in memory.
+func (s *SongRepository) ExportSongs(ctx context.Context, req models.RequestGetAll, fn func(song models.ExportSong) error) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'ExportSongs' method")
+	logger.Debug().Msgf("postgres: export songs by request: %+v", req)
+
+	q, err := newSongsQuery(req)
 	if err != nil {
-		logger.Debug().Msgf("error getting all songs. err: %s", err)
-		return nil, errGetAllSong
+		return err
 	}
 
-	return songs, nil
+	query := fmt.Sprintf(`
+		SELECT s.id, COALESCE(g.group_name, '') AS group_song, s.song_name,
+			COALESCE(NULLIF(s.release_date, ''), a.release_date, '') AS release_date, s.text, s.link,
+			s.album_id, s.track_number, s.created_at, s.updated_at,
+			COALESCE((
+				SELECT json_agg(json_build_object('group', mg.group_name, 'role', mgs.role) ORDER BY mgs.id)
+				FROM mgs JOIN music_group mg ON mg.id = mgs.group_id WHERE mgs.song_id = s.id
+			), '[]') AS artists,
+			ARRAY(SELECT gn.name FROM song_genres sg JOIN genres gn ON gn.id = sg.genre_id
+				WHERE sg.song_id = s.id ORDER BY gn.name) AS genres,
+			ARRAY(SELECT t.name FROM song_tags st JOIN tags t ON t.id = st.tag_id
+				WHERE st.song_id = s.id ORDER BY t.name) AS tags
+		FROM songs s
+		LEFT JOIN albums a ON a.id = s.album_id
+		LEFT JOIN LATERAL (
+			SELECT mg.group_name FROM mgs JOIN music_group mg ON mg.id = mgs.group_id
+			WHERE mgs.song_id = s.id ORDER BY mgs.role = 'primary' DESC, mgs.id LIMIT 1
+		) g ON true
+		WHERE %s
+		ORDER BY s.id
+	`, q.whereClause())
+
+	rows, err := s.conn(ctx).Queryx(query, q.args...)
+	if err != nil {
+		logger.Debug().Msgf("error exporting songs. err: %s", err)
+		return dbError(errExportSongs, err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			song    models.ExportSong
+			artists []byte
+			genres  pq.StringArray
+			tags    pq.StringArray
+		)
+
+		err = rows.Scan(&song.Id, &song.Group, &song.Song, &song.ReleaseDate, &song.Text, &song.Link,
+			&song.AlbumId, &song.TrackNumber, &song.CreatedAt, &song.UpdatedAt, &artists, &genres, &tags)
+		if err != nil {
+			logger.Debug().Msgf("error reading the exported song. err: %s", err)
+			return dbError(errExportSongs, err)
+		}
+
+		if err = json.Unmarshal(artists, &song.Artists); err != nil {
+			return dbError(errExportSongs, err)
+		}
+
+		song.Genres = genres
+		song.Tags = tags
+
+		if err = fn(song); err != nil {
+			return err
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		logger.Debug().Msgf("error exporting songs. err: %s", err)
+		return dbError(errExportSongs, err)
+	}
+
+	return nil
+}
+
+// GetSongFacets - count the genres and tags of all the songs matching the filters, ignoring paging
+func (s *SongRepository) GetSongFacets(ctx context.Context, req models.RequestGetAll) (models.SongFacets, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'GetSongFacets' method")
+
+	var facets models.SongFacets
+
+	q, err := newSongsQuery(req)
+	if err != nil {
+		return facets, err
+	}
+
+	query := fmt.Sprintf(`
+		WITH matched AS (SELECT s.id FROM songs s WHERE %s)
+		SELECT 'genre' AS kind, gn.name, COUNT(*) AS count
+		FROM matched m JOIN song_genres sg ON sg.song_id = m.id JOIN genres gn ON gn.id = sg.genre_id
+		GROUP BY gn.name
+		UNION ALL
+		SELECT 'tag' AS kind, t.name, COUNT(*) AS count
+		FROM matched m JOIN song_tags st ON st.song_id = m.id JOIN tags t ON t.id = st.tag_id
+		GROUP BY t.name
+		ORDER BY count DESC, name
+	`, q.whereClause())
+
+	var rows []struct {
+		Kind string `db:"kind"`
+		models.FacetCount
+	}
+
+	if err = s.conn(ctx).Select(&rows, query, q.args...); err != nil {
+		logger.Debug().Msgf("error getting song facets. err: %s", err)
+		return facets, dbError(errGetAllSong, err)
+	}
+
+	facets.Genres = make([]models.FacetCount, 0)
+	facets.Tags = make([]models.FacetCount, 0)
+
+	for _, row := range rows {
+		if row.Kind == models.KindGenre {
+			facets.Genres = append(facets.Genres, row.FacetCount)
+		} else {
+			facets.Tags = append(facets.Tags, row.FacetCount)
+		}
+	}
+
+	return facets, nil
+}
+
+// GetSongLabels - get the genres and tags of the songs, keyed by song id
+func (s *SongRepository) GetSongLabels(ctx context.Context, songIds []int) (map[int][]string, map[int][]string, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("accessing Postgres using the 'GetSongLabels' method")
+
+	query := fmt.Sprint(`
+		SELECT 'genre' AS kind, sg.song_id, gn.name
+		FROM song_genres sg JOIN genres gn ON gn.id = sg.genre_id
+		WHERE sg.song_id = ANY($1::int[])
+		UNION ALL
+		SELECT 'tag' AS kind, st.song_id, t.name
+		FROM song_tags st JOIN tags t ON t.id = st.tag_id
+		WHERE st.song_id = ANY($1::int[])
+		ORDER BY name
+	`)
+
+	var rows []struct {
+		Kind   string `db:"kind"`
+		SongId int    `db:"song_id"`
+		Name   string `db:"name"`
+	}
+
+	if err := s.conn(ctx).Select(&rows, query, pq.Array(songIds)); err != nil {
+		logger.Debug().Msgf("error getting song labels. err: %s", err)
+		return nil, nil, dbError(errGetAllSong, err)
+	}
+
+	genres := make(map[int][]string)
+	tags := make(map[int][]string)
+
+	for _, row := range rows {
+		if row.Kind == models.KindGenre {
+			genres[row.SongId] = append(genres[row.SongId], row.Name)
+		} else {
+			tags[row.SongId] = append(tags[row.SongId], row.Name)
+		}
+	}
+
+	return genres, tags, nil
 }
 
 // GetLyricsSong - get the lyrics by id
@@ -141,7 +484,7 @@ func (s *SongRepository) GetLyricsSong(ctx context.Context, id string) (string,
 
 	var lyrics string
 
-	err := s.client.QueryRowx(query, id).Scan(&lyrics)
+	err := s.conn(ctx).QueryRowx(query, id).Scan(&lyrics)
 
 	if errors.Is(err, sql.ErrNoRows) {
 		fmt.Println(err)
@@ -162,13 +505,13 @@ func (s *SongRepository) UpdateSong(ctx context.Context, value string, arg []int
 	logger.Debug().Msg("accessing Postgres using the 'UpdateSong' method")
 	logger.Debug().Msgf("postgres: update table by value: %s, arg: %v", value, arg)
 
-	q := fmt.Sprintf(`UPDATE songs SET %s WHERE id = $1`, value)
+	q := fmt.Sprintf(`UPDATE songs SET %s, updated_at = now() WHERE id = $1`, value)
 
-	commandTag, err := s.client.Exec(q, arg...)
+	commandTag, err := s.conn(ctx).Exec(q, arg...)
 
 	if err != nil {
 		logger.Debug().Msgf("failed table updates: %s", err)
-		return errUpdateSong
+		return dbError(errUpdateSong, err)
 	}
 
 	if str, _ := commandTag.RowsAffected(); str != 1 {
@@ -179,20 +522,42 @@ func (s *SongRepository) UpdateSong(ctx context.Context, value string, arg []int
 	return nil
 }
 
-// DeleteSong - delete a song from the library
+// DeleteSong - delete a song from the library and from every playlist holding it.
+// The remaining playlist entries are renumbered, so it must run in a transaction.
 func (s *SongRepository) DeleteSong(ctx context.Context, id int) error {
 	logger := zerolog.Ctx(ctx)
 	logger.Debug().Msg("accessing Postgres using the 'DeleteSong' method")
 
+	playlistsQuery := `
+		WITH removed AS (
+			DELETE FROM playlist_entries WHERE song_id = $1 RETURNING playlist_id
+		), renumbered AS (
+			UPDATE playlist_entries e SET position = r.position
+			FROM (
+				SELECT id, ROW_NUMBER() OVER (PARTITION BY playlist_id ORDER BY position) AS position
+				FROM playlist_entries
+				WHERE playlist_id IN (SELECT playlist_id FROM removed) AND song_id <> $1
+			) r
+			WHERE e.id = r.id AND e.position <> r.position
+		)
+		UPDATE playlists SET updated_at = now() WHERE id IN (SELECT playlist_id FROM removed)
+	`
+
+	if _, err := s.conn(ctx).Exec(playlistsQuery, id); err != nil {
+		logger.Debug().Msgf("failed to remove the song from playlists: %s", err)
+		return dbError(errDeleteSong, err)
+	}
+
 	q := `
 		DELETE FROM songs
 		WHERE id = $1
 	`
 
-	commandTag, err := s.client.Exec(q, id)
+	commandTag, err := s.conn(ctx).Exec(q, id)
 
 	if err != nil {
-		return errDeleteSong
+		logger.Debug().Msgf("failed to delete the song: %s", err)
+		return dbError(errDeleteSong, err)
 	}
 
 	if str, _ := commandTag.RowsAffected(); str != 1 {
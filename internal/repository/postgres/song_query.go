@@ -0,0 +1,147 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// songFilterColumns - song columns that can be passed in the 'filter' parameter
+var songFilterColumns = map[string]string{
+	"song":         "s.song_name",
+	"song_name":    "s.song_name",
+	"release_date": "s.release_date",
+	"text":         "s.text",
+	"link":         "s.link",
+	"album_id":     "s.album_id",
+}
+
+// songSortColumns - the aggregates the song listing can be sorted by, see orderAfter
+var songSortColumns = map[string]string{
+	"rating":    "COALESCE(st.rating, 0)",
+	"plays":     "COALESCE(st.play_count, 0)",
+	"favorites": "COALESCE(st.favorite_count, 0)",
+}
+
+// songsQuery - the conditions of the song listing with their positional arguments
+type songsQuery struct {
+	where []string
+	args  []interface{}
+}
+
+// add - add a condition, '%[n]d' in it is replaced by the placeholder number of the n-th argument
+func (q *songsQuery) add(condition string, args ...interface{}) {
+	numbers := make([]interface{}, 0, len(args))
+
+	for _, arg := range args {
+		q.args = append(q.args, arg)
+		numbers = append(numbers, len(q.args))
+	}
+
+	q.where = append(q.where, fmt.Sprintf(condition, numbers...))
+}
+
+// whereClause - the conditions joined with AND
+func (q *songsQuery) whereClause() string {
+	if len(q.where) == 0 {
+		return "TRUE"
+	}
+
+	return strings.Join(q.where, " AND ")
+}
+
+// orderAfter - add the condition selecting the songs after the song with the id and return the order.
+// The songs are in id order, or sorted by the aggregate with the id breaking ties; a '-' before the
+// name of the aggregate sorts in descending order. The page after a song starts at its current
+// aggregate, so the cursor stays the id of the last song of the previous page.
+func (q *songsQuery) orderAfter(sort string, after string) (string, error) {
+	if sort == "" || sort == "id" {
+		q.add("s.id > $%d", after)
+		return "s.id", nil
+	}
+
+	comparison, direction := ">", "ASC"
+	if strings.HasPrefix(sort, "-") {
+		sort = sort[1:]
+		comparison, direction = "<", "DESC"
+	}
+
+	column, ok := songSortColumns[sort]
+	if !ok {
+		return "", errInvalidSort
+	}
+
+	if after != "0" {
+		q.add(fmt.Sprintf(`(%[1]s, s.id) %[2]s (
+			SELECT %[1]s, s.id FROM songs s LEFT JOIN song_stats st ON st.song_id = s.id WHERE s.id = $%%d
+		)`, column, comparison), after)
+	}
+
+	return fmt.Sprintf("%[1]s %[2]s, s.id %[2]s", column, direction), nil
+}
+
+func newSongsQuery(req models.RequestGetAll) (*songsQuery, error) {
+	q := &songsQuery{}
+
+	if req.Filter != "" && req.Value != "" {
+		switch req.Filter {
+		case "group", "group_song":
+			q.add(`EXISTS (SELECT 1 FROM mgs JOIN music_group mg ON mg.id = mgs.group_id
+				WHERE mgs.song_id = s.id AND mg.group_name = $%d)`, req.Value)
+		default:
+			column, ok := songFilterColumns[req.Filter]
+			if !ok {
+				return nil, errInvalidFilter
+			}
+
+			q.add(column+" = $%d", req.Value)
+		}
+	}
+
+	if req.Artist != "" || req.Role != "" {
+		q.add(`EXISTS (SELECT 1 FROM mgs JOIN music_group mg ON mg.id = mgs.group_id
+			WHERE mgs.song_id = s.id AND ($%[1]d = '' OR mg.group_name = $%[1]d) AND ($%[2]d = '' OR mgs.role = $%[2]d))`,
+			req.Artist, req.Role)
+	}
+
+	if req.Genre != "" {
+		q.add(`EXISTS (SELECT 1 FROM song_genres sg JOIN genres gn ON gn.id = sg.genre_id
+			WHERE sg.song_id = s.id AND gn.name = ANY($%d::varchar[]))`, pq.Array(splitValues(req.Genre)))
+	}
+
+	if req.Tag != "" {
+		q.add(`EXISTS (SELECT 1 FROM song_tags st JOIN tags t ON t.id = st.tag_id
+			WHERE st.song_id = s.id AND t.name = ANY($%d::varchar[]))`, pq.Array(splitValues(req.Tag)))
+	}
+
+	if req.CreatedAfter != "" {
+		q.add("s.created_at >= $%d", req.CreatedAfter)
+	}
+
+	if req.CreatedBefore != "" {
+		q.add("s.created_at < $%d", req.CreatedBefore)
+	}
+
+	if req.UpdatedAfter != "" {
+		q.add("s.updated_at >= $%d", req.UpdatedAfter)
+	}
+
+	if req.UpdatedBefore != "" {
+		q.add("s.updated_at < $%d", req.UpdatedBefore)
+	}
+
+	return q, nil
+}
+
+// splitValues - comma separated filter values, a song matching any of them is selected
+func splitValues(value string) []string {
+	values := strings.Split(value, ",")
+	for i := range values {
+		values[i] = strings.TrimSpace(values[i])
+	}
+
+	return values
+}
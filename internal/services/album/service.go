@@ -0,0 +1,145 @@
+package album
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/Magic-Kot/effective-mobile/internal/apperror"
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+	"github.com/Magic-Kot/effective-mobile/pkg/client/postg"
+
+	"github.com/rs/zerolog"
+)
+
+var errInvalidRequest = apperror.Validation("invalid_request", "invalid request")
+
+// maxLimit - the largest page of the album listing, the v1 one included
+const maxLimit = 1000
+
+type AlbumRepository interface {
+	CreateAlbum(ctx context.Context, groupId int, req models.CreateAlbum) (int, error)
+	GetAllAlbums(ctx context.Context, req models.RequestGetAllAlbums) ([]models.AlbumResponse, error)
+	GetAlbum(ctx context.Context, id int) (models.AlbumResponse, error)
+	GetAlbumTracks(ctx context.Context, id int) ([]models.AlbumTrack, error)
+	UpdateAlbum(ctx context.Context, req models.UpdateAlbum) error
+	DeleteAlbum(ctx context.Context, id int) error
+	SetAlbumTracks(ctx context.Context, id int, songIds []int) error
+}
+
+type GroupRepository interface {
+	GetOrCreateGroup(ctx context.Context, group string) (int, error)
+}
+
+type AlbumService struct {
+	AlbumRepository AlbumRepository
+	GroupRepository GroupRepository
+	Transactor      postg.Transactor
+}
+
+func NewAlbumService(albumRepository AlbumRepository, groupRepository GroupRepository, transactor postg.Transactor) *AlbumService {
+	return &AlbumService{
+		AlbumRepository: albumRepository,
+		GroupRepository: groupRepository,
+		Transactor:      transactor,
+	}
+}
+
+// CreateAlbum - add a new album, the music group is created if it does not exist
+func (s *AlbumService) CreateAlbum(ctx context.Context, req models.CreateAlbum) (int, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'CreateAlbum' service")
+
+	var id int
+
+	err := s.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		groupId, err := s.GroupRepository.GetOrCreateGroup(ctx, req.Group)
+		if err != nil {
+			return err
+		}
+
+		id, err = s.AlbumRepository.CreateAlbum(ctx, groupId, req)
+
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// GetAllAlbums - get all the albums
+func (s *AlbumService) GetAllAlbums(ctx context.Context, req models.RequestGetAllAlbums) ([]models.AlbumResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'GetAllAlbums' service")
+
+	if _, err := strconv.Atoi(req.Id); err != nil {
+		return nil, fmt.Errorf("%w: id must be a number", errInvalidRequest)
+	}
+
+	if limit, err := strconv.Atoi(req.Limit); err != nil || limit < 1 || limit > maxLimit {
+		return nil, fmt.Errorf("%w: limit must be a number from 1 to %d", errInvalidRequest, maxLimit)
+	}
+
+	return s.AlbumRepository.GetAllAlbums(ctx, req)
+}
+
+// GetAlbum - get the album with its ordered track list
+func (s *AlbumService) GetAlbum(ctx context.Context, id int) (models.AlbumResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'GetAlbum' service")
+
+	album, err := s.AlbumRepository.GetAlbum(ctx, id)
+	if err != nil {
+		return album, err
+	}
+
+	album.Tracks, err = s.AlbumRepository.GetAlbumTracks(ctx, id)
+	if err != nil {
+		return album, err
+	}
+
+	return album, nil
+}
+
+// UpdateAlbum - update information about the album
+func (s *AlbumService) UpdateAlbum(ctx context.Context, req models.UpdateAlbum) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'UpdateAlbum' service")
+
+	return s.AlbumRepository.UpdateAlbum(ctx, req)
+}
+
+// DeleteAlbum - delete the album, its songs stay in the library
+func (s *AlbumService) DeleteAlbum(ctx context.Context, id int) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'DeleteAlbum' service")
+
+	return s.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		return s.AlbumRepository.DeleteAlbum(ctx, id)
+	})
+}
+
+// SetAlbumTracks - replace the ordered track list of the album
+func (s *AlbumService) SetAlbumTracks(ctx context.Context, req models.AlbumTracksRequest) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'SetAlbumTracks' service")
+
+	seen := make(map[int]struct{}, len(req.Tracks))
+	for _, songId := range req.Tracks {
+		if _, ok := seen[songId]; ok {
+			return fmt.Errorf("%w: song %d is listed twice", errInvalidRequest, songId)
+		}
+
+		seen[songId] = struct{}{}
+	}
+
+	return s.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := s.AlbumRepository.GetAlbum(ctx, req.Id); err != nil {
+			return err
+		}
+
+		return s.AlbumRepository.SetAlbumTracks(ctx, req.Id, req.Tracks)
+	})
+}
@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+)
+
+type principalKey struct{}
+
+// WithPrincipal - pass the authenticated caller to the handlers and the services
+func WithPrincipal(ctx context.Context, principal models.Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFrom - the authenticated caller of the request, false for the public routes
+func PrincipalFrom(ctx context.Context) (models.Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(models.Principal)
+	return principal, ok
+}
+
+// UserId - the user of the favorites, ratings and plays: the subject of the bearer token, or the API key
+func UserId(principal models.Principal) string {
+	if principal.Subject != "" {
+		return "sub:" + principal.Subject
+	}
+
+	return "key:" + strconv.Itoa(principal.KeyId)
+}
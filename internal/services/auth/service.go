@@ -0,0 +1,174 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/Magic-Kot/effective-mobile/internal/apperror"
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	// keyPrefix - marks the API keys of the library, so a leaked one is easy to recognize
+	keyPrefix = "sl_"
+	// keyBytes - the random bytes of a key
+	keyBytes = 32
+	// shownPrefix - the first characters of the key kept in clear to tell the keys apart
+	shownPrefix = len(keyPrefix) + 8
+)
+
+var (
+	errMissingCredentials = apperror.Unauthorized("missing_credentials", "an API key or a bearer token is required")
+	errInvalidApiKey      = apperror.Unauthorized("invalid_api_key", "the API key is invalid or revoked")
+	errForbidden          = apperror.Forbidden("forbidden", "the role of the API key does not allow the request")
+	errGenerateKey        = apperror.Internal("generate_api_key_failed", "failed to generate API key")
+)
+
+// roleRanks - each role allows everything the roles of a lower rank do
+var roleRanks = map[string]int{
+	models.RoleReader: 1,
+	models.RoleEditor: 2,
+	models.RoleAdmin:  3,
+}
+
+type ApiKeyRepository interface {
+	CreateApiKey(ctx context.Context, req models.CreateApiKey, prefix string, hash string) (models.ApiKeyResponse, error)
+	GetApiKeyByHash(ctx context.Context, hash string) (models.ApiKeyResponse, error)
+	GetAllApiKeys(ctx context.Context) ([]models.ApiKeyResponse, error)
+	RevokeApiKey(ctx context.Context, id int) error
+	AddWrite(ctx context.Context, write models.ApiKeyWrite) error
+	GetApiKeyWrites(ctx context.Context, req models.RequestGetApiKeyWrites) ([]models.ApiKeyWrite, error)
+}
+
+type AuthService struct {
+	ApiKeyRepository ApiKeyRepository
+	// Tokens - nil if the bearer tokens are not accepted
+	Tokens *TokenVerifier
+}
+
+func NewAuthService(apiKeyRepository ApiKeyRepository, tokens *TokenVerifier) *AuthService {
+	return &AuthService{
+		ApiKeyRepository: apiKeyRepository,
+		Tokens:           tokens,
+	}
+}
+
+// IssueApiKey - create a key with the role, the response is the only place the key is shown
+func (s *AuthService) IssueApiKey(ctx context.Context, req models.CreateApiKey) (models.ApiKeyResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'IssueApiKey' service")
+
+	random := make([]byte, keyBytes)
+	if _, err := rand.Read(random); err != nil {
+		return models.ApiKeyResponse{}, fmt.Errorf("%w: %w", errGenerateKey, err)
+	}
+
+	key := keyPrefix + base64.RawURLEncoding.EncodeToString(random)
+
+	res, err := s.ApiKeyRepository.CreateApiKey(ctx, req, key[:shownPrefix], hashKey(key))
+	if err != nil {
+		return res, err
+	}
+
+	res.Key = key
+
+	return res, nil
+}
+
+// Authenticate - the caller owning the key, an unknown or revoked key is rejected
+func (s *AuthService) Authenticate(ctx context.Context, key string) (models.Principal, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'Authenticate' service")
+
+	if key == "" {
+		return models.Principal{}, errMissingCredentials
+	}
+
+	if !strings.HasPrefix(key, keyPrefix) {
+		return models.Principal{}, errInvalidApiKey
+	}
+
+	res, err := s.ApiKeyRepository.GetApiKeyByHash(ctx, hashKey(key))
+	if apperror.From(err).Kind == apperror.KindNotFound {
+		return models.Principal{}, errInvalidApiKey
+	} else if err != nil {
+		return models.Principal{}, err
+	}
+
+	return models.Principal{KeyId: res.Id, Name: res.Name, Role: res.Role}, nil
+}
+
+// AuthenticateToken - the caller the bearer token is issued to
+func (s *AuthService) AuthenticateToken(ctx context.Context, token string) (models.Principal, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'AuthenticateToken' service")
+
+	if s.Tokens == nil {
+		return models.Principal{}, errBearerDisabled
+	}
+
+	if token == "" {
+		return models.Principal{}, errMissingCredentials
+	}
+
+	return s.Tokens.Verify(ctx, token)
+}
+
+// Authorize - check the role of the caller of the request allows what needs the role
+func Authorize(ctx context.Context, role string) error {
+	principal, ok := PrincipalFrom(ctx)
+	if !ok {
+		return errMissingCredentials
+	}
+
+	if roleRanks[principal.Role] < roleRanks[role] {
+		return errForbidden
+	}
+
+	return nil
+}
+
+// GetAllApiKeys - get all the keys without their secrets
+func (s *AuthService) GetAllApiKeys(ctx context.Context) ([]models.ApiKeyResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'GetAllApiKeys' service")
+
+	return s.ApiKeyRepository.GetAllApiKeys(ctx)
+}
+
+// RevokeApiKey - the key is rejected from now on
+func (s *AuthService) RevokeApiKey(ctx context.Context, id int) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'RevokeApiKey' service")
+
+	return s.ApiKeyRepository.RevokeApiKey(ctx, id)
+}
+
+// RecordWrite - attribute the write to the key or the subject of the token it was made with
+func (s *AuthService) RecordWrite(ctx context.Context, write models.ApiKeyWrite) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'RecordWrite' service")
+
+	return s.ApiKeyRepository.AddWrite(ctx, write)
+}
+
+// GetApiKeyWrites - a page of the writes made with the key, or with the tokens of the subject
+func (s *AuthService) GetApiKeyWrites(ctx context.Context, req models.RequestGetApiKeyWrites) ([]models.ApiKeyWrite, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'GetApiKeyWrites' service")
+
+	return s.ApiKeyRepository.GetApiKeyWrites(ctx, req)
+}
+
+// hashKey - the keys are long and random, so a fast hash is enough and lets the key be looked up by it
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
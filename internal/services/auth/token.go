@@ -0,0 +1,262 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Magic-Kot/effective-mobile/internal/apperror"
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	defaultRoleClaim = "roles"
+	// staticIssuer - the issuer of the tokens signed with the static key by IssueToken
+	staticIssuer = "songlib"
+)
+
+var (
+	errInvalidToken     = apperror.Unauthorized("invalid_token", "the bearer token is invalid or expired")
+	errBearerDisabled   = apperror.Unauthorized("bearer_not_accepted", "bearer tokens are not accepted, use an API key")
+	errNoRole           = apperror.Forbidden("no_role", "the token grants no role of the library")
+	errStaticKeyMissing = errors.New("the static key is not configured")
+)
+
+// asymmetricMethods - the algorithms of the keys of a key set, the HMAC ones need the static key
+var asymmetricMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// KeySet - the public keys of the identity provider by key id, see jwks.KeySet
+type KeySet interface {
+	Key(ctx context.Context, kid string) (crypto.PublicKey, error)
+}
+
+type TokenDeps struct {
+	// Keys - the keys of the identity provider, nil if only the static key is used
+	Keys KeySet
+	// StaticKey - the HMAC secret of the tokens of the local tests, empty in production
+	StaticKey []byte
+	// Issuer, Audience - the iss and aud claims the tokens must have, not checked if empty
+	Issuer   string
+	Audience string
+	// RoleClaim - the claim with the roles or groups of the subject, a dotted path for a nested one
+	RoleClaim string
+	// RoleMap - the library role of each value of the claim, the values are the roles themselves if empty
+	RoleMap map[string]string
+	// Leeway - the clock difference allowed checking exp and nbf
+	Leeway time.Duration
+}
+
+// TokenVerifier - checks the bearer tokens issued by the SSO and maps their claims to the roles
+type TokenVerifier struct {
+	deps    TokenDeps
+	methods []string
+}
+
+func NewTokenVerifier(deps TokenDeps) *TokenVerifier {
+	if deps.RoleClaim == "" {
+		deps.RoleClaim = defaultRoleClaim
+	}
+
+	// not nil, so a verifier without keys accepts no algorithm at all
+	methods := []string{}
+	if deps.Keys != nil {
+		methods = append(methods, asymmetricMethods...)
+	}
+
+	if len(deps.StaticKey) > 0 {
+		methods = append(methods, "HS256", "HS384", "HS512")
+	}
+
+	return &TokenVerifier{
+		deps:    deps,
+		methods: methods,
+	}
+}
+
+// Verify - the caller the token is issued to, with the highest role its claim is mapped to
+func (v *TokenVerifier) Verify(ctx context.Context, token string) (models.Principal, error) {
+	parser := jwt.Parser{ValidMethods: v.methods, SkipClaimsValidation: true}
+	claims := jwt.MapClaims{}
+
+	_, err := parser.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		return v.key(ctx, t)
+	})
+	if err != nil {
+		return models.Principal{}, fmt.Errorf("%w: %w", errInvalidToken, err)
+	}
+
+	if err = v.validate(claims); err != nil {
+		return models.Principal{}, fmt.Errorf("%w: %w", errInvalidToken, err)
+	}
+
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		return models.Principal{}, fmt.Errorf("%w: the sub claim is required", errInvalidToken)
+	}
+
+	role := v.role(claims)
+	if role == "" {
+		return models.Principal{}, errNoRole
+	}
+
+	name, _ := claims["preferred_username"].(string)
+	if name == "" {
+		name = subject
+	}
+
+	return models.Principal{Subject: subject, Name: name, Role: role}, nil
+}
+
+// key - the key of the algorithm of the token, so a public key is never taken as an HMAC secret
+func (v *TokenVerifier) key(ctx context.Context, t *jwt.Token) (interface{}, error) {
+	if _, ok := t.Method.(*jwt.SigningMethodHMAC); ok {
+		return v.deps.StaticKey, nil
+	}
+
+	kid, _ := t.Header["kid"].(string)
+
+	if v.deps.Keys == nil {
+		return nil, errors.New("no key set is configured")
+	}
+
+	key, err := v.deps.Keys.Key(ctx, kid)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t.Method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		if _, ok := key.(*rsa.PublicKey); ok {
+			return key, nil
+		}
+	case *jwt.SigningMethodECDSA:
+		if _, ok := key.(*ecdsa.PublicKey); ok {
+			return key, nil
+		}
+	}
+
+	return nil, fmt.Errorf("the key %q does not match the algorithm %s", kid, t.Method.Alg())
+}
+
+func (v *TokenVerifier) validate(claims jwt.MapClaims) error {
+	now := time.Now()
+
+	if !claims.VerifyExpiresAt(now.Add(-v.deps.Leeway).Unix(), true) {
+		return errors.New("the token is expired or has no exp claim")
+	}
+
+	if !claims.VerifyNotBefore(now.Add(v.deps.Leeway).Unix(), false) {
+		return errors.New("the token is not valid yet")
+	}
+
+	if v.deps.Issuer != "" && !claims.VerifyIssuer(v.deps.Issuer, true) {
+		return errors.New("unexpected issuer")
+	}
+
+	if v.deps.Audience != "" && !claims.VerifyAudience(v.deps.Audience, true) {
+		return errors.New("unexpected audience")
+	}
+
+	return nil
+}
+
+// role - the highest library role of the values of the role claim, empty if there is none
+func (v *TokenVerifier) role(claims jwt.MapClaims) string {
+	var res string
+
+	for _, value := range claimValues(claims, v.deps.RoleClaim) {
+		role := value
+		if len(v.deps.RoleMap) > 0 {
+			role = v.deps.RoleMap[value]
+		}
+
+		if roleRanks[role] > roleRanks[res] {
+			res = role
+		}
+	}
+
+	return res
+}
+
+// IssueToken - a token of the subject signed with the static key, for the local tests
+func (v *TokenVerifier) IssueToken(subject string, roles []string, ttl time.Duration) (string, error) {
+	if len(v.deps.StaticKey) == 0 {
+		return "", errStaticKeyMissing
+	}
+
+	now := time.Now()
+
+	claims := jwt.MapClaims{
+		"sub": subject,
+		"iat": now.Unix(),
+		"exp": now.Add(ttl).Unix(),
+		"iss": staticIssuer,
+	}
+
+	if v.deps.Issuer != "" {
+		claims["iss"] = v.deps.Issuer
+	}
+
+	if v.deps.Audience != "" {
+		claims["aud"] = v.deps.Audience
+	}
+
+	setClaim(claims, v.deps.RoleClaim, roles)
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(v.deps.StaticKey)
+}
+
+// claimValues - the string or the strings of the claim at the dotted path
+func claimValues(claims jwt.MapClaims, path string) []string {
+	var value interface{} = map[string]interface{}(claims)
+
+	for _, name := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+
+		value = object[name]
+	}
+
+	switch value := value.(type) {
+	case string:
+		return strings.Fields(value)
+	case []interface{}:
+		res := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				res = append(res, s)
+			}
+		}
+
+		return res
+	}
+
+	return nil
+}
+
+// setClaim - the claimValues of the roles
+func setClaim(claims jwt.MapClaims, path string, roles []string) {
+	names := strings.Split(path, ".")
+	object := map[string]interface{}(claims)
+
+	for _, name := range names[:len(names)-1] {
+		nested, ok := object[name].(map[string]interface{})
+		if !ok {
+			nested = make(map[string]interface{})
+			object[name] = nested
+		}
+
+		object = nested
+	}
+
+	object[names[len(names)-1]] = roles
+}
@@ -0,0 +1,347 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Magic-Kot/effective-mobile/internal/apperror"
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// testKeys - the key set of the identity provider
+type testKeys map[string]crypto.PublicKey
+
+func (k testKeys) Key(_ context.Context, kid string) (crypto.PublicKey, error) {
+	key, ok := k[kid]
+	if !ok {
+		return nil, fmt.Errorf("key %q not found", kid)
+	}
+
+	return key, nil
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, key interface{}, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	return signed
+}
+
+func TestVerify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa key: %v", err)
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ec key: %v", err)
+	}
+
+	rsaPublic, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatalf("marshal rsa key: %v", err)
+	}
+
+	keys := testKeys{"rsa": &rsaKey.PublicKey, "ec": &ecKey.PublicKey}
+	staticKey := []byte("the static key of the tests")
+	now := time.Now()
+
+	// claims - a valid token of an editor, changed by the edits
+	claims := func(edits ...func(jwt.MapClaims)) jwt.MapClaims {
+		c := jwt.MapClaims{
+			"sub":   "user-1",
+			"exp":   now.Add(time.Hour).Unix(),
+			"iss":   "https://sso.example.com",
+			"aud":   "songlib",
+			"roles": []string{models.RoleEditor},
+		}
+
+		for _, edit := range edits {
+			edit(c)
+		}
+
+		return c
+	}
+
+	set := func(name string, value interface{}) func(jwt.MapClaims) {
+		return func(c jwt.MapClaims) { c[name] = value }
+	}
+
+	unset := func(name string) func(jwt.MapClaims) {
+		return func(c jwt.MapClaims) { delete(c, name) }
+	}
+
+	deps := TokenDeps{
+		Keys:     keys,
+		Issuer:   "https://sso.example.com",
+		Audience: "songlib",
+		Leeway:   30 * time.Second,
+	}
+
+	withDeps := func(edit func(*TokenDeps)) TokenDeps {
+		d := deps
+		edit(&d)
+
+		return d
+	}
+
+	tests := []struct {
+		name     string
+		deps     TokenDeps
+		token    string
+		wantRole string
+		wantKind apperror.Kind
+	}{
+		{
+			name:     "rsa",
+			deps:     deps,
+			token:    sign(t, jwt.SigningMethodRS256, rsaKey, "rsa", claims()),
+			wantRole: models.RoleEditor,
+		},
+		{
+			name:     "rsa pss",
+			deps:     deps,
+			token:    sign(t, jwt.SigningMethodPS256, rsaKey, "rsa", claims()),
+			wantRole: models.RoleEditor,
+		},
+		{
+			name:     "ec",
+			deps:     deps,
+			token:    sign(t, jwt.SigningMethodES256, ecKey, "ec", claims()),
+			wantRole: models.RoleEditor,
+		},
+		{
+			name:     "hmac signed with the public rsa key",
+			deps:     deps,
+			token:    sign(t, jwt.SigningMethodHS256, rsaPublic, "rsa", claims()),
+			wantKind: apperror.KindUnauthorized,
+		},
+		{
+			name:     "hmac signed with the public rsa key, static key configured",
+			deps:     withDeps(func(d *TokenDeps) { d.StaticKey = staticKey }),
+			token:    sign(t, jwt.SigningMethodHS256, rsaPublic, "rsa", claims()),
+			wantKind: apperror.KindUnauthorized,
+		},
+		{
+			name:     "alg none",
+			deps:     deps,
+			token:    sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, "rsa", claims()),
+			wantKind: apperror.KindUnauthorized,
+		},
+		{
+			name:     "rsa algorithm with the ec key",
+			deps:     deps,
+			token:    sign(t, jwt.SigningMethodRS256, rsaKey, "ec", claims()),
+			wantKind: apperror.KindUnauthorized,
+		},
+		{
+			name:     "ec algorithm with the rsa key",
+			deps:     deps,
+			token:    sign(t, jwt.SigningMethodES256, ecKey, "rsa", claims()),
+			wantKind: apperror.KindUnauthorized,
+		},
+		{
+			name:     "unknown kid",
+			deps:     deps,
+			token:    sign(t, jwt.SigningMethodRS256, rsaKey, "rotated", claims()),
+			wantKind: apperror.KindUnauthorized,
+		},
+		{
+			name:     "no key set",
+			deps:     withDeps(func(d *TokenDeps) { d.Keys = nil; d.StaticKey = staticKey }),
+			token:    sign(t, jwt.SigningMethodRS256, rsaKey, "rsa", claims()),
+			wantKind: apperror.KindUnauthorized,
+		},
+		{
+			name:     "expired within the leeway",
+			deps:     deps,
+			token:    sign(t, jwt.SigningMethodRS256, rsaKey, "rsa", claims(set("exp", now.Add(-10*time.Second).Unix()))),
+			wantRole: models.RoleEditor,
+		},
+		{
+			name:     "expired",
+			deps:     deps,
+			token:    sign(t, jwt.SigningMethodRS256, rsaKey, "rsa", claims(set("exp", now.Add(-time.Minute).Unix()))),
+			wantKind: apperror.KindUnauthorized,
+		},
+		{
+			name:     "no exp",
+			deps:     deps,
+			token:    sign(t, jwt.SigningMethodRS256, rsaKey, "rsa", claims(unset("exp"))),
+			wantKind: apperror.KindUnauthorized,
+		},
+		{
+			name:     "not before within the leeway",
+			deps:     deps,
+			token:    sign(t, jwt.SigningMethodRS256, rsaKey, "rsa", claims(set("nbf", now.Add(10*time.Second).Unix()))),
+			wantRole: models.RoleEditor,
+		},
+		{
+			name:     "not valid yet",
+			deps:     deps,
+			token:    sign(t, jwt.SigningMethodRS256, rsaKey, "rsa", claims(set("nbf", now.Add(time.Minute).Unix()))),
+			wantKind: apperror.KindUnauthorized,
+		},
+		{
+			name:     "other issuer",
+			deps:     deps,
+			token:    sign(t, jwt.SigningMethodRS256, rsaKey, "rsa", claims(set("iss", "https://evil.example.com"))),
+			wantKind: apperror.KindUnauthorized,
+		},
+		{
+			name:     "no issuer",
+			deps:     deps,
+			token:    sign(t, jwt.SigningMethodRS256, rsaKey, "rsa", claims(unset("iss"))),
+			wantKind: apperror.KindUnauthorized,
+		},
+		{
+			name:     "one of the audiences",
+			deps:     deps,
+			token:    sign(t, jwt.SigningMethodRS256, rsaKey, "rsa", claims(set("aud", []string{"other", "songlib"}))),
+			wantRole: models.RoleEditor,
+		},
+		{
+			name:     "other audience",
+			deps:     deps,
+			token:    sign(t, jwt.SigningMethodRS256, rsaKey, "rsa", claims(set("aud", "other"))),
+			wantKind: apperror.KindUnauthorized,
+		},
+		{
+			name:     "no sub",
+			deps:     deps,
+			token:    sign(t, jwt.SigningMethodRS256, rsaKey, "rsa", claims(unset("sub"))),
+			wantKind: apperror.KindUnauthorized,
+		},
+		{
+			name:     "highest role",
+			deps:     deps,
+			token:    sign(t, jwt.SigningMethodRS256, rsaKey, "rsa", claims(set("roles", []string{"admin", "reader"}))),
+			wantRole: models.RoleAdmin,
+		},
+		{
+			name:     "roles separated by spaces",
+			deps:     deps,
+			token:    sign(t, jwt.SigningMethodRS256, rsaKey, "rsa", claims(set("roles", "reader editor"))),
+			wantRole: models.RoleEditor,
+		},
+		{
+			name:     "no role",
+			deps:     deps,
+			token:    sign(t, jwt.SigningMethodRS256, rsaKey, "rsa", claims(set("roles", []string{"guest"}))),
+			wantKind: apperror.KindForbidden,
+		},
+		{
+			name: "nested role claim mapped",
+			deps: withDeps(func(d *TokenDeps) {
+				d.RoleClaim = "realm_access.roles"
+				d.RoleMap = map[string]string{"library-admins": models.RoleAdmin, "library-users": models.RoleReader}
+			}),
+			token: sign(t, jwt.SigningMethodRS256, rsaKey, "rsa", claims(
+				unset("roles"),
+				set("realm_access", map[string]interface{}{"roles": []string{"offline_access", "library-users", "library-admins"}}),
+			)),
+			wantRole: models.RoleAdmin,
+		},
+		{
+			name: "nested role claim not in the map",
+			deps: withDeps(func(d *TokenDeps) {
+				d.RoleClaim = "realm_access.roles"
+				d.RoleMap = map[string]string{"library-admins": models.RoleAdmin}
+			}),
+			token: sign(t, jwt.SigningMethodRS256, rsaKey, "rsa", claims(
+				set("realm_access", map[string]interface{}{"roles": []string{models.RoleAdmin}}),
+			)),
+			wantKind: apperror.KindForbidden,
+		},
+		{
+			name:     "nested role claim missing",
+			deps:     withDeps(func(d *TokenDeps) { d.RoleClaim = "realm_access.roles" }),
+			token:    sign(t, jwt.SigningMethodRS256, rsaKey, "rsa", claims(set("realm_access", "editor"))),
+			wantKind: apperror.KindForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := NewTokenVerifier(tt.deps).Verify(context.Background(), tt.token)
+
+			if tt.wantKind != "" {
+				var appErr *apperror.Error
+				if !errors.As(err, &appErr) || appErr.Kind != tt.wantKind {
+					t.Fatalf("got %+v, %v, want a %s error", principal, err, tt.wantKind)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+
+			if principal.Role != tt.wantRole || principal.Subject != "user-1" {
+				t.Errorf("got %+v, want the role %s of user-1", principal, tt.wantRole)
+			}
+		})
+	}
+}
+
+func TestIssueToken(t *testing.T) {
+	verifier := NewTokenVerifier(TokenDeps{
+		StaticKey: []byte("the static key of the tests"),
+		Audience:  "songlib",
+		RoleClaim: "realm_access.roles",
+	})
+
+	token, err := verifier.IssueToken("dev", []string{models.RoleReader, models.RoleEditor}, time.Minute)
+	if err != nil {
+		t.Fatalf("IssueToken: %v", err)
+	}
+
+	principal, err := verifier.Verify(context.Background(), token)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+
+	if want := (models.Principal{Subject: "dev", Name: "dev", Role: models.RoleEditor}); principal != want {
+		t.Errorf("got %+v, want %+v", principal, want)
+	}
+
+	expired, err := verifier.IssueToken("dev", []string{models.RoleReader}, -time.Minute)
+	if err != nil {
+		t.Fatalf("IssueToken: %v", err)
+	}
+
+	if _, err = verifier.Verify(context.Background(), expired); !errors.Is(err, errInvalidToken) {
+		t.Errorf("expired token: got %v, want errInvalidToken", err)
+	}
+
+	other := NewTokenVerifier(TokenDeps{StaticKey: []byte("another static key")})
+	if _, err = other.Verify(context.Background(), token); !errors.Is(err, errInvalidToken) {
+		t.Errorf("token of another key: got %v, want errInvalidToken", err)
+	}
+
+	if _, err = NewTokenVerifier(TokenDeps{}).IssueToken("dev", nil, time.Minute); !errors.Is(err, errStaticKeyMissing) {
+		t.Errorf("no static key: got %v, want errStaticKeyMissing", err)
+	}
+}
@@ -0,0 +1,281 @@
+package backup
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+	"github.com/Magic-Kot/effective-mobile/pkg/client/postg"
+
+	"github.com/rs/zerolog"
+)
+
+// maxRecordSize - the longest line of the archive, lyrics can be long
+const maxRecordSize = 16 << 20
+
+var (
+	errNotBackup          = errors.New("the file is not a library backup")
+	errUnsupportedVersion = errors.New("unsupported backup version")
+	errSchemaVersion      = errors.New("schema version mismatch")
+	errTruncated          = errors.New("the backup is truncated")
+	errCorrupted          = errors.New("the backup is corrupted")
+	errConflicts          = errors.New("the backup conflicts with the stored data, nothing was restored")
+	errArchiveConsumed    = errors.New("the restore transaction was retried after the backup had been read, run it again")
+	// errDryRun - rolls back the restore of a dry run
+	errDryRun = errors.New("dry run")
+)
+
+type BackupRepository interface {
+	Tables() []string
+	DumpTable(ctx context.Context, name string, fn func(row json.RawMessage) error) error
+	RestoreRow(ctx context.Context, name string, row json.RawMessage) (string, error)
+	ResetSequences(ctx context.Context) error
+	RebuildSongStats(ctx context.Context) error
+}
+
+// SchemaVersioner - the version of the last applied migration
+type SchemaVersioner interface {
+	Version(ctx context.Context) (int64, error)
+}
+
+type BackupService struct {
+	BackupRepository BackupRepository
+	Transactor       postg.Transactor
+	SchemaVersioner  SchemaVersioner
+}
+
+func NewBackupService(backupRepository BackupRepository, transactor postg.Transactor, schemaVersioner SchemaVersioner) *BackupService {
+	return &BackupService{
+		BackupRepository: backupRepository,
+		Transactor:       transactor,
+		SchemaVersioner:  schemaVersioner,
+	}
+}
+
+// Backup - write all the tables of the library to a gzip compressed archive of JSON lines: the header,
+// the rows of each table and the trailer with the row counts. The tables are read from one snapshot.
+func (s *BackupService) Backup(ctx context.Context, w io.Writer) (models.BackupReport, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'Backup' service")
+
+	report := models.BackupReport{Rows: make(map[string]int)}
+
+	version, err := s.SchemaVersioner.Version(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	report.SchemaVersion = version
+
+	archive := gzip.NewWriter(w)
+	encoder := json.NewEncoder(archive)
+
+	header := models.BackupHeader{
+		Format:        models.BackupFormat,
+		Version:       models.BackupFormatVersion,
+		SchemaVersion: version,
+		CreatedAt:     time.Now().UTC(),
+	}
+
+	if err = encoder.Encode(header); err != nil {
+		return report, err
+	}
+
+	opts := &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+
+	err = s.Transactor.WithinTransactionOptions(ctx, opts, func(ctx context.Context) error {
+		for _, table := range s.BackupRepository.Tables() {
+			// an empty table is counted too, the restore checks every table against the trailer
+			report.Rows[table] = 0
+
+			err := s.BackupRepository.DumpTable(ctx, table, func(row json.RawMessage) error {
+				report.Rows[table]++
+				return encoder.Encode(models.BackupRecord{Table: table, Row: row})
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+
+	if err = encoder.Encode(models.BackupRecord{End: true, Rows: report.Rows}); err != nil {
+		return report, err
+	}
+
+	if err = archive.Close(); err != nil {
+		return report, err
+	}
+
+	logger.Info().Msgf("backup finished, schema version: %d, rows: %v", report.SchemaVersion, report.Rows)
+
+	return report, nil
+}
+
+// Restore - load the archive into the database in one transaction. The schema version of the archive
+// must be the version of the database. Rows equal to the stored ones are skipped, any row clashing with
+// a different stored row is a conflict and rolls the whole restore back. A dry run always rolls back.
+func (s *BackupService) Restore(ctx context.Context, req models.RestoreRequest, r io.Reader) (models.RestoreReport, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'Restore' service")
+
+	report := models.RestoreReport{
+		DryRun: req.DryRun,
+		Tables: make(map[string]models.RestoreTableReport),
+	}
+
+	archive, err := gzip.NewReader(r)
+	if err != nil {
+		return report, fmt.Errorf("%w: %w", errNotBackup, err)
+	}
+
+	defer archive.Close()
+
+	scanner := bufio.NewScanner(archive)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxRecordSize)
+
+	var header models.BackupHeader
+
+	if !scanner.Scan() {
+		return report, errNotBackup
+	}
+
+	if err = json.Unmarshal(scanner.Bytes(), &header); err != nil || header.Format != models.BackupFormat {
+		return report, errNotBackup
+	}
+
+	if header.Version != models.BackupFormatVersion {
+		return report, fmt.Errorf("%w: %d", errUnsupportedVersion, header.Version)
+	}
+
+	report.SchemaVersion = header.SchemaVersion
+
+	version, err := s.SchemaVersioner.Version(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	if version != header.SchemaVersion {
+		return report, fmt.Errorf("%w: the backup needs schema version %d, the database is at %d, run 'migrate up-to %[2]d' or 'migrate down-to %[2]d' first",
+			errSchemaVersion, header.SchemaVersion, version)
+	}
+
+	tables := make(map[string]bool)
+	for _, table := range s.BackupRepository.Tables() {
+		tables[table] = true
+	}
+
+	var consumed bool
+
+	err = s.Transactor.WithinTransactionOptions(ctx, nil, func(ctx context.Context) error {
+		if consumed {
+			return errArchiveConsumed
+		}
+
+		consumed = true
+
+		var (
+			conflicts int
+			trailer   *models.BackupRecord
+			rows      = make(map[string]int)
+		)
+
+		for scanner.Scan() {
+			var record models.BackupRecord
+			if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+				return fmt.Errorf("%w: %w", errCorrupted, err)
+			}
+
+			if record.End {
+				trailer = &record
+				break
+			}
+
+			if !tables[record.Table] {
+				return fmt.Errorf("%w: unknown table %q", errCorrupted, record.Table)
+			}
+
+			result, err := s.BackupRepository.RestoreRow(ctx, record.Table, record.Row)
+			if err != nil {
+				return err
+			}
+
+			rows[record.Table]++
+			counts := report.Tables[record.Table]
+
+			switch result {
+			case models.RestoreInserted:
+				counts.Inserted++
+			case models.RestoreUnchanged:
+				counts.Unchanged++
+			case models.RestoreConflict:
+				counts.Conflicts++
+				conflicts++
+			}
+
+			report.Tables[record.Table] = counts
+		}
+
+		if err := scanner.Err(); err != nil {
+			return fmt.Errorf("%w: %w", errCorrupted, err)
+		}
+
+		if trailer == nil {
+			return errTruncated
+		}
+
+		// every table of the library is in the trailer, so a table missing from the archive is not taken as empty
+		for table := range tables {
+			count, ok := trailer.Rows[table]
+			if !ok {
+				return fmt.Errorf("%w: the trailer has no row count of %s", errCorrupted, table)
+			}
+
+			if rows[table] != count {
+				return fmt.Errorf("%w: %s has %d rows of %d", errTruncated, table, rows[table], count)
+			}
+		}
+
+		for table := range trailer.Rows {
+			if !tables[table] {
+				return fmt.Errorf("%w: unknown table %q", errCorrupted, table)
+			}
+		}
+
+		if conflicts > 0 {
+			return fmt.Errorf("%w: %d rows", errConflicts, conflicts)
+		}
+
+		if err := s.BackupRepository.ResetSequences(ctx); err != nil {
+			return err
+		}
+
+		if err := s.BackupRepository.RebuildSongStats(ctx); err != nil {
+			return err
+		}
+
+		if req.DryRun {
+			return errDryRun
+		}
+
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return report, err
+	}
+
+	logger.Info().Msgf("restore finished, schema version: %d, dry run: %t", report.SchemaVersion, report.DryRun)
+
+	return report, nil
+}
@@ -0,0 +1,144 @@
+package bulk
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Magic-Kot/effective-mobile/internal/apperror"
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+)
+
+var (
+	errUnknownFormat = apperror.Validation("unknown_format", "unknown format")
+	errMissingColumn = apperror.Validation("missing_column", "missing column")
+	errInvalidHeader = apperror.Validation("invalid_header", "invalid csv header")
+)
+
+// maxLineSize - the longest NDJSON line, lyrics can be long
+const maxLineSize = 1 << 20
+
+// rowReader - reads the import file row by row. A malformed row is reported with invalidRowError
+// and reading can go on, any other error stops the import.
+type rowReader interface {
+	Next() (models.ImportRow, error)
+}
+
+type invalidRowError struct {
+	err error
+}
+
+func (e invalidRowError) Error() string {
+	return e.err.Error()
+}
+
+func newRowReader(format string, r io.Reader) (rowReader, error) {
+	switch format {
+	case models.FormatCSV:
+		return newCSVReader(r)
+	case models.FormatNDJSON:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+		return &ndjsonReader{scanner: scanner}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", errUnknownFormat, format)
+}
+
+type csvReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+}
+
+// newCSVReader - the first line is the header naming the columns: group, song, release_date, text, link
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	// an empty file or a malformed header is the fault of the file, not of the server
+	header, err := reader.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: the file is empty", errInvalidHeader)
+		} else if errors.As(err, &parseErr) {
+			return nil, fmt.Errorf("%w: %w", errInvalidHeader, err)
+		}
+
+		return nil, fmt.Errorf("reading the csv header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\uFEFF")))] = i
+	}
+
+	for _, name := range []string{"group", "song"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("%w: %s", errMissingColumn, name)
+		}
+	}
+
+	return &csvReader{reader: reader, columns: columns}, nil
+}
+
+func (c *csvReader) Next() (models.ImportRow, error) {
+	record, err := c.reader.Read()
+	if err != nil {
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return models.ImportRow{}, invalidRowError{err: err}
+		}
+
+		return models.ImportRow{}, err
+	}
+
+	field := func(name string) string {
+		i, ok := c.columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+
+		return strings.TrimSpace(record[i])
+	}
+
+	return models.ImportRow{
+		Group:       field("group"),
+		Song:        field("song"),
+		ReleaseDate: field("release_date"),
+		Text:        field("text"),
+		Link:        field("link"),
+	}, nil
+}
+
+type ndjsonReader struct {
+	scanner *bufio.Scanner
+}
+
+func (n *ndjsonReader) Next() (models.ImportRow, error) {
+	for n.scanner.Scan() {
+		line := strings.TrimSpace(n.scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var row models.ImportRow
+		if err := json.Unmarshal([]byte(line), &row); err != nil {
+			return row, invalidRowError{err: err}
+		}
+
+		return row, nil
+	}
+
+	if err := n.scanner.Err(); err != nil {
+		return models.ImportRow{}, err
+	}
+
+	return models.ImportRow{}, io.EOF
+}
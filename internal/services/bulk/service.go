@@ -0,0 +1,295 @@
+package bulk
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+	"github.com/Magic-Kot/effective-mobile/internal/services/song"
+	"github.com/Magic-Kot/effective-mobile/pkg/client/postg"
+	"github.com/Magic-Kot/effective-mobile/pkg/musicinfo"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/rs/zerolog"
+)
+
+// defaultBatchSize - rows imported in one transaction when the request does not set it
+const defaultBatchSize = 100
+
+var (
+	// errDryRun - rolls back the batch of a dry run
+	errDryRun = errors.New("dry run")
+)
+
+type SongRepository interface {
+	FindSong(ctx context.Context, group string, song string) (int, error)
+	GetOrCreateGroup(ctx context.Context, group string) (int, error)
+	AddSong(ctx context.Context, req models.CreateSong, res musicinfo.SongDetail) (int, error)
+	LinkSongGroup(ctx context.Context, groupId int, songId int, role string) error
+	ExportSongs(ctx context.Context, req models.RequestGetAll, fn func(song models.ExportSong) error) error
+}
+
+type BulkService struct {
+	SongRepository SongRepository
+	Transactor     postg.Transactor
+	MusicInfo      *musicinfo.MusicInfo
+	validator      *validator.Validate
+}
+
+func NewBulkService(songRepository SongRepository, transactor postg.Transactor, musicInfo *musicinfo.MusicInfo, validator *validator.Validate) *BulkService {
+	return &BulkService{
+		SongRepository: songRepository,
+		Transactor:     transactor,
+		MusicInfo:      musicInfo,
+		validator:      validator,
+	}
+}
+
+// pendingRow - a valid row waiting for its batch
+type pendingRow struct {
+	number int
+	row    models.ImportRow
+}
+
+// ImportSongs - add the songs of the CSV or NDJSON file in batches, one transaction per batch.
+// A row failing in the database is rolled back to its savepoint without losing the rest of the batch.
+// A dry run checks every row against the library and rolls back each batch.
+func (s *BulkService) ImportSongs(ctx context.Context, req models.ImportSongsRequest, r io.Reader) (models.ImportSongsReport, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'ImportSongs' service")
+
+	report := models.ImportSongsReport{
+		DryRun: req.DryRun,
+		Rows:   make([]models.ImportRowResult, 0),
+	}
+
+	if err := s.validator.Struct(req); err != nil {
+		return report, err
+	}
+
+	reader, err := newRowReader(req.Format, r)
+	if err != nil {
+		return report, err
+	}
+
+	batchSize := req.BatchSize
+	if batchSize == 0 {
+		batchSize = defaultBatchSize
+	}
+
+	// rows seen earlier in the file, the earlier batches of a dry run are not in the database
+	seen := make(map[string]struct{})
+	batch := make([]pendingRow, 0, batchSize)
+
+	for number := 1; ; number++ {
+		row, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		var invalidRow invalidRowError
+		if errors.As(err, &invalidRow) {
+			addResult(&report, models.ImportRowResult{Row: number, Status: models.RowInvalid, Error: invalidRow.Error()})
+			continue
+		} else if err != nil {
+			return report, err
+		}
+
+		if err = s.validator.Struct(row); err != nil {
+			addResult(&report, models.ImportRowResult{Row: number, Status: models.RowInvalid, Error: err.Error()})
+			continue
+		}
+
+		key := strings.ToLower(row.Group) + "\x00" + strings.ToLower(row.Song)
+		if _, ok := seen[key]; ok {
+			addResult(&report, models.ImportRowResult{Row: number, Status: models.RowDuplicate})
+			continue
+		}
+
+		seen[key] = struct{}{}
+
+		batch = append(batch, pendingRow{number: number, row: row})
+		if len(batch) == batchSize {
+			if err = s.importBatch(ctx, req, batch, &report); err != nil {
+				return report, err
+			}
+
+			batch = batch[:0]
+		}
+	}
+
+	if len(batch) > 0 {
+		if err = s.importBatch(ctx, req, batch, &report); err != nil {
+			return report, err
+		}
+	}
+
+	logger.Info().Msgf("songs import finished, created: %d, duplicate: %d, invalid: %d, failed: %d, dry run: %t",
+		report.Created, report.Duplicate, report.Invalid, report.Failed, report.DryRun)
+
+	return report, nil
+}
+
+func (s *BulkService) importBatch(ctx context.Context, req models.ImportSongsRequest, batch []pendingRow, report *models.ImportSongsReport) error {
+	logger := zerolog.Ctx(ctx)
+
+	details := make([]musicinfo.SongDetail, len(batch))
+	for i, pending := range batch {
+		details[i] = musicinfo.SongDetail{
+			ReleaseData: pending.row.ReleaseDate,
+			Text:        pending.row.Text,
+			Link:        pending.row.Link,
+		}
+
+		if req.Enrich {
+			details[i] = s.enrich(ctx, pending.row, details[i])
+		}
+	}
+
+	var results []models.ImportRowResult
+
+	err := s.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		results = make([]models.ImportRowResult, 0, len(batch))
+
+		for i, pending := range batch {
+			result := models.ImportRowResult{Row: pending.number}
+
+			err := s.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+				existing, err := s.SongRepository.FindSong(ctx, pending.row.Group, pending.row.Song)
+				if err != nil {
+					return err
+				}
+
+				if existing != 0 {
+					result.Status = models.RowDuplicate
+					result.Id = existing
+
+					return nil
+				}
+
+				result.Id, err = s.addSong(ctx, pending.row, details[i])
+				result.Status = models.RowCreated
+
+				return err
+			})
+			if err != nil {
+				logger.Debug().Msgf("import of row %d failed: %v", pending.number, err)
+
+				result = models.ImportRowResult{Row: pending.number, Status: models.RowFailed, Error: err.Error()}
+			}
+
+			if req.DryRun && result.Status == models.RowCreated {
+				result.Id = 0
+			}
+
+			results = append(results, result)
+		}
+
+		if req.DryRun {
+			return errDryRun
+		}
+
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return err
+	}
+
+	for _, result := range results {
+		addResult(report, result)
+	}
+
+	return nil
+}
+
+func (s *BulkService) addSong(ctx context.Context, row models.ImportRow, detail musicinfo.SongDetail) (int, error) {
+	groupId, err := s.SongRepository.GetOrCreateGroup(ctx, row.Group)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := s.SongRepository.AddSong(ctx, models.CreateSong{Group: row.Group, Song: row.Song}, detail)
+	if err != nil {
+		return 0, err
+	}
+
+	return id, s.SongRepository.LinkSongGroup(ctx, groupId, id, models.RolePrimary)
+}
+
+// enrich - fill the details missing from the row with the data of the music info service
+func (s *BulkService) enrich(ctx context.Context, row models.ImportRow, detail musicinfo.SongDetail) musicinfo.SongDetail {
+	if detail.ReleaseData != "" && detail.Text != "" && detail.Link != "" {
+		return detail
+	}
+
+	info, err := s.MusicInfo.Info(ctx, row.Group, row.Song)
+	if err != nil {
+		return detail
+	}
+
+	if detail.ReleaseData == "" {
+		detail.ReleaseData = info.ReleaseData
+	}
+
+	if detail.Text == "" {
+		detail.Text = info.Text
+	}
+
+	if detail.Link == "" {
+		detail.Link = info.Link
+	}
+
+	return detail
+}
+
+// addResult - record the result of the row and count it by status
+func addResult(report *models.ImportSongsReport, result models.ImportRowResult) {
+	switch result.Status {
+	case models.RowCreated:
+		report.Created++
+	case models.RowDuplicate:
+		report.Duplicate++
+	case models.RowInvalid:
+		report.Invalid++
+	case models.RowFailed:
+		report.Failed++
+	}
+
+	report.Rows = append(report.Rows, result)
+}
+
+// ExportSongs - write every song matching the filters of the listing in the format, ignoring paging.
+// The songs are written as they are read from the database. Returns the number of exported songs.
+func (s *BulkService) ExportSongs(ctx context.Context, format string, req models.RequestGetAll, w io.Writer) (int, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'ExportSongs' service")
+
+	if err := song.ValidateFilters(req); err != nil {
+		return 0, err
+	}
+
+	writer, err := newRowWriter(format, w)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+
+	err = s.SongRepository.ExportSongs(ctx, req, func(song models.ExportSong) error {
+		count++
+		return writer.Write(song)
+	})
+	if err != nil {
+		return count, err
+	}
+
+	if err = writer.Close(); err != nil {
+		return count, err
+	}
+
+	logger.Info().Msgf("songs export finished, exported: %d", count)
+
+	return count, nil
+}
@@ -0,0 +1,162 @@
+package bulk
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+)
+
+// csvHeader - the columns of the CSV export, the first five are read back by the import
+var csvHeader = []string{
+	"group", "song", "release_date", "text", "link", "id", "album_id", "track_number",
+	"artists", "genres", "tags", "created_at", "updated_at",
+}
+
+// ContentType - the media type of the export format
+func ContentType(format string) string {
+	switch format {
+	case models.FormatCSV:
+		return "text/csv; charset=utf-8"
+	case models.FormatNDJSON:
+		return "application/x-ndjson"
+	}
+
+	return "application/json"
+}
+
+// rowWriter - writes the export song by song. Nothing is written before the first song or Close,
+// so an error raised before the export starts can still be reported in place of it.
+type rowWriter interface {
+	Write(song models.ExportSong) error
+	Close() error
+}
+
+func newRowWriter(format string, w io.Writer) (rowWriter, error) {
+	switch format {
+	case models.FormatJSON:
+		return &jsonWriter{w: w}, nil
+	case models.FormatNDJSON:
+		return &ndjsonWriter{encoder: json.NewEncoder(w)}, nil
+	case models.FormatCSV:
+		return &csvWriter{writer: csv.NewWriter(w)}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", errUnknownFormat, format)
+}
+
+// jsonWriter - a single JSON array, one song per line
+type jsonWriter struct {
+	w       io.Writer
+	started bool
+}
+
+func (j *jsonWriter) Write(song models.ExportSong) error {
+	data, err := json.Marshal(song)
+	if err != nil {
+		return err
+	}
+
+	prefix := ",\n"
+	if !j.started {
+		prefix = "[\n"
+		j.started = true
+	}
+
+	if _, err = io.WriteString(j.w, prefix); err != nil {
+		return err
+	}
+
+	_, err = j.w.Write(data)
+
+	return err
+}
+
+func (j *jsonWriter) Close() error {
+	if !j.started {
+		_, err := io.WriteString(j.w, "[]\n")
+		return err
+	}
+
+	_, err := io.WriteString(j.w, "\n]\n")
+
+	return err
+}
+
+type ndjsonWriter struct {
+	encoder *json.Encoder
+}
+
+func (n *ndjsonWriter) Write(song models.ExportSong) error {
+	return n.encoder.Encode(song)
+}
+
+func (n *ndjsonWriter) Close() error {
+	return nil
+}
+
+// csvWriter - the artists are written as group:role pairs, the artists, genres and tags are separated with ';'
+type csvWriter struct {
+	writer  *csv.Writer
+	started bool
+}
+
+func (c *csvWriter) header() error {
+	if c.started {
+		return nil
+	}
+
+	c.started = true
+
+	return c.writer.Write(csvHeader)
+}
+
+func (c *csvWriter) Write(song models.ExportSong) error {
+	if err := c.header(); err != nil {
+		return err
+	}
+
+	artists := make([]string, 0, len(song.Artists))
+	for _, artist := range song.Artists {
+		artists = append(artists, artist.Group+":"+artist.Role)
+	}
+
+	return c.writer.Write([]string{
+		song.Group,
+		song.Song,
+		song.ReleaseDate,
+		song.Text,
+		song.Link,
+		strconv.Itoa(song.Id),
+		optionalInt(song.AlbumId),
+		optionalInt(song.TrackNumber),
+		strings.Join(artists, ";"),
+		strings.Join(song.Genres, ";"),
+		strings.Join(song.Tags, ";"),
+		song.CreatedAt.Format(time.RFC3339),
+		song.UpdatedAt.Format(time.RFC3339),
+	})
+}
+
+func (c *csvWriter) Close() error {
+	if err := c.header(); err != nil {
+		return err
+	}
+
+	c.writer.Flush()
+
+	return c.writer.Error()
+}
+
+func optionalInt(value *int) string {
+	if value == nil {
+		return ""
+	}
+
+	return strconv.Itoa(*value)
+}
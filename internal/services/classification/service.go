@@ -0,0 +1,69 @@
+package classification
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Magic-Kot/effective-mobile/internal/apperror"
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+
+	"github.com/rs/zerolog"
+)
+
+var errEmptyName = apperror.Validation("empty_name", "name must not be empty")
+
+type ClassificationRepository interface {
+	GetAllLabels(ctx context.Context, kind string) ([]models.LabelResponse, error)
+	AssignLabel(ctx context.Context, req models.LabelRequest) error
+	RemoveLabel(ctx context.Context, req models.LabelRequest) error
+}
+
+// Transactor - runs several repository calls atomically, the transaction is passed through the context
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type ClassificationService struct {
+	ClassificationRepository ClassificationRepository
+	Transactor               Transactor
+}
+
+func NewClassificationService(classificationRepository ClassificationRepository, transactor Transactor) *ClassificationService {
+	return &ClassificationService{
+		ClassificationRepository: classificationRepository,
+		Transactor:               transactor,
+	}
+}
+
+// GetAllLabels - get all the genres or tags with their song counts
+func (s *ClassificationService) GetAllLabels(ctx context.Context, kind string) ([]models.LabelResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'GetAllLabels' service")
+
+	return s.ClassificationRepository.GetAllLabels(ctx, kind)
+}
+
+// AssignLabel - assign the genre or tag to the song or music group
+func (s *ClassificationService) AssignLabel(ctx context.Context, req models.LabelRequest) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'AssignLabel' service")
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return errEmptyName
+	}
+
+	return s.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		return s.ClassificationRepository.AssignLabel(ctx, req)
+	})
+}
+
+// RemoveLabel - remove the genre or tag from the song or music group
+func (s *ClassificationService) RemoveLabel(ctx context.Context, req models.LabelRequest) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'RemoveLabel' service")
+
+	req.Name = strings.TrimSpace(req.Name)
+
+	return s.ClassificationRepository.RemoveLabel(ctx, req)
+}
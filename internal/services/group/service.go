@@ -0,0 +1,139 @@
+package group
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/Magic-Kot/effective-mobile/internal/apperror"
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+	"github.com/Magic-Kot/effective-mobile/pkg/client/postg"
+
+	"github.com/rs/zerolog"
+)
+
+var (
+	errInvalidRequest = apperror.Validation("invalid_request", "invalid request")
+	errGroupInUse     = apperror.Conflict("group_in_use", "the music group is credited on songs")
+)
+
+type GroupRepository interface {
+	CreateGroup(ctx context.Context, req models.CreateGroup) (int, error)
+	GetAllGroups(ctx context.Context, req models.RequestGetAllGroups) ([]models.GroupResponse, error)
+	GetGroup(ctx context.Context, id int) (models.GroupResponse, error)
+	GetGroupLabels(ctx context.Context, groupIds []int) (map[int][]string, map[int][]string, error)
+	UpdateGroup(ctx context.Context, req models.UpdateGroup) error
+	DeleteGroup(ctx context.Context, id int) error
+}
+
+type GroupService struct {
+	GroupRepository GroupRepository
+	Transactor      postg.Transactor
+}
+
+func NewGroupService(groupRepository GroupRepository, transactor postg.Transactor) *GroupService {
+	return &GroupService{
+		GroupRepository: groupRepository,
+		Transactor:      transactor,
+	}
+}
+
+// CreateGroup - add a new music group
+func (s *GroupService) CreateGroup(ctx context.Context, req models.CreateGroup) (int, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'CreateGroup' service")
+
+	return s.GroupRepository.CreateGroup(ctx, req)
+}
+
+// GetAllGroups - get all the music groups with their genres and tags
+func (s *GroupService) GetAllGroups(ctx context.Context, req models.RequestGetAllGroups) ([]models.GroupResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'GetAllGroups' service")
+
+	if _, err := strconv.Atoi(req.Id); err != nil {
+		return nil, fmt.Errorf("%w: id must be a number", errInvalidRequest)
+	}
+
+	if _, err := strconv.Atoi(req.Limit); err != nil {
+		return nil, fmt.Errorf("%w: limit must be a number", errInvalidRequest)
+	}
+
+	res, err := s.GroupRepository.GetAllGroups(ctx, req)
+	if err != nil || len(res) == 0 {
+		return res, err
+	}
+
+	if err = s.fillGroups(ctx, res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// GetGroup - get the music group by id with its genres and tags
+func (s *GroupService) GetGroup(ctx context.Context, id int) (models.GroupResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'GetGroup' service")
+
+	group, err := s.GroupRepository.GetGroup(ctx, id)
+	if err != nil {
+		return group, err
+	}
+
+	res := []models.GroupResponse{group}
+
+	if err = s.fillGroups(ctx, res); err != nil {
+		return group, err
+	}
+
+	return res[0], nil
+}
+
+// fillGroups - add the genres and the tags to the music groups
+func (s *GroupService) fillGroups(ctx context.Context, res []models.GroupResponse) error {
+	groupIds := make([]int, 0, len(res))
+	for _, group := range res {
+		groupIds = append(groupIds, group.Id)
+	}
+
+	genres, tags, err := s.GroupRepository.GetGroupLabels(ctx, groupIds)
+	if err != nil {
+		return err
+	}
+
+	for i := range res {
+		res[i].Genres = genres[res[i].Id]
+		res[i].Tags = tags[res[i].Id]
+	}
+
+	return nil
+}
+
+// UpdateGroup - rename the music group
+func (s *GroupService) UpdateGroup(ctx context.Context, req models.UpdateGroup) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'UpdateGroup' service")
+
+	return s.GroupRepository.UpdateGroup(ctx, req)
+}
+
+// DeleteGroup - delete the music group with its albums. A group credited on songs is kept,
+// so no song is left without its group.
+func (s *GroupService) DeleteGroup(ctx context.Context, id int) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'DeleteGroup' service")
+
+	return s.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		group, err := s.GroupRepository.GetGroup(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if group.Songs > 0 {
+			return fmt.Errorf("%w: %d songs", errGroupInUse, group.Songs)
+		}
+
+		return s.GroupRepository.DeleteGroup(ctx, id)
+	})
+}
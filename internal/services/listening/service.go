@@ -0,0 +1,194 @@
+package listening
+
+import (
+	"context"
+
+	"github.com/Magic-Kot/effective-mobile/internal/apperror"
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+	"github.com/Magic-Kot/effective-mobile/pkg/client/postg"
+
+	"github.com/rs/zerolog"
+)
+
+type ListeningRepository interface {
+	AddFavorite(ctx context.Context, userId string, songId int) (bool, error)
+	RemoveFavorite(ctx context.Context, userId string, songId int) (bool, error)
+	GetFavorites(ctx context.Context, req models.RequestGetFavorites) ([]models.SongsResponse, error)
+	LockSongStats(ctx context.Context, songId int) error
+	GetRating(ctx context.Context, userId string, songId int) (models.RatingResponse, error)
+	SetRating(ctx context.Context, userId string, req models.RateSong) (models.RatingResponse, error)
+	DeleteRating(ctx context.Context, userId string, songId int) error
+	AddPlay(ctx context.Context, userId string, songId int) (models.PlayResponse, error)
+	GetPlays(ctx context.Context, req models.RequestGetPlays) ([]models.PlayResponse, error)
+	AddSongStats(ctx context.Context, songId int, delta models.SongStatsDelta) error
+}
+
+// SongRepository - the details of the favorite songs
+type SongRepository interface {
+	GetSongArtists(ctx context.Context, songIds []int) (map[int][]models.SongArtist, error)
+	GetSongLabels(ctx context.Context, songIds []int) (map[int][]string, map[int][]string, error)
+}
+
+// ListeningService - the favorites, ratings and plays of the users, and the aggregates of the songs
+// kept in the same transaction as them
+type ListeningService struct {
+	ListeningRepository ListeningRepository
+	SongRepository      SongRepository
+	Transactor          postg.Transactor
+}
+
+func NewListeningService(listeningRepository ListeningRepository, songRepository SongRepository, transactor postg.Transactor) *ListeningService {
+	return &ListeningService{
+		ListeningRepository: listeningRepository,
+		SongRepository:      songRepository,
+		Transactor:          transactor,
+	}
+}
+
+// AddFavorite - add the song to the favorites of the user, adding it again changes nothing
+func (s *ListeningService) AddFavorite(ctx context.Context, userId string, songId int) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'AddFavorite' service")
+
+	return s.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		added, err := s.ListeningRepository.AddFavorite(ctx, userId, songId)
+		if err != nil || !added {
+			return err
+		}
+
+		return s.ListeningRepository.AddSongStats(ctx, songId, models.SongStatsDelta{Favorites: 1})
+	})
+}
+
+// RemoveFavorite - remove the song from the favorites of the user, removing a missing one changes nothing
+func (s *ListeningService) RemoveFavorite(ctx context.Context, userId string, songId int) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'RemoveFavorite' service")
+
+	return s.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		removed, err := s.ListeningRepository.RemoveFavorite(ctx, userId, songId)
+		if err != nil || !removed {
+			return err
+		}
+
+		return s.ListeningRepository.AddSongStats(ctx, songId, models.SongStatsDelta{Favorites: -1})
+	})
+}
+
+// GetFavorites - a page of the favorite songs of the user with their artists, genres and tags
+func (s *ListeningService) GetFavorites(ctx context.Context, req models.RequestGetFavorites) ([]models.SongsResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'GetFavorites' service")
+
+	res, err := s.ListeningRepository.GetFavorites(ctx, req)
+	if err != nil || len(res) == 0 {
+		return res, err
+	}
+
+	songIds := make([]int, 0, len(res))
+	for _, song := range res {
+		songIds = append(songIds, song.Id)
+	}
+
+	artists, err := s.SongRepository.GetSongArtists(ctx, songIds)
+	if err != nil {
+		return nil, err
+	}
+
+	genres, tags, err := s.SongRepository.GetSongLabels(ctx, songIds)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range res {
+		res[i].Artists = artists[res[i].Id]
+		res[i].Genres = genres[res[i].Id]
+		res[i].Tags = tags[res[i].Id]
+	}
+
+	return res, nil
+}
+
+// RateSong - save the rating of the user, a new rating of the song replaces the previous one
+func (s *ListeningService) RateSong(ctx context.Context, userId string, req models.RateSong) (models.RatingResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'RateSong' service")
+
+	var res models.RatingResponse
+
+	err := s.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		// the previous rating is read after the lock, so it is not changed before the aggregates are
+		if err := s.ListeningRepository.LockSongStats(ctx, req.SongId); err != nil {
+			return err
+		}
+
+		delta := models.SongStatsDelta{Ratings: 1, RatingSum: req.Rating}
+
+		previous, err := s.ListeningRepository.GetRating(ctx, userId, req.SongId)
+		switch {
+		case err == nil:
+			delta = models.SongStatsDelta{RatingSum: req.Rating - previous.Rating}
+		case apperror.From(err).Kind != apperror.KindNotFound:
+			return err
+		}
+
+		if res, err = s.ListeningRepository.SetRating(ctx, userId, req); err != nil {
+			return err
+		}
+
+		return s.ListeningRepository.AddSongStats(ctx, req.SongId, delta)
+	})
+
+	return res, err
+}
+
+// DeleteRating - remove the rating of the user from the song
+func (s *ListeningService) DeleteRating(ctx context.Context, userId string, songId int) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'DeleteRating' service")
+
+	return s.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.ListeningRepository.LockSongStats(ctx, songId); err != nil {
+			return err
+		}
+
+		previous, err := s.ListeningRepository.GetRating(ctx, userId, songId)
+		if err != nil {
+			return err
+		}
+
+		if err = s.ListeningRepository.DeleteRating(ctx, userId, songId); err != nil {
+			return err
+		}
+
+		return s.ListeningRepository.AddSongStats(ctx, songId, models.SongStatsDelta{Ratings: -1, RatingSum: -previous.Rating})
+	})
+}
+
+// RecordPlay - add the play to the listening history of the user
+func (s *ListeningService) RecordPlay(ctx context.Context, userId string, req models.RecordPlay) (models.PlayResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'RecordPlay' service")
+
+	var res models.PlayResponse
+
+	err := s.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+
+		if res, err = s.ListeningRepository.AddPlay(ctx, userId, req.SongId); err != nil {
+			return err
+		}
+
+		return s.ListeningRepository.AddSongStats(ctx, req.SongId, models.SongStatsDelta{Plays: 1})
+	})
+
+	return res, err
+}
+
+// GetPlays - a page of the listening history of the user, the latest play first
+func (s *ListeningService) GetPlays(ctx context.Context, req models.RequestGetPlays) ([]models.PlayResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'GetPlays' service")
+
+	return s.ListeningRepository.GetPlays(ctx, req)
+}
@@ -0,0 +1,332 @@
+package playlist
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/Magic-Kot/effective-mobile/internal/apperror"
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+	"github.com/Magic-Kot/effective-mobile/pkg/client/postg"
+	"github.com/Magic-Kot/effective-mobile/pkg/playlistformat"
+
+	"github.com/rs/zerolog"
+)
+
+var (
+	errInvalidRequest  = apperror.Validation("invalid_request", "invalid request")
+	errInvalidPosition = apperror.Validation("invalid_position", "invalid position")
+	errInvalidFile     = apperror.Validation("invalid_playlist_file", "invalid playlist file")
+)
+
+// maxLimit - the largest page of the playlist listing, the v1 one included
+const maxLimit = 1000
+
+type PlaylistRepository interface {
+	CreatePlaylist(ctx context.Context, req models.CreatePlaylist) (int, error)
+	GetAllPlaylists(ctx context.Context, req models.RequestGetAllPlaylists) ([]models.PlaylistResponse, error)
+	GetPlaylist(ctx context.Context, id int) (models.PlaylistResponse, error)
+	GetPlaylistEntries(ctx context.Context, id int) ([]models.PlaylistEntry, error)
+	LockPlaylist(ctx context.Context, id int) (int, error)
+	UpdatePlaylist(ctx context.Context, req models.UpdatePlaylist) error
+	TouchPlaylist(ctx context.Context, id int) error
+	DeletePlaylist(ctx context.Context, id int) error
+	InsertEntries(ctx context.Context, id int, position int, songIds []int) error
+	RemoveEntry(ctx context.Context, id int, position int) error
+	MoveEntry(ctx context.Context, id int, from int, to int) error
+	DuplicatePlaylist(ctx context.Context, req models.DuplicatePlaylist) (int, error)
+	MatchSongs(ctx context.Context, tracks []models.TrackRef) ([]int, error)
+}
+
+type PlaylistService struct {
+	PlaylistRepository PlaylistRepository
+	Transactor         postg.Transactor
+}
+
+func NewPlaylistService(playlistRepository PlaylistRepository, transactor postg.Transactor) *PlaylistService {
+	return &PlaylistService{
+		PlaylistRepository: playlistRepository,
+		Transactor:         transactor,
+	}
+}
+
+// CreatePlaylist - add a new playlist with the songs in the given order
+func (s *PlaylistService) CreatePlaylist(ctx context.Context, req models.CreatePlaylist) (int, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'CreatePlaylist' service")
+
+	var id int
+
+	err := s.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+
+		id, err = s.PlaylistRepository.CreatePlaylist(ctx, req)
+		if err != nil {
+			return err
+		}
+
+		if len(req.Songs) == 0 {
+			return nil
+		}
+
+		return s.PlaylistRepository.InsertEntries(ctx, id, 1, req.Songs)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// GetAllPlaylists - get all the playlists
+func (s *PlaylistService) GetAllPlaylists(ctx context.Context, req models.RequestGetAllPlaylists) ([]models.PlaylistResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'GetAllPlaylists' service")
+
+	if _, err := strconv.Atoi(req.Id); err != nil {
+		return nil, fmt.Errorf("%w: id must be a number", errInvalidRequest)
+	}
+
+	if limit, err := strconv.Atoi(req.Limit); err != nil || limit < 1 || limit > maxLimit {
+		return nil, fmt.Errorf("%w: limit must be a number from 1 to %d", errInvalidRequest, maxLimit)
+	}
+
+	return s.PlaylistRepository.GetAllPlaylists(ctx, req)
+}
+
+// GetPlaylist - get the playlist with its ordered entries
+func (s *PlaylistService) GetPlaylist(ctx context.Context, id int) (models.PlaylistResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'GetPlaylist' service")
+
+	playlist, err := s.PlaylistRepository.GetPlaylist(ctx, id)
+	if err != nil {
+		return playlist, err
+	}
+
+	playlist.Entries, err = s.PlaylistRepository.GetPlaylistEntries(ctx, id)
+	if err != nil {
+		return playlist, err
+	}
+
+	return playlist, nil
+}
+
+// UpdatePlaylist - update the name and the description of the playlist
+func (s *PlaylistService) UpdatePlaylist(ctx context.Context, req models.UpdatePlaylist) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'UpdatePlaylist' service")
+
+	return s.PlaylistRepository.UpdatePlaylist(ctx, req)
+}
+
+// DeletePlaylist - delete the playlist
+func (s *PlaylistService) DeletePlaylist(ctx context.Context, id int) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'DeletePlaylist' service")
+
+	return s.PlaylistRepository.DeletePlaylist(ctx, id)
+}
+
+// AddEntries - insert the songs at the position, or append them when the position is 0
+func (s *PlaylistService) AddEntries(ctx context.Context, req models.PlaylistEntriesRequest) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'AddEntries' service")
+
+	return s.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		count, err := s.PlaylistRepository.LockPlaylist(ctx, req.Id)
+		if err != nil {
+			return err
+		}
+
+		position := req.Position
+		if position == 0 {
+			position = count + 1
+		}
+
+		if position > count+1 {
+			return fmt.Errorf("%w: the playlist has %d entries", errInvalidPosition, count)
+		}
+
+		if err = s.PlaylistRepository.InsertEntries(ctx, req.Id, position, req.Songs); err != nil {
+			return err
+		}
+
+		return s.PlaylistRepository.TouchPlaylist(ctx, req.Id)
+	})
+}
+
+// RemoveEntry - remove the entry at the position
+func (s *PlaylistService) RemoveEntry(ctx context.Context, id int, position int) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'RemoveEntry' service")
+
+	return s.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := s.PlaylistRepository.LockPlaylist(ctx, id); err != nil {
+			return err
+		}
+
+		if err := s.PlaylistRepository.RemoveEntry(ctx, id, position); err != nil {
+			return err
+		}
+
+		return s.PlaylistRepository.TouchPlaylist(ctx, id)
+	})
+}
+
+// MoveEntry - move the entry to another position
+func (s *PlaylistService) MoveEntry(ctx context.Context, req models.MoveEntryRequest) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'MoveEntry' service")
+
+	return s.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		count, err := s.PlaylistRepository.LockPlaylist(ctx, req.Id)
+		if err != nil {
+			return err
+		}
+
+		if req.From > count || req.To > count {
+			return fmt.Errorf("%w: the playlist has %d entries", errInvalidPosition, count)
+		}
+
+		if req.From == req.To {
+			return nil
+		}
+
+		if err = s.PlaylistRepository.MoveEntry(ctx, req.Id, req.From, req.To); err != nil {
+			return err
+		}
+
+		return s.PlaylistRepository.TouchPlaylist(ctx, req.Id)
+	})
+}
+
+// DuplicatePlaylist - copy the playlist with its entries
+func (s *PlaylistService) DuplicatePlaylist(ctx context.Context, req models.DuplicatePlaylist) (int, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'DuplicatePlaylist' service")
+
+	var id int
+
+	err := s.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+
+		id, err = s.PlaylistRepository.DuplicatePlaylist(ctx, req)
+
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// ExportPlaylist - write the playlist in the format, the stored link of each song is its location
+func (s *PlaylistService) ExportPlaylist(ctx context.Context, id int, format string, w io.Writer) error {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'ExportPlaylist' service")
+
+	playlist, err := s.GetPlaylist(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	out := playlistformat.Playlist{
+		Title:      playlist.Name,
+		Creator:    playlist.Owner,
+		Annotation: playlist.Description,
+		Tracks:     make([]playlistformat.Track, 0, len(playlist.Entries)),
+	}
+
+	for _, entry := range playlist.Entries {
+		out.Tracks = append(out.Tracks, playlistformat.Track{
+			Location: entry.Link,
+			Title:    entry.Song,
+			Creator:  entry.GroupSong,
+		})
+	}
+
+	return playlistformat.Encode(w, format, out)
+}
+
+// ImportPlaylist - create a playlist from the file, keeping the entries matched with library songs
+func (s *PlaylistService) ImportPlaylist(ctx context.Context, req models.ImportPlaylist, r io.Reader) (models.ImportPlaylistResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'ImportPlaylist' service")
+
+	var res models.ImportPlaylistResponse
+
+	file, err := playlistformat.Decode(r, req.Format)
+	if err != nil {
+		logger.Debug().Msgf("error decoding the playlist: %v", err)
+		return res, fmt.Errorf("%w: %w", errInvalidFile, err)
+	}
+
+	tracks := make([]models.TrackRef, 0, len(file.Tracks))
+	for i, track := range file.Tracks {
+		tracks = append(tracks, models.TrackRef{
+			Position: i + 1,
+			Group:    track.Creator,
+			Song:     track.Title,
+			Location: track.Location,
+		})
+	}
+
+	create := models.CreatePlaylist{
+		Name:        req.Name,
+		Owner:       req.Owner,
+		Description: file.Annotation,
+	}
+
+	if create.Name == "" {
+		create.Name = file.Title
+	}
+
+	if create.Name == "" {
+		return res, fmt.Errorf("%w: the playlist has no name", errInvalidRequest)
+	}
+
+	res.Unmatched = make([]models.TrackRef, 0)
+
+	err = s.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		res.Unmatched = res.Unmatched[:0]
+		create.Songs = create.Songs[:0]
+
+		if len(tracks) > 0 {
+			songIds, err := s.PlaylistRepository.MatchSongs(ctx, tracks)
+			if err != nil {
+				return err
+			}
+
+			for i, songId := range songIds {
+				if songId == 0 {
+					res.Unmatched = append(res.Unmatched, tracks[i])
+					continue
+				}
+
+				create.Songs = append(create.Songs, songId)
+			}
+		}
+
+		id, err := s.PlaylistRepository.CreatePlaylist(ctx, create)
+		if err != nil {
+			return err
+		}
+
+		res.Id = id
+		res.Matched = len(create.Songs)
+
+		if len(create.Songs) == 0 {
+			return nil
+		}
+
+		return s.PlaylistRepository.InsertEntries(ctx, id, 1, create.Songs)
+	})
+	if err != nil {
+		return models.ImportPlaylistResponse{}, err
+	}
+
+	return res, nil
+}
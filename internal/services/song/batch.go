@@ -0,0 +1,296 @@
+package song
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Magic-Kot/effective-mobile/internal/models"
+	"github.com/Magic-Kot/effective-mobile/pkg/musicinfo"
+
+	"github.com/rs/zerolog"
+)
+
+// errBatchFailed - rolls back the atomic batch after the first failed operation
+var errBatchFailed = errors.New("batch operation failed")
+
+// musicInfoCalls - the calls of the music info service a batch makes at the same time
+const musicInfoCalls = 8
+
+// batchOperation - the decoded and validated operation of the batch
+type batchOperation struct {
+	op     string
+	create models.CreateSong
+	update models.UpdateRequest
+	id     int
+	detail musicinfo.SongDetail
+}
+
+// Batch - run the create, update and delete operations in one transaction. In the atomic mode
+// an invalid or failed operation cancels the whole batch. In the best-effort mode every operation
+// runs in its own savepoint, so a failed one is rolled back alone and the others are kept.
+func (s *SongService) Batch(ctx context.Context, req models.BatchRequest) (models.BatchResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'Batch' service")
+
+	ctx, span := tracer.Start(ctx, "SongService.Batch")
+	defer span.End()
+
+	res := models.BatchResponse{BestEffort: req.BestEffort}
+
+	if err := s.validator.Struct(req); err != nil {
+		return res, fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	operations := make([]*batchOperation, len(req.Operations))
+	results := make([]models.BatchResult, len(req.Operations))
+
+	var invalid bool
+
+	for i, op := range req.Operations {
+		results[i] = models.BatchResult{Index: i, Op: op.Op, Id: op.Id}
+
+		operation, err := s.prepareOperation(op)
+		if err != nil {
+			results[i].Status = models.BatchInvalid
+			results[i].Error = err.Error()
+			invalid = true
+
+			continue
+		}
+
+		operations[i] = operation
+	}
+
+	if invalid && !req.BestEffort {
+		res.Results = cancelBatch(results)
+		return countBatch(res), nil
+	}
+
+	// the music info service is asked before the transaction is opened
+	failed, err := s.fetchDetails(ctx, operations, results, !req.BestEffort)
+	if err != nil {
+		return res, err
+	}
+
+	if failed && !req.BestEffort {
+		res.Results = cancelBatch(results)
+		return countBatch(res), nil
+	}
+
+	err = s.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		for i, operation := range operations {
+			if operation == nil {
+				continue
+			}
+
+			results[i].Status = models.BatchOk
+			results[i].Error = ""
+
+			var id int
+
+			// the savepoint keeps the transaction usable after a failed operation
+			err := s.Transactor.WithinTransaction(ctx, func(ctx context.Context) (err error) {
+				id, err = s.runOperation(ctx, operation)
+				return err
+			})
+			if err != nil {
+				logger.Debug().Msgf("batch operation %d failed: %v", i, err)
+
+				results[i].Status = models.BatchFailed
+				if errors.Is(err, errInvalidRequest) {
+					results[i].Status = models.BatchInvalid
+				}
+
+				results[i].Error = err.Error()
+
+				if !req.BestEffort {
+					return errBatchFailed
+				}
+
+				continue
+			}
+
+			results[i].Id = id
+		}
+
+		return nil
+	})
+	if errors.Is(err, errBatchFailed) {
+		res.Results = cancelBatch(results)
+		return countBatch(res), nil
+	} else if err != nil {
+		return res, err
+	}
+
+	res.Results = results
+
+	return countBatch(res), nil
+}
+
+// fetchDetails - get the details of the created songs from the music info service, a few songs at a time.
+// A song the service fails for is added without details, or marked failed and dropped if they are required.
+// With stopOnFailure the calls left are skipped after the first failure, their songs stay unmarked.
+func (s *SongService) fetchDetails(ctx context.Context, operations []*batchOperation, results []models.BatchResult, stopOnFailure bool) (bool, error) {
+	logger := zerolog.Ctx(ctx)
+
+	callCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg     sync.WaitGroup
+		failed atomic.Bool
+	)
+
+	calls := make(chan struct{}, musicInfoCalls)
+
+	for i, operation := range operations {
+		if operation == nil || operation.op != models.BatchCreate {
+			continue
+		}
+
+		wg.Add(1)
+		calls <- struct{}{}
+
+		go func() {
+			defer func() {
+				<-calls
+				wg.Done()
+			}()
+
+			if callCtx.Err() != nil {
+				return
+			}
+
+			detail, err := s.MusicInfo.Info(callCtx, operation.create.Group, operation.create.Song)
+			if err == nil {
+				operation.detail = detail
+				return
+			}
+
+			// canceled after the failure of another song, or with the request
+			if callCtx.Err() != nil && failed.Load() {
+				return
+			}
+
+			logger.Warn().Msgf("music info of batch operation %d: %v", i, err)
+
+			if !s.requireDetails {
+				return
+			}
+
+			// the cause is logged only, it may show the address of the service
+			results[i].Status = models.BatchFailed
+			results[i].Error = errMusicInfo.Error()
+			operations[i] = nil
+
+			failed.Store(true)
+
+			if stopOnFailure {
+				cancel()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	// the details of some songs may be missing, the batch is not run
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	return failed.Load(), nil
+}
+
+// prepareOperation - decode the song of the operation and check it like the single song requests
+func (s *SongService) prepareOperation(op models.BatchOperation) (*batchOperation, error) {
+	operation := &batchOperation{op: op.Op, id: op.Id}
+
+	switch op.Op {
+	case models.BatchCreate:
+		if err := decodeBatchSong(op.Song, &operation.create); err != nil {
+			return nil, err
+		}
+
+		if err := s.validator.Struct(operation.create); err != nil {
+			return nil, err
+		}
+	case models.BatchUpdate:
+		if err := decodeBatchSong(op.Song, &operation.update); err != nil {
+			return nil, err
+		}
+
+		operation.update.Id = op.Id
+
+		if err := s.validator.Struct(operation.update); err != nil {
+			return nil, err
+		}
+
+		if operation.update.Artists != nil && !hasPrimaryArtist(operation.update.Artists) {
+			return nil, fmt.Errorf("%w: artists must include a primary group", errInvalidRequest)
+		}
+	case models.BatchDelete:
+	default:
+		return nil, fmt.Errorf("%w: unknown operation: %s", errInvalidRequest, op.Op)
+	}
+
+	if op.Op != models.BatchCreate && op.Id <= 0 {
+		return nil, fmt.Errorf("%w: id must be a positive number", errInvalidRequest)
+	}
+
+	return operation, nil
+}
+
+func decodeBatchSong(data json.RawMessage, dest interface{}) error {
+	if len(data) == 0 {
+		return fmt.Errorf("%w: song is required", errInvalidRequest)
+	}
+
+	if err := json.Unmarshal(data, dest); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+
+	return nil
+}
+
+// runOperation - the nested transactions of the song methods become savepoints of the batch
+func (s *SongService) runOperation(ctx context.Context, operation *batchOperation) (int, error) {
+	switch operation.op {
+	case models.BatchCreate:
+		return s.addSong(ctx, operation.create, operation.detail)
+	case models.BatchUpdate:
+		return operation.id, s.UpdateSong(ctx, operation.update)
+	}
+
+	return operation.id, s.DeleteSong(ctx, operation.id)
+}
+
+// cancelBatch - nothing of the atomic batch was applied, the operations that succeeded are rolled back
+func cancelBatch(results []models.BatchResult) []models.BatchResult {
+	for i := range results {
+		if results[i].Status == models.BatchOk || results[i].Status == "" {
+			results[i].Status = models.BatchRolledBack
+
+			if results[i].Op == models.BatchCreate {
+				results[i].Id = 0
+			}
+		}
+	}
+
+	return results
+}
+
+func countBatch(res models.BatchResponse) models.BatchResponse {
+	for _, result := range res.Results {
+		if result.Status == models.BatchOk {
+			res.Succeeded++
+		} else {
+			res.Failed++
+		}
+	}
+
+	return res
+}
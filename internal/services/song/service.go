@@ -6,17 +6,41 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/Magic-Kot/effective-mobile/internal/apperror"
 	"github.com/Magic-Kot/effective-mobile/internal/models"
+	"github.com/Magic-Kot/effective-mobile/pkg/client/postg"
 	"github.com/Magic-Kot/effective-mobile/pkg/musicinfo"
 
+	"github.com/go-playground/validator/v10"
 	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
 )
 
+var (
+	errInvalidRequest = apperror.Validation("invalid_request", "invalid request")
+	errVerseNotFound  = apperror.NotFound("verse_not_found", "verse not found")
+	errMusicInfo      = apperror.Upstream("music_info_failed", "the music info service failed")
+)
+
+// maxLimit - the largest page of the song listing, the v1 one included
+const maxLimit = 1000
+
+// tracer - a span for every method of the service, the statements and the music info call are its children
+var tracer = otel.Tracer("github.com/Magic-Kot/effective-mobile/internal/services/song")
+
 type SongRepository interface {
+	GetOrCreateGroup(ctx context.Context, group string) (int, error)
 	AddSong(ctx context.Context, req models.CreateSong, res musicinfo.SongDetail) (int, error)
+	LinkSongGroup(ctx context.Context, groupId int, songId int, role string) error
+	UnlinkSongGroups(ctx context.Context, songId int) error
+	GetSongArtists(ctx context.Context, songIds []int) (map[int][]models.SongArtist, error)
+	GetSongLabels(ctx context.Context, songIds []int) (map[int][]string, map[int][]string, error)
+	GetSongFacets(ctx context.Context, req models.RequestGetAll) (models.SongFacets, error)
 	GetAllSong(ctx context.Context, req models.RequestGetAll) ([]models.SongsResponse, error)
-	GetAllSongFilter(ctx context.Context, req models.RequestGetAll) ([]models.SongsResponse, error)
+	GetSong(ctx context.Context, id int) (models.SongsResponse, error)
+	SearchSongs(ctx context.Context, text string, limit int) ([]models.SongsResponse, error)
 	GetLyricsSong(ctx context.Context, id string) (string, error)
 	UpdateSong(ctx context.Context, value string, arg []interface{}) error
 	DeleteSong(ctx context.Context, id int) error
@@ -24,24 +48,66 @@ type SongRepository interface {
 
 type SongService struct {
 	SongRepository SongRepository
+	Transactor     postg.Transactor
 	MusicInfo      *musicinfo.MusicInfo
+	// requireDetails - a song the music info service fails for is not added, instead of added without details
+	requireDetails bool
+	validator      *validator.Validate
 }
 
-func NewSongService(songRepository SongRepository, musicInfo *musicinfo.MusicInfo) *SongService {
+func NewSongService(songRepository SongRepository, transactor postg.Transactor, musicInfo *musicinfo.MusicInfo, requireDetails bool, validator *validator.Validate) *SongService {
 	return &SongService{
 		SongRepository: songRepository,
+		Transactor:     transactor,
 		MusicInfo:      musicInfo,
+		requireDetails: requireDetails,
+		validator:      validator,
 	}
 }
 
-// AddSong - add a new song
+// AddSong - add a new song with its details from the music info service. If the service fails the song
+// is added without them, or not at all if the details are required.
 func (s *SongService) AddSong(ctx context.Context, req models.CreateSong) (int, error) {
 	logger := zerolog.Ctx(ctx)
 	logger.Debug().Msg("starting the 'AddSong' service")
 
-	res, _ := s.MusicInfo.Info(req.Group, req.Song)
+	ctx, span := tracer.Start(ctx, "SongService.AddSong")
+	defer span.End()
+
+	res, err := s.MusicInfo.Info(ctx, req.Group, req.Song)
+	if err != nil {
+		logger.Warn().Msgf("music info of the song: %v", err)
+
+		// the cause is logged only, it may show the address of the service
+		if s.requireDetails {
+			return 0, postg.WithCause(errMusicInfo, err)
+		}
+	}
+
+	return s.addSong(ctx, req, res)
+}
+
+// addSong - add the song with the details already received from the music info service
+func (s *SongService) addSong(ctx context.Context, req models.CreateSong, res musicinfo.SongDetail) (int, error) {
+	var id int
+
+	err := s.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		groupId, err := s.SongRepository.GetOrCreateGroup(ctx, req.Group)
+		if err != nil {
+			return err
+		}
+
+		id, err = s.SongRepository.AddSong(ctx, req, res)
+		if err != nil {
+			return err
+		}
+
+		if err = s.SongRepository.LinkSongGroup(ctx, groupId, id, models.RolePrimary); err != nil {
+			return err
+		}
 
-	id, err := s.SongRepository.AddSong(ctx, req, res)
+		return s.linkArtists(ctx, id, req.Artists)
+	})
 	if err != nil {
 		return 0, err
 	}
@@ -49,18 +115,32 @@ func (s *SongService) AddSong(ctx context.Context, req models.CreateSong) (int,
 	return id, nil
 }
 
+// linkArtists - credit the music groups on the song, creating the groups that do not exist
+func (s *SongService) linkArtists(ctx context.Context, songId int, artists []models.SongArtist) error {
+	for _, artist := range artists {
+		groupId, err := s.SongRepository.GetOrCreateGroup(ctx, artist.Group)
+		if err != nil {
+			return err
+		}
+
+		if err = s.SongRepository.LinkSongGroup(ctx, groupId, songId, artist.Role); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // GetAllSong - get all the songs
 func (s *SongService) GetAllSong(ctx context.Context, req models.RequestGetAll) ([]models.SongsResponse, error) {
 	logger := zerolog.Ctx(ctx)
 	logger.Debug().Msg("starting the 'GetAllSong' service")
 
-	if req.Filter != "" && req.Value != "" {
-		res, err := s.SongRepository.GetAllSongFilter(ctx, req)
-		if err != nil {
-			return nil, err
-		}
+	ctx, span := tracer.Start(ctx, "SongService.GetAllSong")
+	defer span.End()
 
-		return res, nil
+	if err := validateGetAll(req); err != nil {
+		return nil, err
 	}
 
 	res, err := s.SongRepository.GetAllSong(ctx, req)
@@ -68,34 +148,197 @@ func (s *SongService) GetAllSong(ctx context.Context, req models.RequestGetAll)
 		return nil, err
 	}
 
+	if len(res) == 0 {
+		return res, nil
+	}
+
+	if err = s.fillSongs(ctx, res); err != nil {
+		return nil, err
+	}
+
 	return res, nil
 }
 
+// GetSong - get the song by id with its artists, genres and tags
+func (s *SongService) GetSong(ctx context.Context, id int) (models.SongsResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'GetSong' service")
+
+	ctx, span := tracer.Start(ctx, "SongService.GetSong")
+	defer span.End()
+
+	song, err := s.SongRepository.GetSong(ctx, id)
+	if err != nil {
+		return song, err
+	}
+
+	res := []models.SongsResponse{song}
+
+	if err = s.fillSongs(ctx, res); err != nil {
+		return song, err
+	}
+
+	return res[0], nil
+}
+
+// SearchSongs - get the songs with the title, the group or the lyrics containing the text
+func (s *SongService) SearchSongs(ctx context.Context, text string, limit int) ([]models.SongsResponse, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'SearchSongs' service")
+
+	ctx, span := tracer.Start(ctx, "SongService.SearchSongs")
+	defer span.End()
+
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("%w: the search text is empty", errInvalidRequest)
+	}
+
+	if limit < 1 {
+		return nil, fmt.Errorf("%w: limit must be positive", errInvalidRequest)
+	}
+
+	res, err := s.SongRepository.SearchSongs(ctx, text, limit)
+	if err != nil || len(res) == 0 {
+		return res, err
+	}
+
+	if err = s.fillSongs(ctx, res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// fillSongs - add the credited music groups, the genres and the tags to the songs
+func (s *SongService) fillSongs(ctx context.Context, res []models.SongsResponse) error {
+	songIds := make([]int, 0, len(res))
+	for _, song := range res {
+		songIds = append(songIds, song.Id)
+	}
+
+	artists, err := s.SongRepository.GetSongArtists(ctx, songIds)
+	if err != nil {
+		return err
+	}
+
+	genres, tags, err := s.SongRepository.GetSongLabels(ctx, songIds)
+	if err != nil {
+		return err
+	}
+
+	for i := range res {
+		res[i].Artists = artists[res[i].Id]
+		res[i].Genres = genres[res[i].Id]
+		res[i].Tags = tags[res[i].Id]
+	}
+
+	return nil
+}
+
+// GetSongFacets - get the genre and tag counts of all the songs matching the filters
+func (s *SongService) GetSongFacets(ctx context.Context, req models.RequestGetAll) (models.SongFacets, error) {
+	logger := zerolog.Ctx(ctx)
+	logger.Debug().Msg("starting the 'GetSongFacets' service")
+
+	ctx, span := tracer.Start(ctx, "SongService.GetSongFacets")
+	defer span.End()
+
+	if err := validateGetAll(req); err != nil {
+		return models.SongFacets{}, err
+	}
+
+	return s.SongRepository.GetSongFacets(ctx, req)
+}
+
+// validateGetAll - check the paging parameters and the RFC 3339 timestamps of the listing
+func validateGetAll(req models.RequestGetAll) error {
+	if _, err := strconv.Atoi(req.Id); err != nil {
+		return fmt.Errorf("%w: id must be a number", errInvalidRequest)
+	}
+
+	if limit, err := strconv.Atoi(req.Limit); err != nil || limit < 1 || limit > maxLimit {
+		return fmt.Errorf("%w: limit must be a number from 1 to %d", errInvalidRequest, maxLimit)
+	}
+
+	return ValidateFilters(req)
+}
+
+// ValidateFilters - check the role and the RFC 3339 timestamps of the song filters, the export of
+// the bulk service takes the same ones. The first invalid timestamp in the order below is reported.
+func ValidateFilters(req models.RequestGetAll) error {
+	switch req.Role {
+	case "", models.RolePrimary, models.RoleFeatured, models.RoleComposer, models.RoleLyricist:
+	default:
+		return fmt.Errorf("%w: unknown role: %s", errInvalidRequest, req.Role)
+	}
+
+	timestamps := []struct {
+		name  string
+		value string
+	}{
+		{"created_after", req.CreatedAfter},
+		{"created_before", req.CreatedBefore},
+		{"updated_after", req.UpdatedAfter},
+		{"updated_before", req.UpdatedBefore},
+	}
+
+	for _, ts := range timestamps {
+		if ts.value == "" {
+			continue
+		}
+
+		if _, err := time.Parse(time.RFC3339, ts.value); err != nil {
+			return fmt.Errorf("%w: %s must be an RFC 3339 timestamp", errInvalidRequest, ts.name)
+		}
+	}
+
+	return nil
+}
+
 // GetLyricsSong - get the lyrics by id
 func (s *SongService) GetLyricsSong(ctx context.Context, songId string, verse string) (string, error) {
 	logger := zerolog.Ctx(ctx)
 	logger.Debug().Msg("starting the 'GetLyricsSong' service")
 
+	ctx, span := tracer.Start(ctx, "SongService.GetLyricsSong")
+	defer span.End()
+
+	if _, err := strconv.Atoi(songId); err != nil {
+		return "", fmt.Errorf("%w: id must be a number", errInvalidRequest)
+	}
+
+	verseInt, err := strconv.Atoi(verse)
+	if err != nil {
+		return "", fmt.Errorf("%w: verse must be a number", errInvalidRequest)
+	}
+
 	text, err := s.SongRepository.GetLyricsSong(ctx, songId)
 	if err != nil {
 		return "", err
 	}
 
-	textSplit := strings.Split(text, "\n\n")
+	textSplit := SplitVerses(text)
 
-	verseInt, err := strconv.Atoi(verse)
-	if err != nil {
-		return "", err
+	if verseInt < 0 || verseInt >= len(textSplit) {
+		return "", fmt.Errorf("%w: the song has %d verses", errVerseNotFound, len(textSplit))
 	}
 
 	return textSplit[verseInt], nil
 }
 
+// SplitVerses - the verses of the lyrics, they are separated by an empty line
+func SplitVerses(text string) []string {
+	return strings.Split(text, "\n\n")
+}
+
 // UpdateSong - update information about a saved song
 func (s *SongService) UpdateSong(ctx context.Context, song models.UpdateRequest) error {
 	logger := zerolog.Ctx(ctx)
 	logger.Debug().Msg("starting the 'UpdateSong' service")
 
+	ctx, span := tracer.Start(ctx, "SongService.UpdateSong")
+	defer span.End()
+
 	value := make([]string, 0)
 	arg := make([]interface{}, 0)
 	argId := 2
@@ -105,15 +348,13 @@ func (s *SongService) UpdateSong(ctx context.Context, song models.UpdateRequest)
 	values := reflect.ValueOf(song)
 	types := values.Type()
 
-	for i := 1; i < values.NumField(); i++ {
-		if types.Field(i).Name == "Song" {
-			value = append(value, fmt.Sprintf("song_name=$%d", argId))
-		} else if types.Field(i).Name == "ReleaseDate" {
-			value = append(value, fmt.Sprintf("release_date=$%d", argId))
-		} else {
-			value = append(value, fmt.Sprintf("%s=$%d", types.Field(i).Name, argId))
+	for i := 0; i < values.NumField(); i++ {
+		column := types.Field(i).Tag.Get("db")
+		if column == "" || column == "-" || column == "id" {
+			continue
 		}
 
+		value = append(value, fmt.Sprintf("%s=$%d", column, argId))
 		arg = append(arg, values.Field(i).String())
 
 		argId++
@@ -121,12 +362,35 @@ func (s *SongService) UpdateSong(ctx context.Context, song models.UpdateRequest)
 
 	valueQuery := strings.Join(value, ", ")
 
-	err := s.SongRepository.UpdateSong(ctx, valueQuery, arg)
-	if err != nil {
-		return err
+	if song.Artists == nil {
+		return s.SongRepository.UpdateSong(ctx, valueQuery, arg)
 	}
 
-	return nil
+	if !hasPrimaryArtist(song.Artists) {
+		return fmt.Errorf("%w: artists must include a primary group", errInvalidRequest)
+	}
+
+	return s.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.SongRepository.UpdateSong(ctx, valueQuery, arg); err != nil {
+			return err
+		}
+
+		if err := s.SongRepository.UnlinkSongGroups(ctx, song.Id); err != nil {
+			return err
+		}
+
+		return s.linkArtists(ctx, song.Id, song.Artists)
+	})
+}
+
+func hasPrimaryArtist(artists []models.SongArtist) bool {
+	for _, artist := range artists {
+		if artist.Role == models.RolePrimary {
+			return true
+		}
+	}
+
+	return false
 }
 
 // DeleteSong - delete a song from the library
@@ -134,7 +398,12 @@ func (s *SongService) DeleteSong(ctx context.Context, id int) error {
 	logger := zerolog.Ctx(ctx)
 	logger.Debug().Msg("starting the 'DeleteSong' service")
 
-	err := s.SongRepository.DeleteSong(ctx, id)
+	ctx, span := tracer.Start(ctx, "SongService.DeleteSong")
+	defer span.End()
+
+	err := s.Transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		return s.SongRepository.DeleteSong(ctx, id)
+	})
 	if err != nil {
 		return err
 	}
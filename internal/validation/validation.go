@@ -0,0 +1,131 @@
+package validation
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/ru"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	entranslations "github.com/go-playground/validator/v10/translations/en"
+	rutranslations "github.com/go-playground/validator/v10/translations/ru"
+)
+
+// registerFunc - registers the default messages of the validator in the language
+type registerFunc func(v *validator.Validate, trans ut.Translator) error
+
+// languages - the shipped message languages, the first one is the fallback
+var languages = []struct {
+	locale   locales.Translator
+	register registerFunc
+}{
+	{locale: en.New(), register: entranslations.RegisterDefaultTranslations},
+	{locale: ru.New(), register: rutranslations.RegisterDefaultTranslations},
+}
+
+// New - the validator naming the fields after their JSON keys, so the messages match the request body
+func New() *validator.Validate {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(jsonFieldName)
+
+	return validate
+}
+
+// jsonFieldName - the JSON key of the struct field
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+
+	return name
+}
+
+// Translator - turns the validation errors into messages in the language of the client
+type Translator struct {
+	uni *ut.UniversalTranslator
+}
+
+func NewTranslator(validate *validator.Validate) (*Translator, error) {
+	fallback := languages[0].locale
+
+	supported := make([]locales.Translator, 0, len(languages))
+	for _, language := range languages {
+		supported = append(supported, language.locale)
+	}
+
+	uni := ut.New(fallback, supported...)
+
+	for _, language := range languages {
+		trans, _ := uni.GetTranslator(language.locale.Locale())
+
+		if err := language.register(validate, trans); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Translator{uni: uni}, nil
+}
+
+// Find - the translator of the most preferred language of the Accept-Language header
+// that is shipped, English if there is none
+func (t *Translator) Find(acceptLanguage string) ut.Translator {
+	trans, _ := t.uni.FindTranslator(parseAcceptLanguage(acceptLanguage)...)
+
+	return trans
+}
+
+// parseAcceptLanguage - the locales of the header by preference: "ru-RU,ru;q=0.9,en;q=0.8" gives ru_RU, ru, en.
+// Every region is followed by its base language, so ru-RU finds the Russian messages.
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		locale string
+		q      float64
+	}
+
+	var tags []weighted
+
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+
+			q = parsed
+		}
+
+		if q <= 0 {
+			continue
+		}
+
+		tags = append(tags, weighted{locale: strings.ReplaceAll(tag, "-", "_"), q: q})
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool {
+		return tags[i].q > tags[j].q
+	})
+
+	result := make([]string, 0, len(tags)*2)
+
+	for _, tag := range tags {
+		result = append(result, tag.locale)
+
+		if base, _, ok := strings.Cut(tag.locale, "_"); ok {
+			result = append(result, base)
+		}
+	}
+
+	return result
+}
@@ -14,11 +14,19 @@ import (
 
 var errConnectingPostgres = errors.New("error connecting to postgres")
 
-type Client interface {
+// Querier - the part of the connection shared by *sqlx.DB and *sqlx.Tx
+type Querier interface {
 	Exec(query string, args ...interface{}) (sql.Result, error)
 	QueryRowx(query string, args ...interface{}) *sqlx.Row
+	Queryx(query string, args ...interface{}) (*sqlx.Rows, error)
 	Select(dest interface{}, query string, args ...interface{}) error
+}
+
+type Client interface {
+	Querier
+	QueryRowxContext(ctx context.Context, query string, args ...interface{}) *sqlx.Row
 	Begin() (*sql.Tx, error)
+	BeginTxx(ctx context.Context, opts *sql.TxOptions) (*sqlx.Tx, error)
 }
 
 type ConfigDeps struct {
@@ -0,0 +1,91 @@
+package postg
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/Magic-Kot/effective-mobile/pkg/client/postg")
+
+// tracedQuerier - a span for each statement, a child of the span of the context the querier was taken with
+type tracedQuerier struct {
+	ctx     context.Context
+	querier Querier
+}
+
+// traced - the querier with the spans of the statements, if the context carries a trace
+func traced(ctx context.Context, querier Querier) Querier {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return querier
+	}
+
+	return tracedQuerier{ctx: ctx, querier: querier}
+}
+
+func (q tracedQuerier) Exec(query string, args ...interface{}) (sql.Result, error) {
+	span := q.start(query)
+
+	res, err := q.querier.Exec(query, args...)
+	endSpan(span, err)
+
+	return res, err
+}
+
+func (q tracedQuerier) QueryRowx(query string, args ...interface{}) *sqlx.Row {
+	span := q.start(query)
+
+	row := q.querier.QueryRowx(query, args...)
+	endSpan(span, row.Err())
+
+	return row
+}
+
+// Queryx - the span ends when the query is sent, not when its rows are read
+func (q tracedQuerier) Queryx(query string, args ...interface{}) (*sqlx.Rows, error) {
+	span := q.start(query)
+
+	rows, err := q.querier.Queryx(query, args...)
+	endSpan(span, err)
+
+	return rows, err
+}
+
+func (q tracedQuerier) Select(dest interface{}, query string, args ...interface{}) error {
+	span := q.start(query)
+
+	err := q.querier.Select(dest, query, args...)
+	endSpan(span, err)
+
+	return err
+}
+
+func (q tracedQuerier) start(query string) trace.Span {
+	text := strings.Join(strings.Fields(query), " ")
+
+	operation, _, _ := strings.Cut(text, " ")
+	operation = strings.ToUpper(operation)
+
+	_, span := tracer.Start(q.ctx, operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL, semconv.DBOperationName(operation), semconv.DBQueryText(text)),
+	)
+
+	return span
+}
+
+// endSpan - end the span, marking it as failed with the error. A missing row is not a failure.
+func endSpan(span trace.Span, err error) {
+	if err != nil && err != sql.ErrNoRows {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
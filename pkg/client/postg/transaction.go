@@ -0,0 +1,192 @@
+package postg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var errTransaction = errors.New("transaction error")
+
+// Postgres error codes after which the whole transaction can be safely retried
+const (
+	codeSerializationFailure = "40001"
+	codeDeadlockDetected     = "40P01"
+)
+
+type txKey struct{}
+
+// txState - transaction carried by the context, depth is the number of active savepoints
+type txState struct {
+	tx    *sqlx.Tx
+	depth int
+}
+
+type TxConfigDeps struct {
+	MaxRetries int
+	RetryDelay time.Duration
+}
+
+// Transactor - runs several repository calls atomically, the transaction is passed through the context.
+// The services depend on it instead of TxManager.
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+	WithinTransactionOptions(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context) error) error
+}
+
+// TxManager - runs several repository calls in one transaction passed through the context
+type TxManager struct {
+	client     Client
+	maxRetries int
+	retryDelay time.Duration
+}
+
+func NewTxManager(client Client, cfg *TxConfigDeps) *TxManager {
+	return &TxManager{
+		client:     client,
+		maxRetries: cfg.MaxRetries,
+		retryDelay: cfg.RetryDelay,
+	}
+}
+
+// WithinTransaction - runs fn in a transaction with the default isolation level
+func (m *TxManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return m.WithinTransactionOptions(ctx, nil, fn)
+}
+
+// WithinTransactionOptions - runs fn in a transaction. If the context already carries a transaction,
+// fn is run inside a savepoint of it. The outermost transaction is retried on serialization failures
+// and deadlocks, so fn may be called more than once and must not have side effects outside the database.
+func (m *TxManager) WithinTransactionOptions(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context) error) error {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return m.withinSavepoint(ctx, state, fn)
+	}
+
+	logger := zerolog.Ctx(ctx)
+
+	var err error
+
+	for attempt := 0; attempt <= m.maxRetries; attempt++ {
+		if attempt > 0 {
+			logger.Debug().Msgf("retrying transaction, attempt: %d, err: %s", attempt, err)
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(m.retryDelay * time.Duration(attempt)):
+			}
+		}
+
+		err = m.run(ctx, opts, fn)
+		if !IsRetryable(err) {
+			return err
+		}
+	}
+
+	return err
+}
+
+func (m *TxManager) run(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context) error) (err error) {
+	logger := zerolog.Ctx(ctx)
+
+	ctx, span := tracer.Start(ctx, "transaction", trace.WithAttributes(semconv.DBSystemPostgreSQL))
+	defer func() { endSpan(span, err) }()
+
+	tx, err := m.client.BeginTxx(ctx, opts)
+	if err != nil {
+		logger.Debug().Msgf("transaction creation error. err: %s", err)
+		return WithCause(errTransaction, err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err = fn(context.WithValue(ctx, txKey{}, &txState{tx: tx})); err != nil {
+		if errRollback := tx.Rollback(); errRollback != nil {
+			logger.Debug().Msgf("transaction rollback error. err: %s", errRollback)
+		}
+
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (m *TxManager) withinSavepoint(ctx context.Context, state *txState, fn func(ctx context.Context) error) error {
+	logger := zerolog.Ctx(ctx)
+
+	state.depth++
+	defer func() { state.depth-- }()
+
+	savepoint := fmt.Sprintf("sp_%d", state.depth)
+
+	if _, err := state.tx.Exec("SAVEPOINT " + savepoint); err != nil {
+		logger.Debug().Msgf("savepoint creation error. err: %s", err)
+		return WithCause(errTransaction, err)
+	}
+
+	if err := fn(ctx); err != nil {
+		if _, errRollback := state.tx.Exec("ROLLBACK TO SAVEPOINT " + savepoint); errRollback != nil {
+			logger.Debug().Msgf("savepoint rollback error. err: %s", errRollback)
+		}
+
+		return err
+	}
+
+	if _, err := state.tx.Exec("RELEASE SAVEPOINT " + savepoint); err != nil {
+		logger.Debug().Msgf("savepoint release error. err: %s", err)
+		return err
+	}
+
+	return nil
+}
+
+// QuerierFromContext - returns the transaction carried by the context or the fallback connection
+func QuerierFromContext(ctx context.Context, fallback Querier) Querier {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok {
+		return traced(ctx, state.tx)
+	}
+
+	return traced(ctx, fallback)
+}
+
+// causeError - keeps the driver error reachable for errors.As while showing only the public message
+type causeError struct {
+	err   error
+	cause error
+}
+
+func (e causeError) Error() string {
+	return e.err.Error()
+}
+
+func (e causeError) Unwrap() []error {
+	return []error{e.err, e.cause}
+}
+
+// WithCause - wraps the repository error with the driver error that caused it
+func WithCause(err, cause error) error {
+	return causeError{err: err, cause: cause}
+}
+
+// IsRetryable - reports whether the transaction failed because of a serialization failure or a deadlock
+func IsRetryable(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+
+	return pqErr.Code == codeSerializationFailure || pqErr.Code == codeDeadlockDetected
+}
@@ -0,0 +1,223 @@
+package postg
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// fakeDriver - a driver recording the statements and the transaction boundaries it is sent
+type fakeDriver struct {
+	mu  sync.Mutex
+	log []string
+}
+
+func (d *fakeDriver) record(stmt string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.log = append(d.log, stmt)
+}
+
+func (d *fakeDriver) statements() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return append([]string(nil), d.log...)
+}
+
+func (d *fakeDriver) Connect(context.Context) (driver.Conn, error) { return fakeConn{d}, nil }
+func (d *fakeDriver) Driver() driver.Driver                        { return nil }
+
+type fakeConn struct{ d *fakeDriver }
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c fakeConn) Close() error                        { return nil }
+func (c fakeConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c fakeConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	c.d.record("BEGIN")
+	return fakeTx{c.d}, nil
+}
+
+func (c fakeConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	c.d.record(query)
+	return driver.RowsAffected(0), nil
+}
+
+type fakeTx struct{ d *fakeDriver }
+
+func (t fakeTx) Commit() error   { t.d.record("COMMIT"); return nil }
+func (t fakeTx) Rollback() error { t.d.record("ROLLBACK"); return nil }
+
+// newTestManager - a manager over the fake driver retrying without a delay
+func newTestManager(maxRetries int) (*TxManager, *fakeDriver) {
+	d := &fakeDriver{}
+	db := sqlx.NewDb(sql.OpenDB(d), "postgres")
+
+	return NewTxManager(db, &TxConfigDeps{MaxRetries: maxRetries}), d
+}
+
+func TestWithinTransactionSavepoints(t *testing.T) {
+	m, d := newTestManager(0)
+	errInner := errors.New("inner failed")
+
+	err := m.WithinTransaction(context.Background(), func(ctx context.Context) error {
+		if err := m.WithinTransaction(ctx, func(ctx context.Context) error {
+			return m.WithinTransaction(ctx, func(context.Context) error { return nil })
+		}); err != nil {
+			return err
+		}
+
+		if err := m.WithinTransaction(ctx, func(context.Context) error { return errInner }); !errors.Is(err, errInner) {
+			t.Errorf("savepoint error = %v, want %v", err, errInner)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithinTransaction() error = %v", err)
+	}
+
+	want := []string{
+		"BEGIN",
+		"SAVEPOINT sp_1",
+		"SAVEPOINT sp_2",
+		"RELEASE SAVEPOINT sp_2",
+		"RELEASE SAVEPOINT sp_1",
+		"SAVEPOINT sp_1",
+		"ROLLBACK TO SAVEPOINT sp_1",
+		"COMMIT",
+	}
+	if got := d.statements(); !reflect.DeepEqual(got, want) {
+		t.Errorf("statements = %q, want %q", got, want)
+	}
+}
+
+func TestWithinTransactionRollback(t *testing.T) {
+	m, d := newTestManager(3)
+	errFn := errors.New("fn failed")
+
+	if err := m.WithinTransaction(context.Background(), func(context.Context) error { return errFn }); !errors.Is(err, errFn) {
+		t.Fatalf("WithinTransaction() error = %v, want %v", err, errFn)
+	}
+
+	// an error that is not retryable is returned after the first attempt
+	want := []string{"BEGIN", "ROLLBACK"}
+	if got := d.statements(); !reflect.DeepEqual(got, want) {
+		t.Errorf("statements = %q, want %q", got, want)
+	}
+}
+
+func TestWithinTransactionRetriesOutermost(t *testing.T) {
+	m, d := newTestManager(3)
+
+	var outer, inner int
+
+	err := m.WithinTransaction(context.Background(), func(ctx context.Context) error {
+		outer++
+
+		return m.WithinTransaction(ctx, func(context.Context) error {
+			inner++
+			if inner == 1 {
+				return &pq.Error{Code: codeSerializationFailure}
+			}
+
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatalf("WithinTransaction() error = %v", err)
+	}
+
+	// the savepoint passes the error up and the whole transaction is run again
+	if outer != 2 || inner != 2 {
+		t.Errorf("calls: outer = %d, inner = %d, want 2 and 2", outer, inner)
+	}
+
+	want := []string{
+		"BEGIN", "SAVEPOINT sp_1", "ROLLBACK TO SAVEPOINT sp_1", "ROLLBACK",
+		"BEGIN", "SAVEPOINT sp_1", "RELEASE SAVEPOINT sp_1", "COMMIT",
+	}
+	if got := d.statements(); !reflect.DeepEqual(got, want) {
+		t.Errorf("statements = %q, want %q", got, want)
+	}
+}
+
+func TestWithinTransactionGivesUp(t *testing.T) {
+	m, _ := newTestManager(2)
+
+	var calls int
+
+	err := m.WithinTransaction(context.Background(), func(context.Context) error {
+		calls++
+		return &pq.Error{Code: codeDeadlockDetected}
+	})
+
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != codeDeadlockDetected {
+		t.Fatalf("WithinTransaction() error = %v, want the deadlock", err)
+	}
+
+	// the first attempt and MaxRetries retries
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+}
+
+func TestWithCause(t *testing.T) {
+	errPublic := errors.New("song not found")
+	cause := &pq.Error{Code: codeSerializationFailure, Message: "could not serialize access"}
+
+	err := fmt.Errorf("getting the song: %w", WithCause(errPublic, cause))
+
+	if got, want := err.Error(), "getting the song: song not found"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+
+	if !errors.Is(err, errPublic) {
+		t.Errorf("errors.Is(err, %v) = false", errPublic)
+	}
+
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr != cause {
+		t.Errorf("errors.As(err, *pq.Error) = %v, want %v", pqErr, cause)
+	}
+
+	if !IsRetryable(err) {
+		t.Error("IsRetryable() = false through the cause")
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "plain error", err: errors.New("failed"), want: false},
+		{name: "serialization failure", err: &pq.Error{Code: "40001"}, want: true},
+		{name: "deadlock", err: &pq.Error{Code: "40P01"}, want: true},
+		{name: "unique violation", err: &pq.Error{Code: "23505"}, want: false},
+		{name: "wrapped", err: fmt.Errorf("update: %w", &pq.Error{Code: "40P01"}), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
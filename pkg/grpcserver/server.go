@@ -0,0 +1,69 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+type ConfigDeps struct {
+	Host string
+	Port string
+}
+
+type Server struct {
+	host string
+	port string
+	serv *grpc.Server
+}
+
+// NewServer - a gRPC server with the reflection service, so tools like grpcurl can list the methods
+func NewServer(deps *ConfigDeps, opts ...grpc.ServerOption) *Server {
+	s := grpc.NewServer(opts...)
+	reflection.Register(s)
+
+	return &Server{
+		host: deps.Host,
+		port: deps.Port,
+		serv: s,
+	}
+}
+
+// Start - serve until Shutdown is called
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.host+s.port)
+	if err != nil {
+		return err
+	}
+
+	if err = s.serv.Serve(listener); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Server) Server() *grpc.Server {
+	return s.serv
+}
+
+// Shutdown - stop accepting calls and wait for the running ones, the calls still running when ctx is done are cancelled
+func (s *Server) Shutdown(ctx context.Context) error {
+	stopped := make(chan struct{})
+
+	go func() {
+		s.serv.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.serv.Stop()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,331 @@
+// Package jwks - the public keys of a JSON Web Key Set (RFC 7517) read from a file or an URL,
+// the keys of an URL are read again when they are stale or a token names an unknown key.
+package jwks
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRefresh = 15 * time.Minute
+	// minRefresh - an unknown key id does not make the set read more often than this
+	minRefresh = 30 * time.Second
+	// defaultTimeout - the time the default client is given to read the key set
+	defaultTimeout = 10 * time.Second
+	maxBody        = 1 << 20
+)
+
+var (
+	ErrKeyNotFound = errors.New("jwks: key not found")
+	errNoSource    = errors.New("jwks: a file or an url is required")
+)
+
+type ConfigDeps struct {
+	// File - the path of the key set, it is read once
+	File string
+	// URL - the address of the key set, e.g. the jwks_uri of the OIDC provider
+	URL string
+	// HTTPClient - a client with a timeout of 10 seconds if nil
+	HTTPClient *http.Client
+	// Refresh - the age of the keys of the URL read again, 15 minutes by default
+	Refresh time.Duration
+}
+
+type KeySet struct {
+	url        string
+	httpClient *http.Client
+	refresh    time.Duration
+
+	mu       sync.Mutex
+	keys     map[string]crypto.PublicKey
+	loadedAt time.Time
+	// loading - the read of the URL in flight, nil if there is none
+	loading *load
+}
+
+// load - a read of the URL, the callers missing a key wait for it instead of reading the URL again
+type load struct {
+	done chan struct{}
+	err  error
+}
+
+// jsonWebKey - the members of a key this package reads
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// NewKeySet - read the key set of the file or the URL, the file is used if both are given
+func NewKeySet(ctx context.Context, deps *ConfigDeps) (*KeySet, error) {
+	ks := &KeySet{
+		url:        deps.URL,
+		httpClient: deps.HTTPClient,
+		refresh:    deps.Refresh,
+	}
+
+	if ks.httpClient == nil {
+		ks.httpClient = &http.Client{Timeout: defaultTimeout}
+	}
+
+	if ks.refresh <= 0 {
+		ks.refresh = defaultRefresh
+	}
+
+	switch {
+	case deps.File != "":
+		data, err := os.ReadFile(deps.File)
+		if err != nil {
+			return nil, fmt.Errorf("jwks: %w", err)
+		}
+
+		keys, err := Parse(data)
+		if err != nil {
+			return nil, err
+		}
+
+		// the keys of a file never go stale
+		ks.url = ""
+		ks.keys = keys
+	case deps.URL != "":
+		keys, err := ks.fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		ks.keys = keys
+		ks.loadedAt = time.Now()
+	default:
+		return nil, errNoSource
+	}
+
+	return ks, nil
+}
+
+// Key - the key with the id. An empty id names the only key of the set. The URL is read without
+// holding the lock, so the keys already known are served while the set is read again.
+func (ks *KeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	ks.mu.Lock()
+
+	age := time.Since(ks.loadedAt)
+	key, ok := ks.lookup(kid)
+
+	l, leader := ks.loading, false
+	if l == nil && ks.url != "" && ((!ok && age > minRefresh) || age > ks.refresh) {
+		l, leader = &load{done: make(chan struct{})}, true
+		ks.loading = l
+		ks.loadedAt = time.Now()
+	}
+
+	ks.mu.Unlock()
+
+	switch {
+	case leader:
+		ks.reload(ctx, l)
+	case ok || l == nil:
+		return found(key, ok, kid)
+	default:
+		select {
+		case <-l.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	ks.mu.Lock()
+	key, ok = ks.lookup(kid)
+	ks.mu.Unlock()
+
+	if l.err != nil && !ok {
+		return nil, l.err
+	}
+
+	return found(key, ok, kid)
+}
+
+// found - the key, or ErrKeyNotFound if the set has no key with the id
+func found(key crypto.PublicKey, ok bool, kid string) (crypto.PublicKey, error) {
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrKeyNotFound, kid)
+	}
+
+	return key, nil
+}
+
+// reload - read the URL and swap the keys, the keys read before stay if it fails
+func (ks *KeySet) reload(ctx context.Context, l *load) {
+	keys, err := ks.fetch(ctx)
+
+	ks.mu.Lock()
+
+	if err == nil {
+		ks.keys = keys
+	}
+
+	l.err = err
+	ks.loading = nil
+
+	ks.mu.Unlock()
+
+	close(l.done)
+}
+
+func (ks *KeySet) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(ks.keys) == 1 {
+		for _, key := range ks.keys {
+			return key, true
+		}
+	}
+
+	key, ok := ks.keys[kid]
+
+	return key, ok
+}
+
+// fetch - read the key set of the URL
+func (ks *KeySet) fetch(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ks.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("jwks: %w", err)
+	}
+
+	resp, err := ks.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("jwks: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jwks: %s: response status %s", ks.url, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBody))
+	if err != nil {
+		return nil, fmt.Errorf("jwks: %w", err)
+	}
+
+	return Parse(data)
+}
+
+// Parse - the RSA and EC signature keys of the key set by id, the other keys are skipped
+func Parse(data []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("jwks: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		var (
+			key crypto.PublicKey
+			err error
+		)
+
+		switch jwk.Kty {
+		case "RSA":
+			key, err = jwk.rsa()
+		case "EC":
+			key, err = jwk.ecdsa()
+		default:
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("jwks: key %q: %w", jwk.Kid, err)
+		}
+
+		keys[jwk.Kid] = key
+	}
+
+	return keys, nil
+}
+
+func (k jsonWebKey) rsa() (*rsa.PublicKey, error) {
+	n, err := decodeInt(k.N)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := decodeInt(k.E)
+	if err != nil {
+		return nil, err
+	}
+
+	if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+		return nil, errors.New("invalid exponent")
+	}
+
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+func (k jsonWebKey) ecdsa() (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+
+	switch k.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+	}
+
+	x, err := decodeInt(k.X)
+	if err != nil {
+		return nil, err
+	}
+
+	y, err := decodeInt(k.Y)
+	if err != nil {
+		return nil, err
+	}
+
+	if !curve.IsOnCurve(x, y) {
+		return nil, errors.New("the point is not on the curve")
+	}
+
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
+
+func decodeInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) == 0 {
+		return nil, errors.New("empty parameter")
+	}
+
+	return new(big.Int).SetBytes(data), nil
+}
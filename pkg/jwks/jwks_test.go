@@ -0,0 +1,339 @@
+package jwks
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func encodeInt(n *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(n.Bytes())
+}
+
+func rsaJWK(kid string, key *rsa.PublicKey) jsonWebKey {
+	return jsonWebKey{Kid: kid, Kty: "RSA", Use: "sig", N: encodeInt(key.N), E: encodeInt(big.NewInt(int64(key.E)))}
+}
+
+func ecJWK(kid string, key *ecdsa.PublicKey) jsonWebKey {
+	return jsonWebKey{Kid: kid, Kty: "EC", Crv: key.Curve.Params().Name, X: encodeInt(key.X), Y: encodeInt(key.Y)}
+}
+
+func keySet(t *testing.T, keys ...jsonWebKey) []byte {
+	t.Helper()
+
+	data, err := json.Marshal(map[string]interface{}{"keys": keys})
+	if err != nil {
+		t.Fatalf("marshal the key set: %v", err)
+	}
+
+	return data
+}
+
+func generateKeys(t *testing.T) (*rsa.PrivateKey, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("rsa key: %v", err)
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("ec key: %v", err)
+	}
+
+	return rsaKey, ecKey
+}
+
+func TestParse(t *testing.T) {
+	rsaKey, ecKey := generateKeys(t)
+
+	offCurve := ecJWK("ec", &ecKey.PublicKey)
+	offCurve.Y = encodeInt(new(big.Int).Add(ecKey.Y, big.NewInt(1)))
+
+	otherCurve := ecJWK("ec", &ecKey.PublicKey)
+	otherCurve.Crv = "P-384"
+
+	badExponent := rsaJWK("rsa", &rsaKey.PublicKey)
+	badExponent.E = encodeInt(big.NewInt(1))
+
+	unknownCurve := ecJWK("ec", &ecKey.PublicKey)
+	unknownCurve.Crv = "secp256k1"
+
+	encryption := rsaJWK("enc", &rsaKey.PublicKey)
+	encryption.Use = "enc"
+
+	tests := []struct {
+		name     string
+		data     []byte
+		wantKids []string
+		wantErr  bool
+	}{
+		{
+			name:     "rsa and ec",
+			data:     keySet(t, rsaJWK("rsa", &rsaKey.PublicKey), ecJWK("ec", &ecKey.PublicKey)),
+			wantKids: []string{"rsa", "ec"},
+		},
+		{
+			name:     "encryption and symmetric keys skipped",
+			data:     keySet(t, rsaJWK("rsa", &rsaKey.PublicKey), encryption, jsonWebKey{Kid: "oct", Kty: "oct"}),
+			wantKids: []string{"rsa"},
+		},
+		{name: "point off the curve", data: keySet(t, offCurve), wantErr: true},
+		{name: "point of another curve", data: keySet(t, otherCurve), wantErr: true},
+		{name: "unknown curve", data: keySet(t, unknownCurve), wantErr: true},
+		{name: "invalid exponent", data: keySet(t, badExponent), wantErr: true},
+		{name: "empty modulus", data: keySet(t, jsonWebKey{Kid: "rsa", Kty: "RSA", E: "AQAB"}), wantErr: true},
+		{name: "not json", data: []byte("<html>"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := Parse(tt.data)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got the keys %v, want an error", keys)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+
+			if len(keys) != len(tt.wantKids) {
+				t.Errorf("got %d keys, want %v", len(keys), tt.wantKids)
+			}
+
+			for _, kid := range tt.wantKids {
+				if _, ok := keys[kid]; !ok {
+					t.Errorf("the key %q is missing", kid)
+				}
+			}
+		})
+	}
+}
+
+func TestKeySetFile(t *testing.T) {
+	rsaKey, _ := generateKeys(t)
+
+	file := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(file, keySet(t, rsaJWK("rsa", &rsaKey.PublicKey)), 0o600); err != nil {
+		t.Fatalf("write the key set: %v", err)
+	}
+
+	ks, err := NewKeySet(context.Background(), &ConfigDeps{File: file})
+	if err != nil {
+		t.Fatalf("NewKeySet: %v", err)
+	}
+
+	// the only key of the set is the key of an empty id
+	for _, kid := range []string{"rsa", ""} {
+		key, err := ks.Key(context.Background(), kid)
+		if err != nil {
+			t.Fatalf("Key(%q): %v", kid, err)
+		}
+
+		if !rsaKey.PublicKey.Equal(key) {
+			t.Errorf("Key(%q): got another key", kid)
+		}
+	}
+
+	if _, err = ks.Key(context.Background(), "other"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("unknown kid: got %v, want ErrKeyNotFound", err)
+	}
+
+	if _, err = NewKeySet(context.Background(), &ConfigDeps{}); !errors.Is(err, errNoSource) {
+		t.Errorf("no source: got %v, want errNoSource", err)
+	}
+}
+
+// jwksServer - serves the key set of the test, counting the reads
+type jwksServer struct {
+	mu      sync.Mutex
+	data    []byte
+	status  int
+	release chan struct{}
+	reads   atomic.Int32
+}
+
+func (s *jwksServer) set(data []byte, status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data, s.status = data, status
+}
+
+func (s *jwksServer) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	s.reads.Add(1)
+
+	if s.release != nil {
+		<-s.release
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w.WriteHeader(s.status)
+	_, _ = w.Write(s.data)
+}
+
+func newKeySetServer(t *testing.T, data []byte) (*jwksServer, *KeySet) {
+	t.Helper()
+
+	server := &jwksServer{data: data, status: http.StatusOK}
+
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	ks, err := NewKeySet(context.Background(), &ConfigDeps{URL: httpServer.URL})
+	if err != nil {
+		t.Fatalf("NewKeySet: %v", err)
+	}
+
+	return server, ks
+}
+
+// expire - make the keys look read the age ago
+func expire(ks *KeySet, age time.Duration) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	ks.loadedAt = time.Now().Add(-age)
+}
+
+func TestKeySetRefresh(t *testing.T) {
+	rsaKey, ecKey := generateKeys(t)
+	ctx := context.Background()
+
+	server, ks := newKeySetServer(t, keySet(t, rsaJWK("old", &rsaKey.PublicKey)))
+
+	// a new key is not looked for more often than minRefresh
+	server.set(keySet(t, rsaJWK("old", &rsaKey.PublicKey), ecJWK("new", &ecKey.PublicKey)), http.StatusOK)
+
+	if _, err := ks.Key(ctx, "new"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("new key before minRefresh: got %v, want ErrKeyNotFound", err)
+	}
+
+	if reads := server.reads.Load(); reads != 1 {
+		t.Errorf("the set is read %d times, want once", reads)
+	}
+
+	expire(ks, 2*minRefresh)
+
+	if _, err := ks.Key(ctx, "new"); err != nil {
+		t.Errorf("new key after minRefresh: %v", err)
+	}
+
+	// the known keys stay if the set can not be read
+	server.set([]byte("unavailable"), http.StatusServiceUnavailable)
+	expire(ks, 2*defaultRefresh)
+
+	if _, err := ks.Key(ctx, "old"); err != nil {
+		t.Errorf("known key after a failed read: %v", err)
+	}
+
+	expire(ks, 2*minRefresh)
+
+	if _, err := ks.Key(ctx, "rotated"); err == nil || errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("unknown key after a failed read: got %v, want the error of the read", err)
+	}
+}
+
+func TestKeySetConcurrentRefresh(t *testing.T) {
+	rsaKey, ecKey := generateKeys(t)
+	ctx := context.Background()
+
+	server, ks := newKeySetServer(t, keySet(t, rsaJWK("old", &rsaKey.PublicKey)))
+	server.set(keySet(t, rsaJWK("old", &rsaKey.PublicKey), ecJWK("new", &ecKey.PublicKey)), http.StatusOK)
+	server.release = make(chan struct{})
+
+	expire(ks, 2*minRefresh)
+
+	var wg sync.WaitGroup
+
+	errs := make(chan error, 10)
+
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := ks.Key(ctx, "new")
+			errs <- err
+		}()
+	}
+
+	// the known keys are served while the set is read
+	for server.reads.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	done := make(chan error)
+	go func() {
+		_, err := ks.Key(ctx, "old")
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("known key during the read: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the known key waits for the read of the set")
+	}
+
+	close(server.release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("new key: %v", err)
+		}
+	}
+
+	if reads := server.reads.Load(); reads != 2 {
+		t.Errorf("the set is read %d times, want twice", reads)
+	}
+}
+
+func TestKeySetWaitCanceled(t *testing.T) {
+	rsaKey, _ := generateKeys(t)
+
+	server, ks := newKeySetServer(t, keySet(t, rsaJWK("old", &rsaKey.PublicKey)))
+	server.release = make(chan struct{})
+	defer close(server.release)
+
+	expire(ks, 2*minRefresh)
+
+	go func() { _, _ = ks.Key(context.Background(), "new") }()
+
+	for server.reads.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := ks.Key(ctx, "new"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want context.DeadlineExceeded", err)
+	}
+}
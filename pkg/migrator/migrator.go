@@ -0,0 +1,167 @@
+package migrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strconv"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog"
+	"github.com/speakeasy-api/goose/v3"
+)
+
+var (
+	errUnknownCommand = errors.New("unknown migrate command")
+	errMissingArg     = errors.New("missing argument")
+)
+
+const Usage = `usage: migrate COMMAND [ARGS]
+
+commands:
+  up                   apply all pending migrations
+  up-to VERSION        apply migrations up to VERSION
+  down                 roll back the latest migration
+  down-to VERSION      roll back migrations down to VERSION, 0 rolls back everything
+  redo                 roll back the latest migration and apply it again
+  status               print the status of all migrations
+  version              print the current database version
+  create NAME [sql|go] create a new migration file in the migrations directory`
+
+type ConfigDeps struct {
+	FS  fs.FS
+	Dir string
+}
+
+type Migrator struct {
+	db  *sqlx.DB
+	dir string
+}
+
+// NewMigrator - creates a migrator reading migrations from the embedded file system
+func NewMigrator(db *sqlx.DB, deps *ConfigDeps) (*Migrator, error) {
+	goose.SetBaseFS(deps.FS)
+
+	if err := goose.SetDialect("postgres"); err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:  db,
+		dir: deps.Dir,
+	}, nil
+}
+
+// Up - apply all pending migrations
+func (m *Migrator) Up(ctx context.Context) error {
+	zerolog.Ctx(ctx).Info().Msg("applying migrations")
+
+	return goose.Up(m.db, m.dir)
+}
+
+// UpTo - apply migrations up to the version
+func (m *Migrator) UpTo(ctx context.Context, version int64) error {
+	zerolog.Ctx(ctx).Info().Msgf("applying migrations up to version: %d", version)
+
+	return goose.UpTo(m.db, m.dir, version)
+}
+
+// Down - roll back the latest migration
+func (m *Migrator) Down(ctx context.Context) error {
+	zerolog.Ctx(ctx).Info().Msg("rolling back the latest migration")
+
+	return goose.Down(m.db, m.dir)
+}
+
+// DownTo - roll back migrations down to the version
+func (m *Migrator) DownTo(ctx context.Context, version int64) error {
+	zerolog.Ctx(ctx).Info().Msgf("rolling back migrations down to version: %d", version)
+
+	return goose.DownTo(m.db, m.dir, version)
+}
+
+// Redo - roll back the latest migration and apply it again
+func (m *Migrator) Redo(ctx context.Context) error {
+	zerolog.Ctx(ctx).Info().Msg("redoing the latest migration")
+
+	return goose.Redo(m.db, m.dir)
+}
+
+// Status - print the status of all migrations
+func (m *Migrator) Status(ctx context.Context) error {
+	return goose.Status(m.db, m.dir)
+}
+
+// Version - get the current database version
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	return goose.GetDBVersion(m.db)
+}
+
+// Run - execute the migrate command with its arguments
+func (m *Migrator) Run(ctx context.Context, command string, args []string) error {
+	switch command {
+	case "up":
+		return m.Up(ctx)
+	case "up-to":
+		version, err := parseVersion(command, args)
+		if err != nil {
+			return err
+		}
+
+		return m.UpTo(ctx, version)
+	case "down":
+		return m.Down(ctx)
+	case "down-to":
+		version, err := parseVersion(command, args)
+		if err != nil {
+			return err
+		}
+
+		return m.DownTo(ctx, version)
+	case "redo":
+		return m.Redo(ctx)
+	case "status":
+		return m.Status(ctx)
+	case "version":
+		version, err := m.Version(ctx)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("version: %d\n", version)
+
+		return nil
+	}
+
+	return fmt.Errorf("%w: %s", errUnknownCommand, command)
+}
+
+// Create - write a new blank migration file to the directory on disk, type is "sql" or "go"
+func Create(dir string, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: create NAME [sql|go]", errMissingArg)
+	}
+
+	migrationType := "sql"
+	if len(args) > 1 {
+		migrationType = args[1]
+	}
+
+	goose.SetBaseFS(nil)
+
+	return goose.Create(nil, dir, args[0], migrationType)
+}
+
+func parseVersion(command string, args []string) (int64, error) {
+	if len(args) == 0 {
+		return 0, fmt.Errorf("%w: %s VERSION", errMissingArg, command)
+	}
+
+	version, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("version must be a number, got: %s", args[0])
+	}
+
+	return version, nil
+}
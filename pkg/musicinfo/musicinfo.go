@@ -1,36 +1,81 @@
 package musicinfo
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"time"
 )
 
+// The outcomes of the calls told to the Observe func
+const (
+	OutcomeOK              = "ok"
+	OutcomeError           = "error"
+	OutcomeBadStatus       = "bad_status"
+	OutcomeInvalidResponse = "invalid_response"
+)
+
+// DefaultTimeout - the time a call of the default client is given
+const DefaultTimeout = 5 * time.Second
+
 type SongDetail struct {
 	ReleaseData string
 	Text        string
 	Link        string
 }
 
+type ConfigDeps struct {
+	URL string
+	// HTTPClient - a client with the DefaultTimeout if nil
+	HTTPClient *http.Client
+	// Observe - told the outcome and the duration of every call, e.g. to export them as metrics
+	Observe func(outcome string, duration time.Duration)
+}
+
 type MusicInfo struct {
-	url string
+	url        string
+	httpClient *http.Client
+	observe    func(outcome string, duration time.Duration)
 }
 
-func NewMusicInfo(url string) *MusicInfo {
-	return &MusicInfo{
-		url: url,
+func NewMusicInfo(deps *ConfigDeps) *MusicInfo {
+	m := &MusicInfo{
+		url:        deps.URL,
+		httpClient: deps.HTTPClient,
+		observe:    deps.Observe,
+	}
+
+	if m.httpClient == nil {
+		m.httpClient = &http.Client{Timeout: DefaultTimeout}
+	}
+
+	if m.observe == nil {
+		m.observe = func(string, time.Duration) {}
 	}
+
+	return m
+}
+
+// Info - the details of the song, the context cancels the call and carries its trace
+func (m *MusicInfo) Info(ctx context.Context, group string, song string) (SongDetail, error) {
+	start := time.Now()
+
+	yr, outcome, err := m.info(ctx, group, song)
+	m.observe(outcome, time.Since(start))
+
+	return yr, err
 }
 
-func (m *MusicInfo) Info(group string, song string) (SongDetail, error) {
+func (m *MusicInfo) info(ctx context.Context, group string, song string) (SongDetail, string, error) {
 	var yr SongDetail
 
-	req, err := http.NewRequest(http.MethodGet, m.url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.url, nil)
 	if err != nil {
-		return yr, err
+		return yr, OutcomeError, err
 	}
 
 	req.URL.RawQuery = url.Values{
@@ -38,28 +83,28 @@ func (m *MusicInfo) Info(group string, song string) (SongDetail, error) {
 		"song":  {song},
 	}.Encode()
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := m.httpClient.Do(req)
 	if err != nil {
-		return yr, err
+		return yr, OutcomeError, err
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return yr, err
+		return yr, OutcomeError, err
 	}
 
 	err = resp.Body.Close()
 	if err != nil {
-		return yr, err
+		return yr, OutcomeError, err
 	}
 
-	if err = json.Unmarshal(body, &yr); err != nil {
-		return yr, err
+	if resp.StatusCode != http.StatusOK {
+		return yr, OutcomeBadStatus, errors.New(fmt.Sprint("Response status: ", resp.Status))
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return yr, errors.New(fmt.Sprint("Response status: ", resp.Status))
+	if err = json.Unmarshal(body, &yr); err != nil {
+		return yr, OutcomeInvalidResponse, err
 	}
 
-	return yr, nil
+	return yr, OutcomeOK, nil
 }
@@ -0,0 +1,71 @@
+package playlistformat
+
+import (
+	"encoding/json"
+	"io"
+)
+
+type jspfDocument struct {
+	Playlist jspfPlaylist `json:"playlist"`
+}
+
+type jspfPlaylist struct {
+	Title      string      `json:"title,omitempty"`
+	Creator    string      `json:"creator,omitempty"`
+	Annotation string      `json:"annotation,omitempty"`
+	Tracks     []jspfTrack `json:"track"`
+}
+
+type jspfTrack struct {
+	Location []string `json:"location,omitempty"`
+	Title    string   `json:"title,omitempty"`
+	Creator  string   `json:"creator,omitempty"`
+}
+
+func encodeJSPF(w io.Writer, playlist Playlist) error {
+	doc := jspfDocument{
+		Playlist: jspfPlaylist{
+			Title:      playlist.Title,
+			Creator:    playlist.Creator,
+			Annotation: playlist.Annotation,
+			Tracks:     make([]jspfTrack, 0, len(playlist.Tracks)),
+		},
+	}
+
+	for _, track := range playlist.Tracks {
+		doc.Playlist.Tracks = append(doc.Playlist.Tracks, jspfTrack{
+			Location: locations(track.Location),
+			Title:    track.Title,
+			Creator:  track.Creator,
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(doc)
+}
+
+func decodeJSPF(r io.Reader) (Playlist, error) {
+	var doc jspfDocument
+
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return Playlist{}, err
+	}
+
+	playlist := Playlist{
+		Title:      doc.Playlist.Title,
+		Creator:    doc.Playlist.Creator,
+		Annotation: doc.Playlist.Annotation,
+	}
+
+	for _, track := range doc.Playlist.Tracks {
+		playlist.Tracks = append(playlist.Tracks, Track{
+			Location: firstLocation(track.Location),
+			Title:    track.Title,
+			Creator:  track.Creator,
+		})
+	}
+
+	return playlist, nil
+}
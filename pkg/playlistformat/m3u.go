@@ -0,0 +1,155 @@
+package playlistformat
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	m3uHeader   = "#EXTM3U"
+	m3uPlaylist = "#PLAYLIST:"
+	m3uInfo     = "#EXTINF:"
+	// m3uSong - a track without a location, players skip it as a comment
+	m3uSong = "#EXTSONG:"
+	// m3uArtist - the creator of the next track, when "Creator - Title" cannot be split back into them
+	m3uArtist = "#EXTART:"
+)
+
+// encodeM3U - extended M3U in UTF-8, the track info is written as "Creator - Title". When the creator or
+// the title contains " - " itself, the creator is written on an #EXTART line before the info as well.
+// A track without a location is kept as an #EXTSONG comment, an #EXTINF with a blank location would be
+// dropped. Every value is written on one line: the control characters of the text become spaces and
+// the ASCII ones of the location are percent-encoded.
+func encodeM3U(w io.Writer, playlist Playlist) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, m3uHeader)
+
+	if playlist.Title != "" {
+		fmt.Fprintln(bw, m3uPlaylist+oneLine(playlist.Title))
+	}
+
+	for _, track := range playlist.Tracks {
+		creator, title := strings.TrimSpace(oneLine(track.Creator)), strings.TrimSpace(oneLine(track.Title))
+		info := joinCreatorTitle(creator, title)
+
+		if c, t := splitCreatorTitle(info); c != creator || t != title {
+			fmt.Fprintln(bw, m3uArtist+creator)
+		}
+
+		if track.Location == "" {
+			fmt.Fprintln(bw, m3uSong+info)
+			continue
+		}
+
+		fmt.Fprintf(bw, "%s-1,%s\n", m3uInfo, info)
+		fmt.Fprintln(bw, escapeLocation(track.Location))
+	}
+
+	return bw.Flush()
+}
+
+func decodeM3U(r io.Reader) (Playlist, error) {
+	var (
+		playlist Playlist
+		pending  Track
+		// artist - the creator of the pending track was read from an #EXTART line
+		artist bool
+	)
+
+	// info - the creator and the title of "Creator - Title", knowing the creator if it was given on its own
+	info := func(text string) (string, string) {
+		if !artist {
+			return splitCreatorTitle(text)
+		}
+
+		artist = false
+
+		if pending.Creator == "" {
+			return "", strings.TrimSpace(text)
+		}
+
+		return pending.Creator, strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(text), pending.Creator+" - "))
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\uFEFF"))
+
+		switch {
+		case line == "" || line == m3uHeader:
+		case strings.HasPrefix(line, m3uPlaylist):
+			playlist.Title = strings.TrimPrefix(line, m3uPlaylist)
+		case strings.HasPrefix(line, m3uInfo):
+			// #EXTINF:duration,Creator - Title
+			text := strings.TrimPrefix(line, m3uInfo)
+			if i := strings.Index(text, ","); i >= 0 {
+				text = text[i+1:]
+			}
+
+			pending.Creator, pending.Title = info(text)
+		case strings.HasPrefix(line, m3uArtist):
+			pending.Creator = strings.TrimSpace(strings.TrimPrefix(line, m3uArtist))
+			artist = true
+		case strings.HasPrefix(line, m3uSong):
+			creator, title := info(strings.TrimPrefix(line, m3uSong))
+			playlist.Tracks = append(playlist.Tracks, Track{Creator: creator, Title: title})
+			pending, artist = Track{}, false
+		case strings.HasPrefix(line, "#"):
+		default:
+			pending.Location = line
+			playlist.Tracks = append(playlist.Tracks, pending)
+			pending, artist = Track{}, false
+		}
+	}
+
+	return playlist, scanner.Err()
+}
+
+func joinCreatorTitle(creator, title string) string {
+	if creator == "" {
+		return title
+	}
+
+	return creator + " - " + title
+}
+
+func splitCreatorTitle(info string) (string, string) {
+	creator, title, ok := strings.Cut(info, " - ")
+	if !ok {
+		return "", strings.TrimSpace(info)
+	}
+
+	return strings.TrimSpace(creator), strings.TrimSpace(title)
+}
+
+// oneLine - the text with its control characters, line breaks included, replaced with spaces
+func oneLine(text string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+
+		return r
+	}, text)
+}
+
+// escapeLocation - the location with its ASCII control characters percent-encoded, so it stays on its line
+func escapeLocation(location string) string {
+	var b strings.Builder
+
+	for _, r := range location {
+		if unicode.IsControl(r) && r < utf8.RuneSelf {
+			fmt.Fprintf(&b, "%%%02X", r)
+			continue
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,76 @@
+package playlistformat
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Supported playlist formats
+const (
+	M3U8 = "m3u8"
+	XSPF = "xspf"
+	JSPF = "jspf"
+)
+
+var errUnknownFormat = errors.New("unknown playlist format")
+
+type Track struct {
+	Location string
+	Title    string
+	Creator  string
+}
+
+type Playlist struct {
+	Title      string
+	Creator    string
+	Annotation string
+	Tracks     []Track
+}
+
+// ContentType - the MIME type of the format
+func ContentType(format string) string {
+	switch format {
+	case M3U8:
+		return "audio/x-mpegurl"
+	case XSPF:
+		return "application/xspf+xml"
+	case JSPF:
+		return "application/jspf+json"
+	}
+
+	return "application/octet-stream"
+}
+
+// Encode - write the playlist in the format
+func Encode(w io.Writer, format string, playlist Playlist) error {
+	switch format {
+	case M3U8:
+		return encodeM3U(w, playlist)
+	case XSPF:
+		return encodeXSPF(w, playlist)
+	case JSPF:
+		return encodeJSPF(w, playlist)
+	}
+
+	return fmt.Errorf("%w: %s", errUnknownFormat, format)
+}
+
+// Decode - read a playlist in the format
+func Decode(r io.Reader, format string) (Playlist, error) {
+	switch format {
+	case M3U8:
+		return decodeM3U(r)
+	case XSPF:
+		return decodeXSPF(r)
+	case JSPF:
+		return decodeJSPF(r)
+	}
+
+	return Playlist{}, fmt.Errorf("%w: %s", errUnknownFormat, format)
+}
+
+// IsSupported - reports whether the format can be encoded and decoded
+func IsSupported(format string) bool {
+	return format == M3U8 || format == XSPF || format == JSPF
+}
@@ -0,0 +1,102 @@
+package playlistformat
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	playlist := Playlist{
+		Title: "Road trip",
+		Tracks: []Track{
+			{Location: "https://example.com/supermassive", Title: "Supermassive Black Hole", Creator: "Muse"},
+			{Title: "Without a link", Creator: "Muse"},
+			{Location: "https://example.com/untitled", Title: "No group"},
+			{Title: "No group and no link"},
+			{Location: "https://example.com/dash", Title: "Title - with a dash", Creator: "Group - with a dash"},
+			{Title: "Dash - in the title only"},
+			{Location: "https://example.com/dash-title", Title: "Dash - in the title", Creator: "Muse"},
+		},
+	}
+
+	for _, format := range []string{M3U8, XSPF, JSPF} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			if err := Encode(&buf, format, playlist); err != nil {
+				t.Fatalf("Encode: %v", err)
+			}
+
+			got, err := Decode(&buf, format)
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+
+			if !reflect.DeepEqual(got.Tracks, playlist.Tracks) {
+				t.Errorf("tracks: got %+v, want %+v", got.Tracks, playlist.Tracks)
+			}
+
+			if got.Title != playlist.Title {
+				t.Errorf("title: got %q, want %q", got.Title, playlist.Title)
+			}
+		})
+	}
+}
+
+func TestDecodeM3U(t *testing.T) {
+	file := "\uFEFF#EXTM3U\n" +
+		"#EXTINF:215,Muse - Uprising\n" +
+		"uprising.mp3\n" +
+		"\n" +
+		"#EXTSONG:Muse - Hysteria\n" +
+		"plain.mp3\n"
+
+	want := []Track{
+		{Location: "uprising.mp3", Title: "Uprising", Creator: "Muse"},
+		{Title: "Hysteria", Creator: "Muse"},
+		{Location: "plain.mp3"},
+	}
+
+	got, err := Decode(bytes.NewBufferString(file), M3U8)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+
+	if !reflect.DeepEqual(got.Tracks, want) {
+		t.Errorf("got %+v, want %+v", got.Tracks, want)
+	}
+}
+
+func TestEncodeM3UOneLine(t *testing.T) {
+	playlist := Playlist{
+		Title: "Line\nbreak",
+		Tracks: []Track{
+			{Location: "https://example.com/a\n#EXTINF:-1,Injected\nhttps://evil.example", Title: "Two\nlines", Creator: "Muse"},
+		},
+	}
+
+	var buf bytes.Buffer
+
+	if err := Encode(&buf, M3U8, playlist); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	want := "#EXTM3U\n" +
+		"#PLAYLIST:Line break\n" +
+		"#EXTINF:-1,Muse - Two lines\n" +
+		"https://example.com/a%0A#EXTINF:-1,Injected%0Ahttps://evil.example\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	got, err := Decode(strings.NewReader(buf.String()), M3U8)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+
+	if len(got.Tracks) != 1 {
+		t.Errorf("tracks: got %d, want 1", len(got.Tracks))
+	}
+}
@@ -0,0 +1,92 @@
+package playlistformat
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+const xspfNamespace = "http://xspf.org/ns/0/"
+
+type xspfPlaylist struct {
+	XMLName    xml.Name    `xml:"playlist"`
+	Version    string      `xml:"version,attr"`
+	Namespace  string      `xml:"xmlns,attr"`
+	Title      string      `xml:"title,omitempty"`
+	Creator    string      `xml:"creator,omitempty"`
+	Annotation string      `xml:"annotation,omitempty"`
+	Tracks     []xspfTrack `xml:"trackList>track"`
+}
+
+type xspfTrack struct {
+	Location []string `xml:"location"`
+	Title    string   `xml:"title,omitempty"`
+	Creator  string   `xml:"creator,omitempty"`
+}
+
+func encodeXSPF(w io.Writer, playlist Playlist) error {
+	doc := xspfPlaylist{
+		Version:    "1",
+		Namespace:  xspfNamespace,
+		Title:      playlist.Title,
+		Creator:    playlist.Creator,
+		Annotation: playlist.Annotation,
+		Tracks:     make([]xspfTrack, 0, len(playlist.Tracks)),
+	}
+
+	for _, track := range playlist.Tracks {
+		doc.Tracks = append(doc.Tracks, xspfTrack{
+			Location: locations(track.Location),
+			Title:    track.Title,
+			Creator:  track.Creator,
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	return enc.Encode(doc)
+}
+
+func decodeXSPF(r io.Reader) (Playlist, error) {
+	var doc xspfPlaylist
+
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return Playlist{}, err
+	}
+
+	playlist := Playlist{
+		Title:      doc.Title,
+		Creator:    doc.Creator,
+		Annotation: doc.Annotation,
+	}
+
+	for _, track := range doc.Tracks {
+		playlist.Tracks = append(playlist.Tracks, Track{
+			Location: firstLocation(track.Location),
+			Title:    track.Title,
+			Creator:  track.Creator,
+		})
+	}
+
+	return playlist, nil
+}
+
+func locations(location string) []string {
+	if location == "" {
+		return nil
+	}
+
+	return []string{location}
+}
+
+func firstLocation(locations []string) string {
+	if len(locations) == 0 {
+		return ""
+	}
+
+	return locations[0]
+}
@@ -0,0 +1,143 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// newTestStore - a store with a clock moved only by the returned func
+func newTestStore() (*MemoryStore, func(time.Duration)) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	s := NewMemoryStore()
+	s.now = func() time.Time { return now }
+
+	return s, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestMemoryStoreTake(t *testing.T) {
+	// a token every second
+	limit := Limit{Requests: 10, Period: 10 * time.Second}
+
+	type step struct {
+		// advance - the time passed before the take
+		advance time.Duration
+		tokens  int
+		want    Result
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "full bucket",
+			steps: []step{
+				{tokens: 1, want: Result{Allowed: true, Limit: limit, Remaining: 9, Reset: time.Second}},
+				{tokens: 4, want: Result{Allowed: true, Limit: limit, Remaining: 5, Reset: 5 * time.Second}},
+			},
+		},
+		{
+			name: "empty bucket",
+			steps: []step{
+				{tokens: 10, want: Result{Allowed: true, Limit: limit, Remaining: 0, Reset: 10 * time.Second}},
+				{tokens: 1, want: Result{Limit: limit, Remaining: 0, Reset: 10 * time.Second, RetryAfter: time.Second}},
+			},
+		},
+		{
+			name: "costly request waits for all its tokens",
+			steps: []step{
+				{tokens: 8, want: Result{Allowed: true, Limit: limit, Remaining: 2, Reset: 8 * time.Second}},
+				{tokens: 5, want: Result{Limit: limit, Remaining: 2, Reset: 8 * time.Second, RetryAfter: 3 * time.Second}},
+				{advance: 3 * time.Second, tokens: 5, want: Result{Allowed: true, Limit: limit, Remaining: 0, Reset: 10 * time.Second}},
+			},
+		},
+		{
+			name: "refill",
+			steps: []step{
+				{tokens: 10, want: Result{Allowed: true, Limit: limit, Remaining: 0, Reset: 10 * time.Second}},
+				{advance: 3500 * time.Millisecond, tokens: 1, want: Result{Allowed: true, Limit: limit, Remaining: 2, Reset: 7500 * time.Millisecond}},
+			},
+		},
+		{
+			name: "refill stops at the capacity",
+			steps: []step{
+				{tokens: 5, want: Result{Allowed: true, Limit: limit, Remaining: 5, Reset: 5 * time.Second}},
+				{advance: time.Hour, tokens: 1, want: Result{Allowed: true, Limit: limit, Remaining: 9, Reset: time.Second}},
+			},
+		},
+		{
+			name: "taking 0 tokens leaves the bucket",
+			steps: []step{
+				{tokens: 0, want: Result{Allowed: true, Limit: limit, Remaining: 10}},
+				{tokens: 10, want: Result{Allowed: true, Limit: limit, Remaining: 0, Reset: 10 * time.Second}},
+				{tokens: 0, want: Result{Limit: limit, Remaining: 0, Reset: 10 * time.Second, RetryAfter: time.Second}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, advance := newTestStore()
+
+			for i, st := range tt.steps {
+				advance(st.advance)
+
+				got, err := s.Take(context.Background(), "key", limit, st.tokens)
+				if err != nil {
+					t.Fatalf("step %d: Take: %v", i, err)
+				}
+
+				if got != st.want {
+					t.Errorf("step %d: got %+v, want %+v", i, got, st.want)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryStoreKeys(t *testing.T) {
+	limit := Limit{Requests: 1, Period: time.Second}
+	s, _ := newTestStore()
+
+	if res, _ := s.Take(context.Background(), "a", limit, 1); !res.Allowed {
+		t.Fatalf("first take of a: got %+v", res)
+	}
+
+	if res, _ := s.Take(context.Background(), "b", limit, 1); !res.Allowed {
+		t.Errorf("the bucket of b is taken by a: got %+v", res)
+	}
+
+	if res, _ := s.Take(context.Background(), "a", limit, 1); res.Allowed {
+		t.Errorf("second take of a: got %+v", res)
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	limit := Limit{Requests: 10, Period: 10 * time.Second}
+	s, advance := newTestStore()
+	ctx := context.Background()
+
+	_, _ = s.Take(ctx, "idle", limit, 10)
+	advance(sweepInterval)
+	_, _ = s.Take(ctx, "busy", limit, 10)
+
+	// the idle bucket is full by now, the busy one is still refilling
+	advance(sweepInterval - 5*time.Second)
+	_, _ = s.Take(ctx, "other", limit, 0)
+
+	if _, ok := s.buckets["idle"]; ok {
+		t.Error("the full bucket is not swept")
+	}
+
+	if _, ok := s.buckets["busy"]; !ok {
+		t.Error("the bucket still refilling is swept")
+	}
+
+	// a swept bucket is the same as a full one
+	res, _ := s.Take(ctx, "idle", limit, 1)
+	if want := (Result{Allowed: true, Limit: limit, Remaining: 9, Reset: time.Second}); res != want {
+		t.Errorf("got %+v, want %+v", res, want)
+	}
+}
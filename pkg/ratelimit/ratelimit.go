@@ -0,0 +1,157 @@
+// Package ratelimit - token buckets of the clients: a bucket holds up to Limit.Requests tokens and
+// is refilled with all of them over Limit.Period, a request takes one token or more.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// The headers of the limits (draft-ietf-httpapi-ratelimit-headers)
+const (
+	HeaderLimit     = "RateLimit-Limit"
+	HeaderRemaining = "RateLimit-Remaining"
+	HeaderReset     = "RateLimit-Reset"
+	HeaderPolicy    = "RateLimit-Policy"
+	HeaderRetry     = "Retry-After"
+)
+
+type Limit struct {
+	// Requests - the size of the bucket, 0 turns the limit off
+	Requests int
+	// Period - the time the empty bucket takes to be full again
+	Period time.Duration
+}
+
+// Off - the limit lets all the requests through
+func (l Limit) Off() bool {
+	return l.Requests <= 0 || l.Period <= 0
+}
+
+// rate - the tokens added to the bucket every second
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// Result - the bucket after a request took its tokens, or failed to
+type Result struct {
+	Allowed bool
+	Limit   Limit
+	// Remaining - the whole tokens left in the bucket
+	Remaining int
+	// Reset - the time until the bucket is full
+	Reset time.Duration
+	// RetryAfter - the time until the bucket has the tokens of the rejected request
+	RetryAfter time.Duration
+}
+
+// Header - the RateLimit-* headers of the result, and Retry-After if the request is rejected
+func (r Result) Header() http.Header {
+	header := make(http.Header)
+
+	if r.Limit.Off() {
+		return header
+	}
+
+	header.Set(HeaderLimit, strconv.Itoa(r.Limit.Requests))
+	header.Set(HeaderRemaining, strconv.Itoa(r.Remaining))
+	header.Set(HeaderReset, strconv.Itoa(seconds(r.Reset)))
+	header.Set(HeaderPolicy, fmt.Sprintf("%d;w=%d", r.Limit.Requests, seconds(r.Limit.Period)))
+
+	if !r.Allowed {
+		header.Set(HeaderRetry, strconv.Itoa(max(seconds(r.RetryAfter), 1)))
+	}
+
+	return header
+}
+
+// ErrTooCostly - the request costs more tokens than the whole bucket holds, so it is never allowed
+var ErrTooCostly = errors.New("ratelimit: the request costs more than the whole bucket")
+
+// Store - keeps the buckets, e.g. in memory or in a store shared by the instances of the server
+type Store interface {
+	// Take - take the tokens from the bucket of the key if it has enough of them. Taking 0 tokens only
+	// looks at the bucket, it is allowed if the bucket has a token left.
+	Take(ctx context.Context, key string, limit Limit, tokens int) (Result, error)
+}
+
+type ConfigDeps struct {
+	// Store - a MemoryStore if nil
+	Store Store
+	// Read, Write - the limits of the requests reading and changing the library
+	Read  Limit
+	Write Limit
+	// Failures - the limit of the failed authentications of an IP address
+	Failures Limit
+}
+
+// Limiter - a read bucket and a write bucket for every client, and a bucket of the failed authentications
+// for every IP address
+type Limiter struct {
+	store    Store
+	read     Limit
+	write    Limit
+	failures Limit
+}
+
+func NewLimiter(deps *ConfigDeps) *Limiter {
+	store := deps.Store
+	if store == nil {
+		store = NewMemoryStore()
+	}
+
+	return &Limiter{
+		store:    store,
+		read:     deps.Read,
+		write:    deps.Write,
+		failures: deps.Failures,
+	}
+}
+
+// Take - take the tokens of the request from the read or the write bucket of the client. A request costing
+// more than the whole bucket is ErrTooCostly.
+func (l *Limiter) Take(ctx context.Context, client string, write bool, tokens int) (Result, error) {
+	limit, bucket := l.read, "read"
+	if write {
+		limit, bucket = l.write, "write"
+	}
+
+	if limit.Off() {
+		return Result{Allowed: true, Limit: limit}, nil
+	}
+
+	tokens = max(tokens, 1)
+	if tokens > limit.Requests {
+		return Result{Limit: limit}, fmt.Errorf("%w: %d tokens of %d", ErrTooCostly, tokens, limit.Requests)
+	}
+
+	return l.store.Take(ctx, bucket+":"+client, limit, tokens)
+}
+
+// CheckFailures - whether the failed authentications of the address have left a token in its bucket
+func (l *Limiter) CheckFailures(ctx context.Context, address string) (Result, error) {
+	if l.failures.Off() {
+		return Result{Allowed: true, Limit: l.failures}, nil
+	}
+
+	return l.store.Take(ctx, "failures:"+address, l.failures, 0)
+}
+
+// AddFailure - take a token of the bucket of the address for a failed authentication
+func (l *Limiter) AddFailure(ctx context.Context, address string) (Result, error) {
+	if l.failures.Off() {
+		return Result{Allowed: true, Limit: l.failures}, nil
+	}
+
+	return l.store.Take(ctx, "failures:"+address, l.failures, 1)
+}
+
+// seconds - the duration rounded up to whole seconds
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
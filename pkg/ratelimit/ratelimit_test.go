@@ -0,0 +1,125 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestResultHeader(t *testing.T) {
+	limit := Limit{Requests: 60, Period: time.Minute}
+
+	tests := []struct {
+		name   string
+		result Result
+		want   map[string]string
+	}{
+		{
+			name:   "limit off",
+			result: Result{Allowed: true},
+			want:   map[string]string{},
+		},
+		{
+			name:   "allowed",
+			result: Result{Allowed: true, Limit: limit, Remaining: 59, Reset: 1500 * time.Millisecond},
+			want: map[string]string{
+				HeaderLimit:     "60",
+				HeaderRemaining: "59",
+				HeaderReset:     "2",
+				HeaderPolicy:    "60;w=60",
+			},
+		},
+		{
+			name:   "rejected",
+			result: Result{Limit: limit, Remaining: 0, Reset: time.Minute, RetryAfter: 2500 * time.Millisecond},
+			want: map[string]string{
+				HeaderLimit:     "60",
+				HeaderRemaining: "0",
+				HeaderReset:     "60",
+				HeaderPolicy:    "60;w=60",
+				HeaderRetry:     "3",
+			},
+		},
+		{
+			name:   "rejected retry at least a second",
+			result: Result{Limit: limit, Reset: time.Minute},
+			want: map[string]string{
+				HeaderLimit:     "60",
+				HeaderRemaining: "0",
+				HeaderReset:     "60",
+				HeaderPolicy:    "60;w=60",
+				HeaderRetry:     "1",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.result.Header()
+			if len(got) != len(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+
+			for key, value := range tt.want {
+				if got.Get(key) != value {
+					t.Errorf("%s: got %q, want %q", key, got.Get(key), value)
+				}
+			}
+		})
+	}
+}
+
+func TestLimiterTake(t *testing.T) {
+	ctx := context.Background()
+	l := NewLimiter(&ConfigDeps{
+		Read:     Limit{Requests: 10, Period: time.Minute},
+		Write:    Limit{Requests: 1, Period: time.Minute},
+		Failures: Limit{Requests: 2, Period: time.Minute},
+	})
+
+	if _, err := l.Take(ctx, "client", false, 11); !errors.Is(err, ErrTooCostly) {
+		t.Errorf("request costing more than the bucket: got %v, want ErrTooCostly", err)
+	}
+
+	if res, err := l.Take(ctx, "client", true, 1); err != nil || !res.Allowed {
+		t.Fatalf("first write: got %+v, %v", res, err)
+	}
+
+	if res, _ := l.Take(ctx, "client", true, 1); res.Allowed {
+		t.Error("second write is allowed")
+	}
+
+	if res, _ := l.Take(ctx, "client", false, 10); !res.Allowed {
+		t.Error("the read bucket is taken by the writes")
+	}
+
+	for i := 0; i < 2; i++ {
+		if res, _ := l.CheckFailures(ctx, "10.0.0.1"); !res.Allowed {
+			t.Fatalf("check after %d failures is rejected", i)
+		}
+
+		_, _ = l.AddFailure(ctx, "10.0.0.1")
+	}
+
+	if res, _ := l.CheckFailures(ctx, "10.0.0.1"); res.Allowed {
+		t.Error("check after all the failures is allowed")
+	}
+
+	if res, _ := l.CheckFailures(ctx, "10.0.0.2"); !res.Allowed {
+		t.Error("the failures of another address are counted")
+	}
+}
+
+func TestLimiterOff(t *testing.T) {
+	l := NewLimiter(&ConfigDeps{})
+
+	res, err := l.Take(context.Background(), "client", true, 1000)
+	if err != nil || !res.Allowed {
+		t.Errorf("got %+v, %v", res, err)
+	}
+
+	if len(res.Header()) != 0 {
+		t.Errorf("headers of the limit off: %v", res.Header())
+	}
+}
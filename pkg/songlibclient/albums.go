@@ -0,0 +1,59 @@
+package songlibclient
+
+import "context"
+
+// ListAlbums - a page of the albums in id order, of the music group if it is not empty
+func (c *Client) ListAlbums(ctx context.Context, page Page, group string) ([]Album, error) {
+	var res []Album
+
+	query := page.values()
+	setNotEmpty(query, "group", group)
+
+	return res, c.call(ctx, c.newRequest(routeListAlbums).withQuery(query), &res)
+}
+
+func (c *Client) CreateAlbum(ctx context.Context, album CreateAlbum) (Album, error) {
+	var res Album
+
+	req, err := c.newRequest(routeCreateAlbum).withJSON(album)
+	if err != nil {
+		return res, err
+	}
+
+	return res, c.call(ctx, req, &res)
+}
+
+// GetAlbum - the album with its ordered track list
+func (c *Client) GetAlbum(ctx context.Context, id int) (Album, error) {
+	var res Album
+
+	return res, c.call(ctx, c.newRequest(routeGetAlbum, id), &res)
+}
+
+func (c *Client) UpdateAlbum(ctx context.Context, id int, album UpdateAlbum) (Album, error) {
+	var res Album
+
+	req, err := c.newRequest(routeUpdateAlbum, id).withJSON(album)
+	if err != nil {
+		return res, err
+	}
+
+	return res, c.call(ctx, req, &res)
+}
+
+// DeleteAlbum - delete the album, its songs stay in the library
+func (c *Client) DeleteAlbum(ctx context.Context, id int) error {
+	return c.call(ctx, c.newRequest(routeDeleteAlbum, id), nil)
+}
+
+// SetAlbumTracks - replace the track list, the track number is the position of the song id in the list
+func (c *Client) SetAlbumTracks(ctx context.Context, id int, songIds []int) (Album, error) {
+	var res Album
+
+	req, err := c.newRequest(routeSetAlbumTracks, id).withJSON(map[string][]int{"tracks": songIds})
+	if err != nil {
+		return res, err
+	}
+
+	return res, c.call(ctx, req, &res)
+}
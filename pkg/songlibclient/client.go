@@ -0,0 +1,298 @@
+// Package songlibclient - the Go client of the /api/v2 routes of the song library, the routes are checked
+// against docs/swagger.yaml by the tests of the package.
+package songlibclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	basePath         = "/api/v2"
+	headerApiKey     = "X-API-Key"
+	defaultUserAgent = "songlibclient"
+	maxRetryDelay    = 30 * time.Second
+)
+
+type ConfigDeps struct {
+	// BaseURL - the address of the server, e.g. http://localhost:8080
+	BaseURL string
+	// HTTPClient - http.DefaultClient if nil
+	HTTPClient *http.Client
+	// MaxRetries - the number of times a failed call is repeated
+	MaxRetries int
+	// RetryDelay - the delay before the first retry, it doubles with every next one up to 30s.
+	// With 0 the calls are retried immediately.
+	RetryDelay time.Duration
+	// Header - sent with every call, e.g. Accept-Language for the validation messages
+	Header http.Header
+	// APIKey - sent in the X-API-Key header, every route but the documentation needs one
+	APIKey string
+	// BearerToken - a token of the SSO sent in the Authorization header instead of the API key
+	BearerToken string
+}
+
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	maxRetries int
+	retryDelay time.Duration
+	header     http.Header
+}
+
+func NewClient(deps *ConfigDeps) (*Client, error) {
+	baseURL, err := url.Parse(strings.TrimSuffix(deps.BaseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("songlibclient: base url: %w", err)
+	}
+
+	if baseURL.Scheme == "" || baseURL.Host == "" {
+		return nil, fmt.Errorf("songlibclient: base url must be absolute: %q", deps.BaseURL)
+	}
+
+	httpClient := deps.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	header := deps.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+
+	if deps.APIKey != "" {
+		header.Set(headerApiKey, deps.APIKey)
+	}
+
+	if deps.BearerToken != "" {
+		header.Set("Authorization", "Bearer "+deps.BearerToken)
+	}
+
+	if header.Get("User-Agent") == "" {
+		header.Set("User-Agent", defaultUserAgent)
+	}
+
+	return &Client{
+		baseURL:    baseURL,
+		httpClient: httpClient,
+		maxRetries: deps.MaxRetries,
+		retryDelay: deps.RetryDelay,
+		header:     header,
+	}, nil
+}
+
+// request - one call of a route. The body is either JSON sent again on every retry or a stream,
+// a call with a stream is never retried.
+type request struct {
+	route       route
+	params      []interface{}
+	query       url.Values
+	body        []byte
+	stream      io.Reader
+	contentType string
+}
+
+func (c *Client) newRequest(route route, params ...interface{}) *request {
+	return &request{route: route, params: params}
+}
+
+func (r *request) withQuery(query url.Values) *request {
+	r.query = query
+	return r
+}
+
+func (r *request) withJSON(body interface{}) (*request, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("songlibclient: encode request: %w", err)
+	}
+
+	r.body = data
+	r.contentType = "application/json"
+
+	return r, nil
+}
+
+func (r *request) withStream(body io.Reader, contentType string) *request {
+	r.stream = body
+	r.contentType = contentType
+
+	return r
+}
+
+// call - send the request and decode the JSON response into out, out may be nil
+func (c *Client) call(ctx context.Context, req *request, out interface{}) error {
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return decodeError(resp)
+	}
+
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+
+	if err = json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("songlibclient: decode response: %w", err)
+	}
+
+	return nil
+}
+
+// stream - send the request and return the body of a successful response, the caller closes it
+func (c *Client) stream(ctx context.Context, req *request) (io.ReadCloser, error) {
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		defer resp.Body.Close()
+		return nil, decodeError(resp)
+	}
+
+	return resp.Body, nil
+}
+
+// send - send the request, repeating it after a network error or a response the server asks to repeat.
+// The response is returned whatever its status.
+func (c *Client) send(ctx context.Context, req *request) (*http.Response, error) {
+	target := *c.baseURL
+	target.RawPath = c.baseURL.EscapedPath() + basePath + req.route.expand(req.params...)
+	target.Path, _ = url.PathUnescape(target.RawPath)
+	target.RawQuery = req.query.Encode()
+
+	for attempt := 0; ; attempt++ {
+		var body io.Reader
+		switch {
+		case req.stream != nil:
+			body = req.stream
+		case req.body != nil:
+			body = bytes.NewReader(req.body)
+		}
+
+		httpReq, err := http.NewRequestWithContext(ctx, req.route.method, target.String(), body)
+		if err != nil {
+			return nil, fmt.Errorf("songlibclient: %w", err)
+		}
+
+		for key, values := range c.header {
+			httpReq.Header[key] = values
+		}
+
+		if req.contentType != "" {
+			httpReq.Header.Set("Content-Type", req.contentType)
+		}
+
+		resp, err := c.httpClient.Do(httpReq)
+
+		retry, delay := c.shouldRetry(req, resp, err, attempt)
+		if !retry {
+			if err != nil {
+				return nil, fmt.Errorf("songlibclient: %s %s: %w", req.route.method, req.route.path, err)
+			}
+
+			return resp, nil
+		}
+
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// shouldRetry - a call is repeated after a network error, 502, 503 or 504 if the method is idempotent,
+// and after 429 whatever the method, since the server did not handle the call
+func (c *Client) shouldRetry(req *request, resp *http.Response, err error, attempt int) (bool, time.Duration) {
+	if attempt >= c.maxRetries || req.stream != nil {
+		return false, 0
+	}
+
+	// the doubled delay is capped before the shift could overflow
+	delay := maxRetryDelay
+	if c.retryDelay <= maxRetryDelay>>attempt {
+		delay = c.retryDelay << attempt
+	}
+
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return false, 0
+		}
+
+		return idempotent(req.route.method), delay
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		if !idempotent(req.route.method) {
+			return false, 0
+		}
+	default:
+		return false, 0
+	}
+
+	if after, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+		delay = min(after, maxRetryDelay)
+	}
+
+	return true, delay
+}
+
+// mediaType - the media type of the response without its parameters
+func mediaType(resp *http.Response) string {
+	value, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return value
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	return false
+}
+
+// retryAfter - the delay of the Retry-After header, in seconds or as an HTTP date
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+
+	return 0, false
+}
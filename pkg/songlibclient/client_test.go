@@ -0,0 +1,441 @@
+package songlibclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(&ConfigDeps{
+		BaseURL:    server.URL,
+		MaxRetries: 2,
+		RetryDelay: time.Millisecond,
+		Header:     http.Header{"Accept-Language": {"ru"}},
+		APIKey:     "sl_test",
+	})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	return client
+}
+
+func writeProblem(w http.ResponseWriter, problem Problem) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(problem.Status)
+	_ = json.NewEncoder(w).Encode(problem)
+}
+
+// TestRoutesMatchSwagger - the client covers every /api/v2 route of docs/swagger.yaml and no other
+func TestRoutesMatchSwagger(t *testing.T) {
+	data, err := os.ReadFile("../../docs/swagger.yaml")
+	if err != nil {
+		t.Fatalf("read swagger: %v", err)
+	}
+
+	var spec struct {
+		Paths map[string]map[string]interface{} `yaml:"paths"`
+	}
+
+	if err = yaml.Unmarshal(data, &spec); err != nil {
+		t.Fatalf("parse swagger: %v", err)
+	}
+
+	documented := make(map[string]bool)
+
+	for path, operations := range spec.Paths {
+		if !strings.HasPrefix(path, basePath+"/") {
+			continue
+		}
+
+		for method := range operations {
+			documented[strings.ToUpper(method)+" "+strings.TrimPrefix(path, basePath)] = true
+		}
+	}
+
+	covered := make(map[string]bool)
+
+	for _, r := range routes {
+		key := r.method + " " + r.path
+		if covered[key] {
+			t.Errorf("route %s is listed twice", key)
+		}
+
+		covered[key] = true
+	}
+
+	var missing, unknown []string
+
+	for key := range documented {
+		if !covered[key] {
+			missing = append(missing, key)
+		}
+	}
+
+	for key := range covered {
+		if !documented[key] {
+			unknown = append(unknown, key)
+		}
+	}
+
+	sort.Strings(missing)
+	sort.Strings(unknown)
+
+	if len(missing) > 0 {
+		t.Errorf("routes of swagger.yaml without a client method: %v", missing)
+	}
+
+	if len(unknown) > 0 {
+		t.Errorf("client routes missing from swagger.yaml: %v", unknown)
+	}
+}
+
+func TestCreateSong(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v2/songs" {
+			t.Errorf("unexpected call %s %s", r.Method, r.URL.Path)
+		}
+
+		if got := r.Header.Get("Content-Type"); got != "application/json" {
+			t.Errorf("content type = %q", got)
+		}
+
+		if got := r.Header.Get("Accept-Language"); got != "ru" {
+			t.Errorf("accept language = %q", got)
+		}
+
+		if got := r.Header.Get("X-API-Key"); got != "sl_test" {
+			t.Errorf("api key = %q", got)
+		}
+
+		var req CreateSong
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Location", "/api/v2/songs/7")
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(Song{Id: 7, GroupSong: req.Group, Song: req.Song, Artists: req.Artists})
+	})
+
+	song, err := client.CreateSong(context.Background(), CreateSong{
+		Group:   "Muse",
+		Song:    "Hysteria",
+		Artists: []SongArtist{{Group: "Muse", Role: RolePrimary}},
+	})
+	if err != nil {
+		t.Fatalf("CreateSong: %v", err)
+	}
+
+	if song.Id != 7 || song.GroupSong != "Muse" || song.Song != "Hysteria" || len(song.Artists) != 1 {
+		t.Errorf("unexpected song: %+v", song)
+	}
+}
+
+func TestListSongsQuery(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		want := "after=20&genre=rock%2Cpop&limit=10&role=featured"
+		if got := r.URL.RawQuery; got != want {
+			t.Errorf("query = %q, want %q", got, want)
+		}
+
+		_, _ = io.WriteString(w, `[{"id": 21}, {"id": 22}]`)
+	})
+
+	songs, err := client.ListSongs(context.Background(), Page{After: 20, Limit: 10}, SongFilters{Genre: "rock,pop", Role: RoleFeatured})
+	if err != nil {
+		t.Fatalf("ListSongs: %v", err)
+	}
+
+	if len(songs) != 2 || songs[1].Id != 22 {
+		t.Errorf("unexpected songs: %+v", songs)
+	}
+}
+
+func TestPathEscaping(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if want := "/api/v2/songs/3/tags/rock%2Froll%20live"; r.URL.EscapedPath() != want {
+			t.Errorf("path = %q, want %q", r.URL.EscapedPath(), want)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	if err := client.AssignSongLabel(context.Background(), 3, Tags, "rock/roll live"); err != nil {
+		t.Fatalf("AssignSongLabel: %v", err)
+	}
+}
+
+func TestErrorDecoding(t *testing.T) {
+	tests := []struct {
+		name     string
+		handler  http.HandlerFunc
+		status   int
+		code     string
+		check    func(error) bool
+		fields   int
+		contains string
+	}{
+		{
+			name: "problem details",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				writeProblem(w, Problem{Type: "about:blank", Title: "Not Found", Status: http.StatusNotFound, Detail: "song not found", Code: "song_not_found"})
+			},
+			status:   http.StatusNotFound,
+			code:     "song_not_found",
+			check:    IsNotFound,
+			contains: "404 song_not_found: song not found",
+		},
+		{
+			name: "validation fields",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				writeProblem(w, Problem{Title: "Bad Request", Status: http.StatusBadRequest, Code: "invalid_request", Errors: []ProblemField{
+					{Field: "group", Rule: "required", Message: "group is required"},
+					{Field: "song", Rule: "min", Param: "2", Message: "song must be at least 2 characters"},
+				}})
+			},
+			status: http.StatusBadRequest,
+			code:   "invalid_request",
+			check:  IsValidation,
+			fields: 2,
+		},
+		{
+			name: "plain text",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "oops", http.StatusConflict)
+			},
+			status:   http.StatusConflict,
+			check:    IsConflict,
+			contains: "409: Conflict",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, tt.handler)
+
+			_, err := client.GetSong(context.Background(), 1)
+
+			var e *Error
+			if !errors.As(err, &e) {
+				t.Fatalf("error %v is not an *Error", err)
+			}
+
+			if e.StatusCode != tt.status || ErrorCode(err) != tt.code || !tt.check(err) {
+				t.Errorf("unexpected error: %+v", e)
+			}
+
+			if len(e.Errors) != tt.fields {
+				t.Errorf("fields = %d, want %d", len(e.Errors), tt.fields)
+			}
+
+			if !strings.Contains(err.Error(), tt.contains) {
+				t.Errorf("message %q does not contain %q", err.Error(), tt.contains)
+			}
+		})
+	}
+}
+
+func TestRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		call     func(*Client) error
+		attempts int32
+		ok       bool
+	}{
+		{
+			name:   "idempotent call after 503",
+			status: http.StatusServiceUnavailable,
+			call: func(c *Client) error {
+				_, err := c.GetSong(context.Background(), 1)
+				return err
+			},
+			attempts: 3,
+			ok:       true,
+		},
+		{
+			name:   "post is not repeated after 503",
+			status: http.StatusServiceUnavailable,
+			call: func(c *Client) error {
+				_, err := c.CreateSong(context.Background(), CreateSong{Group: "Muse", Song: "Uprising"})
+				return err
+			},
+			attempts: 1,
+		},
+		{
+			name:   "post is repeated after 429",
+			status: http.StatusTooManyRequests,
+			call: func(c *Client) error {
+				_, err := c.CreateSong(context.Background(), CreateSong{Group: "Muse", Song: "Uprising"})
+				return err
+			},
+			attempts: 3,
+			ok:       true,
+		},
+		{
+			name:   "streamed body is not repeated",
+			status: http.StatusTooManyRequests,
+			call: func(c *Client) error {
+				_, err := c.ImportSongs(context.Background(), FormatCSV, strings.NewReader("group,song\n"), ImportSongsOptions{})
+				return err
+			},
+			attempts: 1,
+		},
+		{
+			name:   "client errors are not repeated",
+			status: http.StatusNotFound,
+			call: func(c *Client) error {
+				return c.DeleteSong(context.Background(), 1)
+			},
+			attempts: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+
+				if attempts.Add(1) < 3 {
+					w.Header().Set("Retry-After", "0")
+					writeProblem(w, Problem{Title: http.StatusText(tt.status), Status: tt.status, Code: "busy"})
+
+					return
+				}
+
+				if r.Method == http.MethodPost && len(body) == 0 {
+					t.Error("the repeated call lost its body")
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = io.WriteString(w, `{"id": 1}`)
+			})
+
+			err := tt.call(client)
+
+			if got := attempts.Load(); got != tt.attempts {
+				t.Errorf("attempts = %d, want %d", got, tt.attempts)
+			}
+
+			if (err == nil) != tt.ok {
+				t.Errorf("err = %v, want ok %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestRetryStopsWithContext(t *testing.T) {
+	var attempts atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := NewClient(&ConfigDeps{BaseURL: server.URL, MaxRetries: 5, RetryDelay: time.Hour})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err = client.ListGenres(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want deadline exceeded", err)
+	}
+
+	if got := attempts.Load(); got != 1 {
+		t.Errorf("attempts = %d, want 1", got)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryDelay time.Duration
+		attempt    int
+		want       time.Duration
+	}{
+		{name: "immediately", retryDelay: 0, attempt: 3, want: 0},
+		{name: "first retry", retryDelay: time.Second, attempt: 0, want: time.Second},
+		{name: "doubled", retryDelay: time.Second, attempt: 3, want: 8 * time.Second},
+		{name: "capped", retryDelay: time.Second, attempt: 5, want: maxRetryDelay},
+		{name: "overflow", retryDelay: time.Second, attempt: 70, want: maxRetryDelay},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClient(&ConfigDeps{BaseURL: "http://localhost", MaxRetries: 100, RetryDelay: tt.retryDelay})
+			if err != nil {
+				t.Fatalf("NewClient: %v", err)
+			}
+
+			retry, delay := client.shouldRetry(&request{route: routeListGenres}, nil, errors.New("connection refused"), tt.attempt)
+			if !retry || delay != tt.want {
+				t.Errorf("shouldRetry = %v, %v, want true, %v", retry, delay, tt.want)
+			}
+		})
+	}
+}
+
+func TestBatchRejected(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(BatchResponse{Failed: 1, Results: []BatchResult{{Index: 0, Op: OpDelete, Status: "failed", Error: "song not found"}}})
+	})
+
+	res, err := client.BatchSongs(context.Background(), BatchRequest{Operations: []BatchOperation{{Op: OpDelete, Id: 9}}})
+	if !errors.Is(err, ErrBatchRejected) {
+		t.Fatalf("err = %v, want ErrBatchRejected", err)
+	}
+
+	if res.Failed != 1 || len(res.Results) != 1 || res.Results[0].Error != "song not found" {
+		t.Errorf("unexpected results: %+v", res)
+	}
+}
+
+func TestExportPlaylist(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/playlists/4/export" || r.URL.Query().Get("format") != FormatM3U8 {
+			t.Errorf("unexpected call %s", r.URL)
+		}
+
+		w.Header().Set("Content-Type", "audio/x-mpegurl")
+		_, _ = io.WriteString(w, "#EXTM3U\n")
+	})
+
+	body, err := client.ExportPlaylist(context.Background(), 4, FormatM3U8)
+	if err != nil {
+		t.Fatalf("ExportPlaylist: %v", err)
+	}
+
+	defer body.Close()
+
+	data, _ := io.ReadAll(body)
+	if string(data) != "#EXTM3U\n" {
+		t.Errorf("body = %q", data)
+	}
+}
@@ -0,0 +1,78 @@
+package songlibclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// maxErrorBody - the most bytes of an error response read
+const maxErrorBody = 64 << 10
+
+// Error - the response of a failed call. The server describes its errors as RFC 7807 problem details,
+// a response without them has the status text as the title.
+type Error struct {
+	StatusCode int
+	Problem
+}
+
+func (e *Error) Error() string {
+	message := e.Detail
+	if message == "" {
+		message = e.Title
+	}
+
+	if e.Code == "" {
+		return fmt.Sprintf("songlibclient: %d: %s", e.StatusCode, message)
+	}
+
+	return fmt.Sprintf("songlibclient: %d %s: %s", e.StatusCode, e.Code, message)
+}
+
+// ErrorCode - the stable error code of the failed call, empty if err is not an *Error
+func ErrorCode(err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+
+	return ""
+}
+
+// IsNotFound - the resource of the call does not exist
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsConflict - the call conflicts with a saved resource, e.g. the song already exists
+func IsConflict(err error) bool {
+	return hasStatus(err, http.StatusConflict)
+}
+
+// IsValidation - the server rejected the request, the fields breaking the rules are in Errors
+func IsValidation(err error) bool {
+	return hasStatus(err, http.StatusBadRequest)
+}
+
+func hasStatus(err error, status int) bool {
+	var e *Error
+	return errors.As(err, &e) && e.StatusCode == status
+}
+
+func decodeError(resp *http.Response) error {
+	e := &Error{StatusCode: resp.StatusCode}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	if err == nil && json.Unmarshal(body, &e.Problem) == nil && e.Title != "" {
+		return e
+	}
+
+	e.Problem = Problem{
+		Title:  http.StatusText(resp.StatusCode),
+		Status: resp.StatusCode,
+	}
+
+	return e
+}
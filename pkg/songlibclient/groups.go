@@ -0,0 +1,69 @@
+package songlibclient
+
+import (
+	"context"
+)
+
+// ListGroups - a page of the music groups in id order, name matches the groups with the name containing it
+func (c *Client) ListGroups(ctx context.Context, page Page, name string) ([]Group, error) {
+	var res []Group
+
+	query := page.values()
+	setNotEmpty(query, "name", name)
+
+	return res, c.call(ctx, c.newRequest(routeListGroups).withQuery(query), &res)
+}
+
+func (c *Client) GetGroup(ctx context.Context, id int) (Group, error) {
+	var res Group
+
+	return res, c.call(ctx, c.newRequest(routeGetGroup, id), &res)
+}
+
+// ListGroupSongs - a page of the songs crediting the music group, with the role if it is not empty
+func (c *Client) ListGroupSongs(ctx context.Context, id int, page Page, role string) ([]Song, error) {
+	var res []Song
+
+	query := page.values()
+	setNotEmpty(query, "role", role)
+
+	return res, c.call(ctx, c.newRequest(routeListGroupSongs, id).withQuery(query), &res)
+}
+
+func (c *Client) ListGroupAlbums(ctx context.Context, id int, page Page) ([]Album, error) {
+	var res []Album
+
+	return res, c.call(ctx, c.newRequest(routeListGroupAlbums, id).withQuery(page.values()), &res)
+}
+
+// ListGenres - all the genres with the number of songs of each
+func (c *Client) ListGenres(ctx context.Context) ([]Label, error) {
+	var res []Label
+
+	return res, c.call(ctx, c.newRequest(routeListGenres), &res)
+}
+
+// ListTags - all the tags with the number of songs of each
+func (c *Client) ListTags(ctx context.Context) ([]Label, error) {
+	var res []Label
+
+	return res, c.call(ctx, c.newRequest(routeListTags), &res)
+}
+
+// AssignSongLabel - assign the genre or tag to the song, kinds is Genres or Tags
+func (c *Client) AssignSongLabel(ctx context.Context, id int, kinds string, name string) error {
+	return c.call(ctx, c.newRequest(routeAssignSongLabel, id, kinds, name), nil)
+}
+
+func (c *Client) RemoveSongLabel(ctx context.Context, id int, kinds string, name string) error {
+	return c.call(ctx, c.newRequest(routeRemoveSongLabel, id, kinds, name), nil)
+}
+
+// AssignGroupLabel - assign the genre or tag to the music group, kinds is Genres or Tags
+func (c *Client) AssignGroupLabel(ctx context.Context, id int, kinds string, name string) error {
+	return c.call(ctx, c.newRequest(routeAssignGroupLabel, id, kinds, name), nil)
+}
+
+func (c *Client) RemoveGroupLabel(ctx context.Context, id int, kinds string, name string) error {
+	return c.call(ctx, c.newRequest(routeRemoveGroupLabel, id, kinds, name), nil)
+}
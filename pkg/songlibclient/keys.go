@@ -0,0 +1,45 @@
+package songlibclient
+
+import (
+	"context"
+)
+
+// IssueAPIKey - issue a key with the role, the key is only shown in the result. It needs the admin role.
+func (c *Client) IssueAPIKey(ctx context.Context, key CreateAPIKey) (APIKey, error) {
+	var res APIKey
+
+	req, err := c.newRequest(routeIssueKey).withJSON(key)
+	if err != nil {
+		return res, err
+	}
+
+	return res, c.call(ctx, req, &res)
+}
+
+// ListAPIKeys - all the keys with the revoked ones, without the keys themselves
+func (c *Client) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+	var res []APIKey
+
+	return res, c.call(ctx, c.newRequest(routeListKeys), &res)
+}
+
+func (c *Client) RevokeAPIKey(ctx context.Context, id int) error {
+	return c.call(ctx, c.newRequest(routeRevokeKey, id), nil)
+}
+
+// ListAPIKeyWrites - a page of the writes made with the key in id order
+func (c *Client) ListAPIKeyWrites(ctx context.Context, id int, page Page) ([]APIKeyWrite, error) {
+	var res []APIKeyWrite
+
+	return res, c.call(ctx, c.newRequest(routeListKeyWrites, id).withQuery(page.values()), &res)
+}
+
+// ListSubjectWrites - a page of the writes made with the bearer tokens of the subject in id order
+func (c *Client) ListSubjectWrites(ctx context.Context, subject string, page Page) ([]APIKeyWrite, error) {
+	var res []APIKeyWrite
+
+	query := page.values()
+	query.Set("subject", subject)
+
+	return res, c.call(ctx, c.newRequest(routeListWrites).withQuery(query), &res)
+}
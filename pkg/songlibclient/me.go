@@ -0,0 +1,63 @@
+package songlibclient
+
+import (
+	"context"
+	"strconv"
+)
+
+// ListFavorites - a page of the favorite songs of the caller in id order
+func (c *Client) ListFavorites(ctx context.Context, page Page) ([]Song, error) {
+	var res []Song
+
+	return res, c.call(ctx, c.newRequest(routeListFavorites).withQuery(page.values()), &res)
+}
+
+// AddFavorite - add the song to the favorites of the caller, adding it again changes nothing
+func (c *Client) AddFavorite(ctx context.Context, songId int) error {
+	return c.call(ctx, c.newRequest(routeAddFavorite, songId), nil)
+}
+
+func (c *Client) RemoveFavorite(ctx context.Context, songId int) error {
+	return c.call(ctx, c.newRequest(routeRemoveFavorite, songId), nil)
+}
+
+// RateSong - rate the song from 1 to 5, replacing the previous rating of the caller
+func (c *Client) RateSong(ctx context.Context, songId int, rating int) (Rating, error) {
+	var res Rating
+
+	req, err := c.newRequest(routeRateSong, songId).withJSON(map[string]int{"rating": rating})
+	if err != nil {
+		return res, err
+	}
+
+	return res, c.call(ctx, req, &res)
+}
+
+func (c *Client) DeleteRating(ctx context.Context, songId int) error {
+	return c.call(ctx, c.newRequest(routeDeleteRating, songId), nil)
+}
+
+// ListPlays - a page of the listening history of the caller, the latest play first. The next page
+// is the one before the id of the last play, all of the latest ones if before is 0.
+func (c *Client) ListPlays(ctx context.Context, before int64, limit int) ([]Play, error) {
+	var res []Play
+
+	query := Page{Limit: limit}.values()
+	if before > 0 {
+		query.Set("before", strconv.FormatInt(before, 10))
+	}
+
+	return res, c.call(ctx, c.newRequest(routeListPlays).withQuery(query), &res)
+}
+
+// RecordPlay - add a play of the song to the listening history of the caller
+func (c *Client) RecordPlay(ctx context.Context, songId int) (Play, error) {
+	var res Play
+
+	req, err := c.newRequest(routeRecordPlay).withJSON(map[string]int{"song_id": songId})
+	if err != nil {
+		return res, err
+	}
+
+	return res, c.call(ctx, req, &res)
+}
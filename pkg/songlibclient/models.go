@@ -0,0 +1,298 @@
+package songlibclient
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Roles a music group can be credited with on a song
+const (
+	RolePrimary  = "primary"
+	RoleFeatured = "featured"
+	RoleComposer = "composer"
+	RoleLyricist = "lyricist"
+)
+
+// Classifications of the songs and the music groups
+const (
+	Genres = "genres"
+	Tags   = "tags"
+)
+
+type Problem struct {
+	Type     string         `json:"type"`
+	Title    string         `json:"title"`
+	Status   int            `json:"status"`
+	Detail   string         `json:"detail,omitempty"`
+	Instance string         `json:"instance,omitempty"`
+	Code     string         `json:"code"`
+	Errors   []ProblemField `json:"errors,omitempty"`
+}
+
+// ProblemField - a field of the request breaking the validation rule
+type ProblemField struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+type SongArtist struct {
+	Group string `json:"group"`
+	Role  string `json:"role"`
+}
+
+type Song struct {
+	Id          int          `json:"id"`
+	GroupSong   string       `json:"group_song"`
+	Song        string       `json:"song"`
+	ReleaseDate string       `json:"release_date"`
+	Text        string       `json:"text"`
+	Link        string       `json:"link"`
+	AlbumId     *int         `json:"album_id"`
+	TrackNumber *int         `json:"track_number"`
+	Artists     []SongArtist `json:"artists"`
+	Genres      []string     `json:"genres"`
+	Tags        []string     `json:"tags"`
+	Rating      float64      `json:"rating"`
+	Ratings     int          `json:"ratings"`
+	Plays       int64        `json:"plays"`
+	Favorites   int          `json:"favorites"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+type CreateSong struct {
+	Group       string       `json:"group"`
+	Song        string       `json:"song"`
+	AlbumId     int          `json:"album_id,omitempty"`
+	TrackNumber int          `json:"track_number,omitempty"`
+	Artists     []SongArtist `json:"artists,omitempty"`
+}
+
+// UpdateSong - the empty fields are left as they are, the artists replace the credits of the song if given
+type UpdateSong struct {
+	Song        string       `json:"song,omitempty"`
+	ReleaseDate string       `json:"release_date,omitempty"`
+	Text        string       `json:"text,omitempty"`
+	Link        string       `json:"link,omitempty"`
+	Artists     []SongArtist `json:"artists,omitempty"`
+}
+
+// Verse - a verse of the lyrics, numbered from 0
+type Verse struct {
+	SongId int    `json:"song_id"`
+	Verse  int    `json:"verse"`
+	Text   string `json:"text"`
+}
+
+type FacetCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type SongFacets struct {
+	Genres []FacetCount `json:"genres"`
+	Tags   []FacetCount `json:"tags"`
+}
+
+// Batch operations
+const (
+	OpCreate = "create"
+	OpUpdate = "update"
+	OpDelete = "delete"
+)
+
+type BatchOperation struct {
+	Op   string          `json:"op"`
+	Id   int             `json:"id,omitempty"`
+	Song json.RawMessage `json:"song,omitempty"`
+}
+
+type BatchRequest struct {
+	BestEffort bool             `json:"best_effort"`
+	Operations []BatchOperation `json:"operations"`
+}
+
+type BatchResult struct {
+	Index  int    `json:"index"`
+	Op     string `json:"op"`
+	Status string `json:"status"`
+	Id     int    `json:"id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// BatchResponse - succeeded counts the applied operations and failed the rest. In the atomic mode
+// either every operation is ok or none was applied.
+type BatchResponse struct {
+	BestEffort bool          `json:"best_effort"`
+	Succeeded  int           `json:"succeeded"`
+	Failed     int           `json:"failed"`
+	Results    []BatchResult `json:"results"`
+}
+
+type ImportRowResult struct {
+	Row    int    `json:"row"`
+	Status string `json:"status"`
+	Id     int    `json:"id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type ImportSongsReport struct {
+	DryRun    bool              `json:"dry_run"`
+	Created   int               `json:"created"`
+	Duplicate int               `json:"duplicate"`
+	Invalid   int               `json:"invalid"`
+	Failed    int               `json:"failed"`
+	Rows      []ImportRowResult `json:"rows"`
+}
+
+type Label struct {
+	Id    int    `json:"id"`
+	Name  string `json:"name"`
+	Songs int    `json:"songs"`
+}
+
+type Group struct {
+	Id        int       `json:"id"`
+	Name      string    `json:"name"`
+	Songs     int       `json:"songs"`
+	Albums    int       `json:"albums"`
+	Genres    []string  `json:"genres"`
+	Tags      []string  `json:"tags"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type Album struct {
+	Id          int          `json:"id"`
+	Group       string       `json:"group"`
+	Title       string       `json:"title"`
+	ReleaseDate string       `json:"release_date"`
+	CoverLink   string       `json:"cover_link"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+	Tracks      []AlbumTrack `json:"tracks,omitempty"`
+}
+
+type AlbumTrack struct {
+	TrackNumber int    `json:"track_number"`
+	SongId      int    `json:"song_id"`
+	Song        string `json:"song"`
+	ReleaseDate string `json:"release_date"`
+}
+
+type CreateAlbum struct {
+	Group       string `json:"group"`
+	Title       string `json:"title"`
+	ReleaseDate string `json:"release_date,omitempty"`
+	CoverLink   string `json:"cover_link,omitempty"`
+}
+
+type UpdateAlbum struct {
+	Title       string `json:"title"`
+	ReleaseDate string `json:"release_date,omitempty"`
+	CoverLink   string `json:"cover_link,omitempty"`
+}
+
+type Playlist struct {
+	Id          int             `json:"id"`
+	Name        string          `json:"name"`
+	Owner       string          `json:"owner"`
+	Description string          `json:"description"`
+	Songs       int             `json:"songs"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	Entries     []PlaylistEntry `json:"entries,omitempty"`
+}
+
+type PlaylistEntry struct {
+	Position  int    `json:"position"`
+	SongId    int    `json:"song_id"`
+	GroupSong string `json:"group_song"`
+	Song      string `json:"song"`
+	Link      string `json:"link"`
+}
+
+type CreatePlaylist struct {
+	Name        string `json:"name"`
+	Owner       string `json:"owner"`
+	Description string `json:"description,omitempty"`
+	Songs       []int  `json:"songs,omitempty"`
+}
+
+type UpdatePlaylist struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// CopyPlaylist - the copy keeps the name and owner of the original unless they are given
+type CopyPlaylist struct {
+	Name  string `json:"name,omitempty"`
+	Owner string `json:"owner,omitempty"`
+}
+
+type TrackRef struct {
+	Position int    `json:"position"`
+	Group    string `json:"group"`
+	Song     string `json:"song"`
+	Location string `json:"location"`
+}
+
+type ImportPlaylistResult struct {
+	Id        int        `json:"id"`
+	Matched   int        `json:"matched"`
+	Unmatched []TrackRef `json:"unmatched"`
+}
+
+// Roles of the API keys, each one allows everything the previous one does
+const (
+	KeyRoleReader = "reader"
+	KeyRoleEditor = "editor"
+	KeyRoleAdmin  = "admin"
+)
+
+type CreateAPIKey struct {
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+// APIKey - Key is only set in the response issuing the key, the server keeps its hash only
+type APIKey struct {
+	Id               int        `json:"id"`
+	Name             string     `json:"name"`
+	Role             string     `json:"role"`
+	Prefix           string     `json:"prefix"`
+	Key              string     `json:"key,omitempty"`
+	CreatedBy        *int       `json:"created_by"`
+	CreatedBySubject string     `json:"created_by_subject,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	RevokedAt        *time.Time `json:"revoked_at"`
+}
+
+// APIKeyWrite - a write of the API attributed to the key, or to the subject of the bearer token
+type APIKeyWrite struct {
+	Id        int       `json:"id"`
+	KeyId     int       `json:"key_id"`
+	Subject   string    `json:"subject,omitempty"`
+	Method    string    `json:"method"`
+	Route     string    `json:"route"`
+	Path      string    `json:"path"`
+	Status    int       `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type Rating struct {
+	SongId    int       `json:"song_id"`
+	Rating    int       `json:"rating"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Play - a play of the listening history
+type Play struct {
+	Id        int64     `json:"id"`
+	SongId    int       `json:"song_id"`
+	GroupSong string    `json:"group_song"`
+	Song      string    `json:"song"`
+	PlayedAt  time.Time `json:"played_at"`
+}
@@ -0,0 +1,66 @@
+package songlibclient
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// Page - the page of a listing: the resources with the id greater than After, Limit of them
+// (20 if 0, at most 100). A page shorter than the limit is the last one.
+type Page struct {
+	After int
+	Limit int
+}
+
+func (p Page) values() url.Values {
+	query := make(url.Values)
+
+	if p.After > 0 {
+		query.Set("after", strconv.Itoa(p.After))
+	}
+
+	if p.Limit > 0 {
+		query.Set("limit", strconv.Itoa(p.Limit))
+	}
+
+	return query
+}
+
+// SongFilters - the filters of the song listing, the facets and the export. Genre and Tag are
+// comma separated, a song of any of them matches.
+type SongFilters struct {
+	Filter        string
+	Value         string
+	CreatedAfter  string
+	CreatedBefore string
+	UpdatedAfter  string
+	UpdatedBefore string
+	Artist        string
+	Role          string
+	Genre         string
+	Tag           string
+	// Sort - rating, plays or favorites, a '-' before it sorts in descending order; only the listing sorts
+	Sort string
+}
+
+func (f SongFilters) addTo(query url.Values) url.Values {
+	setNotEmpty(query, "filter", f.Filter)
+	setNotEmpty(query, "value", f.Value)
+	setNotEmpty(query, "created_after", f.CreatedAfter)
+	setNotEmpty(query, "created_before", f.CreatedBefore)
+	setNotEmpty(query, "updated_after", f.UpdatedAfter)
+	setNotEmpty(query, "updated_before", f.UpdatedBefore)
+	setNotEmpty(query, "artist", f.Artist)
+	setNotEmpty(query, "role", f.Role)
+	setNotEmpty(query, "genre", f.Genre)
+	setNotEmpty(query, "tag", f.Tag)
+	setNotEmpty(query, "sort", f.Sort)
+
+	return query
+}
+
+func setNotEmpty(query url.Values, key string, value string) {
+	if value != "" {
+		query.Set(key, value)
+	}
+}
@@ -0,0 +1,119 @@
+package songlibclient
+
+import (
+	"context"
+	"io"
+	"net/url"
+)
+
+// Playlist formats of the import and the export
+const (
+	FormatM3U8 = "m3u8"
+	FormatXSPF = "xspf"
+	FormatJSPF = "jspf"
+)
+
+// ListPlaylists - a page of the playlists in id order, of the owner if it is not empty
+func (c *Client) ListPlaylists(ctx context.Context, page Page, owner string) ([]Playlist, error) {
+	var res []Playlist
+
+	query := page.values()
+	setNotEmpty(query, "owner", owner)
+
+	return res, c.call(ctx, c.newRequest(routeListPlaylists).withQuery(query), &res)
+}
+
+func (c *Client) CreatePlaylist(ctx context.Context, playlist CreatePlaylist) (Playlist, error) {
+	var res Playlist
+
+	req, err := c.newRequest(routeCreatePlaylist).withJSON(playlist)
+	if err != nil {
+		return res, err
+	}
+
+	return res, c.call(ctx, req, &res)
+}
+
+// GetPlaylist - the playlist with its ordered entries
+func (c *Client) GetPlaylist(ctx context.Context, id int) (Playlist, error) {
+	var res Playlist
+
+	return res, c.call(ctx, c.newRequest(routeGetPlaylist, id), &res)
+}
+
+func (c *Client) UpdatePlaylist(ctx context.Context, id int, playlist UpdatePlaylist) (Playlist, error) {
+	var res Playlist
+
+	req, err := c.newRequest(routeUpdatePlaylist, id).withJSON(playlist)
+	if err != nil {
+		return res, err
+	}
+
+	return res, c.call(ctx, req, &res)
+}
+
+func (c *Client) DeletePlaylist(ctx context.Context, id int) error {
+	return c.call(ctx, c.newRequest(routeDeletePlaylist, id), nil)
+}
+
+func (c *Client) CopyPlaylist(ctx context.Context, id int, playlist CopyPlaylist) (Playlist, error) {
+	var res Playlist
+
+	req, err := c.newRequest(routeCopyPlaylist, id).withJSON(playlist)
+	if err != nil {
+		return res, err
+	}
+
+	return res, c.call(ctx, req, &res)
+}
+
+// AddEntries - insert the songs starting at the position, 0 appends them to the end
+func (c *Client) AddEntries(ctx context.Context, id int, songIds []int, position int) (Playlist, error) {
+	var res Playlist
+
+	body := struct {
+		Songs    []int `json:"songs"`
+		Position int   `json:"position"`
+	}{songIds, position}
+
+	req, err := c.newRequest(routeAddEntries, id).withJSON(body)
+	if err != nil {
+		return res, err
+	}
+
+	return res, c.call(ctx, req, &res)
+}
+
+// MoveEntry - move the entry at the position from to the position to, the positions start at 1
+func (c *Client) MoveEntry(ctx context.Context, id int, from int, to int) (Playlist, error) {
+	var res Playlist
+
+	req, err := c.newRequest(routeMoveEntry, id, from).withJSON(map[string]int{"to": to})
+	if err != nil {
+		return res, err
+	}
+
+	return res, c.call(ctx, req, &res)
+}
+
+func (c *Client) RemoveEntry(ctx context.Context, id int, position int) error {
+	return c.call(ctx, c.newRequest(routeRemoveEntry, id, position), nil)
+}
+
+// ImportPlaylist - create a playlist of the owner from a playlist file, name overrides the title of the file.
+// The file is streamed, so the call is not retried.
+func (c *Client) ImportPlaylist(ctx context.Context, format string, owner string, name string, file io.Reader) (ImportPlaylistResult, error) {
+	var res ImportPlaylistResult
+
+	query := url.Values{"format": {format}, "owner": {owner}}
+	setNotEmpty(query, "name", name)
+
+	req := c.newRequest(routeImportPlaylist).withQuery(query).withStream(file, "text/plain")
+
+	return res, c.call(ctx, req, &res)
+}
+
+// ExportPlaylist - the playlist file in the format, the caller closes the returned body
+func (c *Client) ExportPlaylist(ctx context.Context, id int, format string) (io.ReadCloser, error) {
+	return c.stream(ctx, c.newRequest(routeExportPlaylist, id).withQuery(url.Values{"format": {format}}))
+}
@@ -0,0 +1,109 @@
+package songlibclient
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// route - the method and the path template of an /api/v2 route as it is in docs/swagger.yaml
+type route struct {
+	method string
+	path   string
+}
+
+var (
+	routeListSongs  = route{http.MethodGet, "/songs"}
+	routeCreateSong = route{http.MethodPost, "/songs"}
+	routeSongFacets = route{http.MethodGet, "/songs/facets"}
+	routeSearchSong = route{http.MethodGet, "/songs/search"}
+	routeBatchSongs = route{http.MethodPost, "/songs/batch"}
+	routeImportSong = route{http.MethodPost, "/songs/import"}
+	routeExportSong = route{http.MethodGet, "/songs/export"}
+	routeGetSong    = route{http.MethodGet, "/songs/{id}"}
+	routeUpdateSong = route{http.MethodPut, "/songs/{id}"}
+	routeDeleteSong = route{http.MethodDelete, "/songs/{id}"}
+	routeGetVerse   = route{http.MethodGet, "/songs/{id}/verses/{verse}"}
+
+	routeAssignSongLabel  = route{http.MethodPut, "/songs/{id}/{kinds}/{name}"}
+	routeRemoveSongLabel  = route{http.MethodDelete, "/songs/{id}/{kinds}/{name}"}
+	routeAssignGroupLabel = route{http.MethodPut, "/groups/{id}/{kinds}/{name}"}
+	routeRemoveGroupLabel = route{http.MethodDelete, "/groups/{id}/{kinds}/{name}"}
+	routeListGenres       = route{http.MethodGet, "/genres"}
+	routeListTags         = route{http.MethodGet, "/tags"}
+
+	routeListGroups      = route{http.MethodGet, "/groups"}
+	routeGetGroup        = route{http.MethodGet, "/groups/{id}"}
+	routeListGroupSongs  = route{http.MethodGet, "/groups/{id}/songs"}
+	routeListGroupAlbums = route{http.MethodGet, "/groups/{id}/albums"}
+
+	routeListAlbums     = route{http.MethodGet, "/albums"}
+	routeCreateAlbum    = route{http.MethodPost, "/albums"}
+	routeGetAlbum       = route{http.MethodGet, "/albums/{id}"}
+	routeUpdateAlbum    = route{http.MethodPut, "/albums/{id}"}
+	routeDeleteAlbum    = route{http.MethodDelete, "/albums/{id}"}
+	routeSetAlbumTracks = route{http.MethodPut, "/albums/{id}/tracks"}
+
+	routeListPlaylists  = route{http.MethodGet, "/playlists"}
+	routeCreatePlaylist = route{http.MethodPost, "/playlists"}
+	routeImportPlaylist = route{http.MethodPost, "/playlists/import"}
+	routeGetPlaylist    = route{http.MethodGet, "/playlists/{id}"}
+	routeUpdatePlaylist = route{http.MethodPut, "/playlists/{id}"}
+	routeDeletePlaylist = route{http.MethodDelete, "/playlists/{id}"}
+	routeCopyPlaylist   = route{http.MethodPost, "/playlists/{id}/copies"}
+	routeAddEntries     = route{http.MethodPost, "/playlists/{id}/entries"}
+	routeMoveEntry      = route{http.MethodPatch, "/playlists/{id}/entries/{position}"}
+	routeRemoveEntry    = route{http.MethodDelete, "/playlists/{id}/entries/{position}"}
+	routeExportPlaylist = route{http.MethodGet, "/playlists/{id}/export"}
+
+	routeListFavorites  = route{http.MethodGet, "/me/favorites"}
+	routeAddFavorite    = route{http.MethodPut, "/me/favorites/{id}"}
+	routeRemoveFavorite = route{http.MethodDelete, "/me/favorites/{id}"}
+	routeRateSong       = route{http.MethodPut, "/me/ratings/{id}"}
+	routeDeleteRating   = route{http.MethodDelete, "/me/ratings/{id}"}
+	routeListPlays      = route{http.MethodGet, "/me/plays"}
+	routeRecordPlay     = route{http.MethodPost, "/me/plays"}
+
+	routeListKeys      = route{http.MethodGet, "/admin/keys"}
+	routeIssueKey      = route{http.MethodPost, "/admin/keys"}
+	routeRevokeKey     = route{http.MethodDelete, "/admin/keys/{id}"}
+	routeListKeyWrites = route{http.MethodGet, "/admin/keys/{id}/writes"}
+	routeListWrites    = route{http.MethodGet, "/admin/writes"}
+)
+
+// routes - every route of the client, the tests compare them with docs/swagger.yaml
+var routes = []route{
+	routeListSongs, routeCreateSong, routeSongFacets, routeSearchSong, routeBatchSongs, routeImportSong, routeExportSong,
+	routeGetSong, routeUpdateSong, routeDeleteSong, routeGetVerse,
+	routeAssignSongLabel, routeRemoveSongLabel, routeAssignGroupLabel, routeRemoveGroupLabel,
+	routeListGenres, routeListTags,
+	routeListGroups, routeGetGroup, routeListGroupSongs, routeListGroupAlbums,
+	routeListAlbums, routeCreateAlbum, routeGetAlbum, routeUpdateAlbum, routeDeleteAlbum, routeSetAlbumTracks,
+	routeListPlaylists, routeCreatePlaylist, routeImportPlaylist, routeGetPlaylist, routeUpdatePlaylist,
+	routeDeletePlaylist, routeCopyPlaylist, routeAddEntries, routeMoveEntry, routeRemoveEntry, routeExportPlaylist,
+	routeListFavorites, routeAddFavorite, routeRemoveFavorite, routeRateSong, routeDeleteRating, routeListPlays, routeRecordPlay,
+	routeListKeys, routeIssueKey, routeRevokeKey, routeListKeyWrites, routeListWrites,
+}
+
+// expand - the path with its {params} replaced by the values in order, the values are escaped
+func (r route) expand(values ...interface{}) string {
+	path := r.path
+
+	for _, value := range values {
+		start := strings.IndexByte(path, '{')
+		end := strings.IndexByte(path, '}')
+
+		if start < 0 || end < start {
+			panic(fmt.Sprintf("songlibclient: too many values for %s", r.path))
+		}
+
+		path = path[:start] + url.PathEscape(fmt.Sprint(value)) + path[end+1:]
+	}
+
+	if strings.IndexByte(path, '{') >= 0 {
+		panic(fmt.Sprintf("songlibclient: missing values for %s", r.path))
+	}
+
+	return path
+}
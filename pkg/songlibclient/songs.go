@@ -0,0 +1,175 @@
+package songlibclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Song export formats
+const (
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+	FormatCSV    = "csv"
+)
+
+// ErrBatchRejected - the atomic batch was not applied, the results tell which operations failed
+var ErrBatchRejected = errors.New("songlibclient: batch rejected")
+
+// ListSongs - a page of the songs matching the filters, in id order
+func (c *Client) ListSongs(ctx context.Context, page Page, filters SongFilters) ([]Song, error) {
+	var res []Song
+
+	req := c.newRequest(routeListSongs).withQuery(filters.addTo(page.values()))
+
+	return res, c.call(ctx, req, &res)
+}
+
+// SongFacets - the genre and tag counts of all the songs matching the filters, the dates are ignored
+func (c *Client) SongFacets(ctx context.Context, filters SongFilters) (SongFacets, error) {
+	var res SongFacets
+
+	req := c.newRequest(routeSongFacets).withQuery(filters.addTo(make(url.Values)))
+
+	return res, c.call(ctx, req, &res)
+}
+
+// SearchSongs - the songs with the title, the primary group or the lyrics containing the text, at most limit
+// of them (20 if 0)
+func (c *Client) SearchSongs(ctx context.Context, text string, limit int) ([]Song, error) {
+	var res []Song
+
+	query := url.Values{"q": {text}}
+
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+
+	return res, c.call(ctx, c.newRequest(routeSearchSong).withQuery(query), &res)
+}
+
+func (c *Client) CreateSong(ctx context.Context, song CreateSong) (Song, error) {
+	var res Song
+
+	req, err := c.newRequest(routeCreateSong).withJSON(song)
+	if err != nil {
+		return res, err
+	}
+
+	return res, c.call(ctx, req, &res)
+}
+
+func (c *Client) GetSong(ctx context.Context, id int) (Song, error) {
+	var res Song
+
+	return res, c.call(ctx, c.newRequest(routeGetSong, id), &res)
+}
+
+func (c *Client) UpdateSong(ctx context.Context, id int, song UpdateSong) (Song, error) {
+	var res Song
+
+	req, err := c.newRequest(routeUpdateSong, id).withJSON(song)
+	if err != nil {
+		return res, err
+	}
+
+	return res, c.call(ctx, req, &res)
+}
+
+func (c *Client) DeleteSong(ctx context.Context, id int) error {
+	return c.call(ctx, c.newRequest(routeDeleteSong, id), nil)
+}
+
+// GetVerse - the verse of the lyrics numbered from 0
+func (c *Client) GetVerse(ctx context.Context, id int, verse int) (Verse, error) {
+	var res Verse
+
+	return res, c.call(ctx, c.newRequest(routeGetVerse, id, verse), &res)
+}
+
+// BatchSongs - apply the operations in one call. A rejected atomic batch returns its results
+// together with ErrBatchRejected.
+func (c *Client) BatchSongs(ctx context.Context, batch BatchRequest) (BatchResponse, error) {
+	var res BatchResponse
+
+	req, err := c.newRequest(routeBatchSongs).withJSON(batch)
+	if err != nil {
+		return res, err
+	}
+
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return res, err
+	}
+
+	defer resp.Body.Close()
+
+	rejected := resp.StatusCode == http.StatusBadRequest && mediaType(resp) == "application/json"
+
+	if resp.StatusCode >= http.StatusBadRequest && !rejected {
+		return res, decodeError(resp)
+	}
+
+	if err = json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return res, fmt.Errorf("songlibclient: decode response: %w", err)
+	}
+
+	if rejected {
+		return res, ErrBatchRejected
+	}
+
+	return res, nil
+}
+
+// ImportSongsOptions - DryRun checks the rows without saving them, Enrich fills the missing details
+// from the music info service, BatchSize is the number of rows saved in one transaction (100 if 0)
+type ImportSongsOptions struct {
+	DryRun    bool
+	Enrich    bool
+	BatchSize int
+}
+
+// ImportSongs - import the songs of a csv or ndjson file. The file is streamed, so the call is not retried.
+func (c *Client) ImportSongs(ctx context.Context, format string, file io.Reader, opts ImportSongsOptions) (ImportSongsReport, error) {
+	var res ImportSongsReport
+
+	contentType, ok := importTypes[format]
+	if !ok {
+		return res, fmt.Errorf("songlibclient: unknown import format: %s", format)
+	}
+
+	query := url.Values{"format": {format}}
+
+	if opts.DryRun {
+		query.Set("dry_run", "true")
+	}
+
+	if opts.Enrich {
+		query.Set("enrich", "true")
+	}
+
+	if opts.BatchSize > 0 {
+		query.Set("batch_size", strconv.Itoa(opts.BatchSize))
+	}
+
+	req := c.newRequest(routeImportSong).withQuery(query).withStream(file, contentType)
+
+	return res, c.call(ctx, req, &res)
+}
+
+var importTypes = map[string]string{
+	FormatCSV:    "text/csv",
+	FormatNDJSON: "application/x-ndjson",
+}
+
+// ExportSongs - the songs matching the filters in the format, the caller closes the returned body
+func (c *Client) ExportSongs(ctx context.Context, format string, filters SongFilters) (io.ReadCloser, error) {
+	query := filters.addTo(url.Values{"format": {format}})
+
+	return c.stream(ctx, c.newRequest(routeExportSong).withQuery(query))
+}
@@ -0,0 +1,159 @@
+// Package tracing - the OpenTelemetry traces of the service, exported with OTLP or printed to stdout,
+// and the W3C trace context propagated through the HTTP requests.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// The exporters of the spans
+const (
+	ExporterNone   = ""
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+const instrumentationName = "github.com/Magic-Kot/effective-mobile/pkg/tracing"
+
+var errUnknownExporter = errors.New("tracing: unknown exporter")
+
+type ConfigDeps struct {
+	// Exporter - otlp, stdout, or empty to keep only the propagation of the trace context
+	Exporter string
+	// Endpoint - the host:port of the OTLP collector receiving gRPC
+	Endpoint string
+	// Insecure - send to the collector without TLS
+	Insecure bool
+	// SampleRatio - the part of the traces started here that are kept, the sampling of the caller is followed
+	SampleRatio float64
+	ServiceName string
+}
+
+// Provider - the spans of the service, the Shutdown sends the ones still buffered
+type Provider struct {
+	provider *sdktrace.TracerProvider
+}
+
+// NewProvider - set the global tracer provider and the W3C trace context propagator. Without an exporter
+// no span is recorded, but the trace context of the requests is still passed on to the outbound calls.
+func NewProvider(ctx context.Context, deps *ConfigDeps) (*Provider, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var (
+		exporter sdktrace.SpanExporter
+		err      error
+	)
+
+	switch deps.Exporter {
+	case ExporterNone:
+		return &Provider{}, nil
+	case ExporterOTLP:
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(deps.Endpoint)}
+		if deps.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+
+		exporter, err = otlptracegrpc.New(ctx, opts...)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New()
+	default:
+		return nil, fmt.Errorf("%w: %q", errUnknownExporter, deps.Exporter)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("tracing: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(deps.ServiceName)))
+	if err != nil {
+		return nil, fmt.Errorf("tracing: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(deps.SampleRatio))),
+	)
+
+	otel.SetTracerProvider(provider)
+
+	return &Provider{provider: provider}, nil
+}
+
+func (p *Provider) Shutdown(ctx context.Context) error {
+	if p.provider == nil {
+		return nil
+	}
+
+	return p.provider.Shutdown(ctx)
+}
+
+// SetError - mark the span as failed with the error, if there is one
+func SetError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// transport - starts a client span for every request and sends its trace context in the headers
+type transport struct {
+	base   http.RoundTripper
+	tracer trace.Tracer
+}
+
+// NewTransport - the base transport with the spans of the outbound requests, http.DefaultTransport if nil
+func NewTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &transport{
+		base:   base,
+		tracer: otel.Tracer(instrumentationName),
+	}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := t.tracer.Start(req.Context(), "HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(req.Method),
+			semconv.URLFull(req.URL.Redacted()),
+			semconv.ServerAddress(req.URL.Hostname()),
+		),
+	)
+	defer span.End()
+
+	// the request must not be changed by the transport, so the headers are set on a clone
+	req = req.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		SetError(span, err)
+		return nil, err
+	}
+
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+
+	return resp, nil
+}
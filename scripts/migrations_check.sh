@@ -0,0 +1,34 @@
+#!/usr/bin/env bash
+# Applies every migration, rolls all of them back and applies them again
+# against a throwaway Postgres container, so broken Down blocks fail locally.
+set -euo pipefail
+
+CONTAINER=song-library-migrations-check
+PORT=${PORT_POSTGRES:-55432}
+
+cleanup() {
+  docker rm -f "$CONTAINER" >/dev/null 2>&1 || true
+}
+trap cleanup EXIT
+
+docker run --name "$CONTAINER" -e POSTGRES_PASSWORD=12345 -p "$PORT":5432 -d postgres >/dev/null
+
+export HOST_POSTGRES=127.0.0.1
+export PORT_POSTGRES=$PORT
+export PASSWORD_POSTGRES=12345
+export DELAY=2s
+export MAX_ATTEMPTS=15
+
+migrate() {
+  go run ./cmd migrate "$@"
+}
+
+migrate up
+migrate status
+migrate redo
+migrate down-to 0
+migrate status
+migrate up
+migrate version
+
+echo "migrations check passed"
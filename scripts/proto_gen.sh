@@ -0,0 +1,9 @@
+#!/usr/bin/env bash
+# Regenerates the Go code of the gRPC API in pkg/api from the definitions in proto/.
+# Needs protoc, protoc-gen-go and protoc-gen-go-grpc on the PATH.
+set -euo pipefail
+
+protoc -I proto \
+  --go_out=pkg/api --go_opt=paths=source_relative \
+  --go-grpc_out=pkg/api --go-grpc_opt=paths=source_relative \
+  library/v1/library.proto